@@ -0,0 +1,215 @@
+package qframe
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/math/float"
+	qfsort "github.com/yistabraq/qframe/internal/sort"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// aggregate applies fn to each of the groups in indices, built in aggregations available
+// for several column types are handled here, everything else is delegated to the column.
+func aggregate(col column.Column, indices []index.Int, fn types.SliceFuncOrBuiltInId) (column.Column, error) {
+	if fn == "count" {
+		// Special convenience case for "count" which would normally require a cast from
+		// any other type of column to int before being executed.
+		counts := make([]int, len(indices))
+		for i, ix := range indices {
+			counts[i] = len(ix)
+		}
+
+		return icolumn.New(counts), nil
+	}
+
+	if name, ok := fn.(string); ok {
+		result, handled, err := aggregateBuiltIn(col, indices, name)
+		if handled {
+			return result, err
+		}
+	}
+
+	return col.Aggregate(indices, fn)
+}
+
+// Built in aggregations that are available for several column types. They are implemented
+// here, rather than in the column packages, since the result may be of a different type than
+// the aggregated column. Null values are ignored by all of them.
+//
+//	first, last - The first/last non null value in the group. Any column type.
+//	mode        - The most common value in the group, the smallest value on ties. Any column type.
+//	nunique     - The number of distinct non null values in the group, an int. Any column type.
+//	median      - The median of the values in the group, a float. Int and float columns.
+//	quantile(p) - The p quantile, 0 <= p <= 1, of the values in the group using linear
+//	              interpolation between the closest values, a float. Int and float columns.
+//	std, var    - The sample standard deviation/variance of the values in the group, a float.
+//	              Int and float columns.
+//
+// Groups without any non null values produce null, except for nunique which produces 0.
+func aggregateBuiltIn(col column.Column, indices []index.Int, name string) (column.Column, bool, error) {
+	switch name {
+	case "first", "last", "mode":
+		result, err := aggregatePick(col, indices, name)
+		return result, true, err
+	case "nunique":
+		return aggregateNUnique(col, indices), true, nil
+	case "median":
+		result, err := aggregateFloat(col, indices, quantileFn(0.5))
+		return result, true, err
+	case "std":
+		result, err := aggregateFloat(col, indices, func(values []float64) float64 {
+			return math.Sqrt(float.Variance(values))
+		})
+		return result, true, err
+	case "var":
+		result, err := aggregateFloat(col, indices, float.Variance)
+		return result, true, err
+	}
+
+	if strings.HasPrefix(name, "quantile(") && strings.HasSuffix(name, ")") {
+		p, err := strconv.ParseFloat(name[len("quantile("):len(name)-1], 64)
+		if err != nil || p < 0 || p > 1 {
+			return nil, true, qerrors.New("aggregate", "invalid quantile, must be between 0 and 1: %s", name)
+		}
+
+		result, err := aggregateFloat(col, indices, quantileFn(p))
+		return result, true, err
+	}
+
+	return nil, false, nil
+}
+
+// isNull uses the fact that null is the only value that is not equal to itself
+// when nulls are not considered equal.
+func isNull(comp column.Comparable, i uint32) bool {
+	return comp.Compare(i, i) == column.NotEqual
+}
+
+// aggregatePick picks one of the values in each group.
+func aggregatePick(col column.Column, indices []index.Int, name string) (column.Column, error) {
+	comp := col.Comparable(false, false, false)
+	ix := make(index.Int, len(indices))
+	for i, group := range indices {
+		ix[i] = noMatch
+		switch name {
+		case "first":
+			for _, j := range group {
+				if !isNull(comp, j) {
+					ix[i] = j
+					break
+				}
+			}
+		case "last":
+			for k := len(group) - 1; k >= 0; k-- {
+				if !isNull(comp, group[k]) {
+					ix[i] = group[k]
+					break
+				}
+			}
+		case "mode":
+			bestCount := 0
+			forEachRun(comp, group, func(run index.Int) {
+				if len(run) > bestCount {
+					ix[i], bestCount = run[0], len(run)
+				}
+			})
+		}
+	}
+
+	return subsetWithNull(col, ix, uint32(col.Len()))
+}
+
+func aggregateNUnique(col column.Column, indices []index.Int) column.Column {
+	comp := col.Comparable(false, false, false)
+	counts := make([]int, len(indices))
+	for i, group := range indices {
+		forEachRun(comp, group, func(index.Int) { counts[i]++ })
+	}
+
+	return icolumn.New(counts)
+}
+
+// forEachRun calls fn for each set of equal, non null, values in group in ascending order.
+func forEachRun(comp column.Comparable, group index.Int, fn func(run index.Int)) {
+	sorted := group.Copy()
+	qfsort.New(sorted, []column.Comparable{comp}).Sort()
+
+	// Nulls are sorted first
+	start := 0
+	for start < len(sorted) && isNull(comp, sorted[start]) {
+		start++
+	}
+
+	for start < len(sorted) {
+		end := start + 1
+		for end < len(sorted) && comp.Compare(sorted[start], sorted[end]) == column.Equal {
+			end++
+		}
+
+		fn(sorted[start:end])
+		start = end
+	}
+}
+
+func quantileFn(p float64) func([]float64) float64 {
+	return func(values []float64) float64 {
+		sort.Float64s(values)
+		return float.Quantile(values, p)
+	}
+}
+
+// aggregateFloat applies fn to the non null values of each group of an int or float column.
+func aggregateFloat(col column.Column, indices []index.Int, fn func([]float64) float64) (column.Column, error) {
+	valueAt, err := floatValueAt(col)
+	if err != nil {
+		return nil, qerrors.Propagate("aggregate", err)
+	}
+
+	result := make([]float64, len(indices))
+	var buf []float64
+	for i, group := range indices {
+		buf = buf[:0]
+		for _, j := range group {
+			if v, ok := valueAt(j); ok {
+				buf = append(buf, v)
+			}
+		}
+
+		if len(buf) == 0 {
+			result[i] = math.NaN()
+			continue
+		}
+
+		result[i] = fn(buf)
+	}
+
+	return fcolumn.New(result), nil
+}
+
+// floatValueAt returns a function that returns the value at a row of an int or float column as
+// a float together with a bool that is false if the value is null.
+func floatValueAt(col column.Column) (func(i uint32) (float64, bool), error) {
+	switch c := col.(type) {
+	case icolumn.Column:
+		view := c.View(index.NewAscending(uint32(c.Len())))
+		return func(i uint32) (float64, bool) {
+			return float64(view.ItemAt(int(i))), !view.IsNull(int(i))
+		}, nil
+	case fcolumn.Column:
+		view := c.View(index.NewAscending(uint32(c.Len())))
+		return func(i uint32) (float64, bool) {
+			v := view.ItemAt(int(i))
+			return v, !math.IsNaN(v)
+		}, nil
+	}
+
+	return nil, qerrors.New("floatValueAt", "only defined for int and float columns, not %s", col.DataType())
+}
@@ -0,0 +1,10 @@
+package aggregation
+
+import "strconv"
+
+// Quantile returns the name of the built in aggregation that computes
+// the p quantile, 0 <= p <= 1, of int and float columns. The result
+// is a float column.
+func Quantile(p float64) string {
+	return "quantile(" + strconv.FormatFloat(p, 'g', -1, 64) + ")"
+}
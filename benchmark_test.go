@@ -286,6 +286,29 @@ func BenchmarkQFrame_ReadCSV(b *testing.B) {
 	}
 }
 
+func BenchmarkQFrame_ReadCSVParallel(b *testing.B) {
+	rowCount := 100000
+	input := csvBytes(rowCount)
+
+	for _, p := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Parallelism %d", p), func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r := bytes.NewReader(input)
+				df := qf.ReadCSV(r, csv.Parallelism(p))
+				if df.Err != nil {
+					b.Errorf("Unexpected CSV error: %s", df.Err)
+				}
+
+				if df.Len() != rowCount {
+					b.Errorf("Unexpected size: %d", df.Len())
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkQFrame_ReadCSVEnum(b *testing.B) {
 	rowCount := 100000
 	cardinality := 20
@@ -659,12 +682,15 @@ func BenchmarkGroupBy(b *testing.B) {
 		cardinality2 int
 		cardinality3 int
 		cols         []string
+		parallelism  int
 	}{
 		{name: "single col", size: 100000, cardinality1: 1000, cardinality2: 10, cardinality3: 2, cols: []string{"COL1"}},
 		{name: "triple col", size: 100000, cardinality1: 1000, cardinality2: 10, cardinality3: 2, cols: []string{"COL1", "COL2", "COL3"}},
 		{name: "high cardinality", size: 100000, cardinality1: 50000, cardinality2: 1, cardinality3: 1, cols: []string{"COL1"}},
 		{name: "low cardinality", size: 100000, cardinality1: 5, cardinality2: 1, cardinality3: 1, cols: []string{"COL1"}},
 		{name: "small frame", size: 100, cardinality1: 20, cardinality2: 1, cardinality3: 1, cols: []string{"COL1"}},
+		{name: "triple col parallel", size: 100000, cardinality1: 1000, cardinality2: 10, cardinality3: 2, cols: []string{"COL1", "COL2", "COL3"}, parallelism: 4},
+		{name: "high cardinality parallel", size: 100000, cardinality1: 50000, cardinality2: 1, cardinality3: 1, cols: []string{"COL1"}, parallelism: 4},
 	}
 
 	for _, tc := range table {
@@ -689,7 +715,7 @@ func BenchmarkGroupBy(b *testing.B) {
 				b.ResetTimer()
 				var stats qf.GroupStats
 				for i := 0; i < b.N; i++ {
-					grouper := df.GroupBy(groupby.Columns(tc.cols...))
+					grouper := df.GroupBy(groupby.Columns(tc.cols...), groupby.Parallelism(tc.parallelism))
 					if grouper.Err != nil {
 						b.Errorf(grouper.Err.Error())
 					}
@@ -13,6 +13,7 @@ import (
 	igenerator "github.com/yistabraq/qframe/internal/icolumn"
 	qfgenerator "github.com/yistabraq/qframe/internal/qframe/generator"
 	sgenerator "github.com/yistabraq/qframe/internal/scolumn"
+	tgenerator "github.com/yistabraq/qframe/internal/tcolumn"
 	"github.com/yistabraq/qframe/qerrors"
 )
 
@@ -40,6 +41,8 @@ func main() {
 		"efilter": egenerator.GenerateFilters,
 		"sdoc":    sgenerator.GenerateDoc,
 		"sfilter": sgenerator.GenerateFilters,
+		"tdoc":    tgenerator.GenerateDoc,
+		"tfilter": tgenerator.GenerateFilters,
 		"qframe":  qfgenerator.GenerateQFrame,
 	}
 
@@ -98,6 +98,18 @@ func EnumValues(values map[string][]string) ConfigFunc {
 	}
 }
 
+// TimeFormat sets the layout, as understood by time.Parse, used to parse time columns.
+// Columns that have been given the time type (using Types above) are parsed using
+// time.RFC3339 if no layout is given. If a layout is given columns without an explicit
+// type are also tried as time columns before falling back to strings.
+//
+// layout - The time layout, eg. "2006-01-02 15:04:05".
+func TimeFormat(layout string) ConfigFunc {
+	return func(c *Config) {
+		c.TimeFormat = layout
+	}
+}
+
 // RowCountHint can be used to provide an indication of the number of rows
 // in the CSV. In some cases this will help allocating buffers more efficiently
 // and improve import times.
@@ -118,6 +130,58 @@ func Headers(headers []string) ConfigFunc {
 	}
 }
 
+// Columns restricts reading to the given columns, all other columns are skipped
+// without being parsed. The columns keep the order they have in the CSV.
+// It is an error to specify a column that is not present in the CSV.
+//
+// columns - Slice with column names.
+func Columns(columns []string) ConfigFunc {
+	return func(c *Config) {
+		c.Columns = columns
+	}
+}
+
+// Parallelism sets the maximum number of goroutines used to parse the CSV.
+// With a parallelism above 1 the input is split into chunks of complete lines
+// that are tokenized concurrently, after which the columns are converted in parallel.
+// The result is the same as when reading sequentially.
+//
+// Note that the entire input is read into memory before parsing starts. To find
+// line boundaries quotes are assumed to only be used to enclose fields, as specified
+// in RFC 4180. Default is 1, sequential reading.
+//
+// n - The max number of goroutines.
+func Parallelism(n int) ConfigFunc {
+	return func(c *Config) {
+		c.Parallelism = n
+	}
+}
+
+// AutoEnum makes columns without an explicit type that would otherwise be read as
+// strings be stored as enums if they have at most maxCardinality distinct values.
+// Enums use a lot less memory than strings for columns with repeated values and are
+// faster to filter and group. Values of automatically detected enums have no defined order.
+//
+// When reading in chunks the type is detected from the first chunk, following chunks
+// always use the detected type.
+//
+// maxCardinality - The max number of distinct values, 0 (default) disables automatic enums.
+func AutoEnum(maxCardinality int) ConfigFunc {
+	return func(c *Config) {
+		c.AutoEnumMaxCardinality = maxCardinality
+	}
+}
+
+// AutoEnumRatio further restricts which columns are stored as enums by AutoEnum
+// to those where the number of distinct values is at most ratio times the number of rows.
+//
+// ratio - The max ratio between distinct values and rows, 0 (default) means no restriction.
+func AutoEnumRatio(ratio float64) ConfigFunc {
+	return func(c *Config) {
+		c.AutoEnumMaxRatio = ratio
+	}
+}
+
 // ToConfig holds configuration for writing CSV files
 type ToConfig qfio.ToCsvConfig
 
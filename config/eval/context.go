@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/yistabraq/qframe/function"
 	qfstrings "github.com/yistabraq/qframe/internal/strings"
@@ -15,6 +17,7 @@ import (
 type functionsByArgCount struct {
 	singleArgs map[string]interface{}
 	doubleArgs map[string]interface{}
+	paramArgs  map[string]interface{}
 }
 
 type functionsByArgType map[types.FunctionType]functionsByArgCount
@@ -25,6 +28,10 @@ type ArgCount byte
 const (
 	ArgCountOne ArgCount = iota
 	ArgCountTwo
+
+	// ArgCountParams denotes single argument functions that also take one or more
+	// constant parameters, see SetFunc.
+	ArgCountParams
 )
 
 // String returns a string representation of the ArgCount
@@ -34,6 +41,8 @@ func (c ArgCount) String() string {
 		return "Single argument"
 	case ArgCountTwo:
 		return "Double argument"
+	case ArgCountParams:
+		return "Parameterized"
 	default:
 		return "Unknown argument count"
 	}
@@ -48,20 +57,55 @@ type Context struct {
 // NewDefaultCtx creates a default context containing a base set of functions.
 // It can be used as is or enhanced with other/more functions. See the source code
 // for the current set of functions.
+//
+// In addition to the functions in the context the conditional expressions if, coalesce
+// and case are always available, see qframe.Expr.
+//
+// Apart from arithmetic, comparison and type conversion functions the default context
+// contains the following functions. Parameters, written within brackets, must be constants.
+//
+//	Float  - sqrt(x), log(x), exp(x), pow(x, y), floor(x), ceil(x), round(x), round(x, [n]),
+//	         clip(x, [lo], [hi]), format(x, [format])
+//	Int    - format(x, [format]), int columns are converted to float for the float functions above
+//	Bool   - format(x, [format])
+//	String - trim(s), contains(s, sub), startswith(s, prefix), replace(s, [old], [new]),
+//	         substr(s, [start], [length]), split_part(s, [sep], [n]), regex_extract(s, [pattern]),
+//	         pad(s, [width], [fill]), format(s, [format])
+//
+// See the corresponding functions in the function package for details. All functions
+// return null for null input.
 func NewDefaultCtx() *Context {
 	return &Context{
 		functionsByArgType{
 			types.FunctionTypeFloat: functionsByArgCount{
 				singleArgs: map[string]interface{}{
-					"abs": math.Abs,
-					"str": function.StrF,
-					"int": function.IntF,
+					"abs":   math.Abs,
+					"str":   function.StrF,
+					"int":   function.IntF,
+					"sqrt":  math.Sqrt,
+					"log":   math.Log,
+					"exp":   math.Exp,
+					"floor": math.Floor,
+					"ceil":  math.Ceil,
+					"round": math.Round,
 				},
 				doubleArgs: map[string]interface{}{
-					"+": function.PlusF,
-					"-": function.MinusF,
-					"*": function.MulF,
-					"/": function.DivF,
+					"+":   function.PlusF,
+					"-":   function.MinusF,
+					"*":   function.MulF,
+					"/":   function.DivF,
+					"==":  function.EqF,
+					"!=":  function.NeqF,
+					"<":   function.LtF,
+					"<=":  function.LteF,
+					">":   function.GtF,
+					">=":  function.GteF,
+					"pow": math.Pow,
+				},
+				paramArgs: map[string]interface{}{
+					"round":  function.RoundF,
+					"clip":   function.ClipF,
+					"format": function.FormatF,
 				},
 			},
 			types.FunctionTypeInt: functionsByArgCount{
@@ -72,10 +116,19 @@ func NewDefaultCtx() *Context {
 					"float": function.FloatI,
 				},
 				doubleArgs: map[string]interface{}{
-					"+": function.PlusI,
-					"-": function.MinusI,
-					"*": function.MulI,
-					"/": function.DivI,
+					"+":  function.PlusI,
+					"-":  function.MinusI,
+					"*":  function.MulI,
+					"/":  function.DivI,
+					"==": function.EqI,
+					"!=": function.NeqI,
+					"<":  function.LtI,
+					"<=": function.LteI,
+					">":  function.GtI,
+					">=": function.GteI,
+				},
+				paramArgs: map[string]interface{}{
+					"format": function.FormatI,
 				},
 			},
 			types.FunctionTypeBool: functionsByArgCount{
@@ -87,9 +140,13 @@ func NewDefaultCtx() *Context {
 				doubleArgs: map[string]interface{}{
 					"&":    function.AndB,
 					"|":    function.OrB,
+					"==":   function.EqB,
 					"!=":   function.XorB,
 					"nand": function.NandB,
 				},
+				paramArgs: map[string]interface{}{
+					"format": function.FormatB,
+				},
 			},
 			types.FunctionTypeString: functionsByArgCount{
 				singleArgs: map[string]interface{}{
@@ -97,11 +154,40 @@ func NewDefaultCtx() *Context {
 					"lower": function.LowerS,
 					"str":   function.StrS,
 					"len":   function.LenS,
+					"trim":  function.TrimS,
 				},
 				doubleArgs: map[string]interface{}{
-					"+": function.ConcatS,
+					"+":          function.ConcatS,
+					"==":         function.EqS,
+					"!=":         function.NeqS,
+					"<":          function.LtS,
+					"<=":         function.LteS,
+					">":          function.GtS,
+					">=":         function.GteS,
+					"contains":   function.ContainsS,
+					"startswith": function.StartsWithS,
+				},
+				paramArgs: map[string]interface{}{
+					"replace":       function.ReplaceS,
+					"substr":        function.SubstrS,
+					"split_part":    function.SplitPartS,
+					"regex_extract": function.RegexExtractS,
+					"pad":           function.PadS,
+					"format":        function.FormatS,
 				},
 			},
+			types.FunctionTypeTime: functionsByArgCount{
+				singleArgs: map[string]interface{}{},
+				doubleArgs: map[string]interface{}{
+					"==": function.EqT,
+					"!=": function.NeqT,
+					"<":  function.LtT,
+					"<=": function.LteT,
+					">":  function.GtT,
+					">=": function.GteT,
+				},
+				paramArgs: map[string]interface{}{},
+			},
 		},
 	}
 }
@@ -115,31 +201,61 @@ func (ctx *Context) GetFunc(typ types.FunctionType, ac ArgCount, name string) (i
 		return nil, true
 	}
 
-	var fn interface{}
-	var ok bool
-	if ac == ArgCountOne {
-		fn, ok = ctx.functions[typ].singleArgs[name]
-	} else {
-		fn, ok = ctx.functions[typ].doubleArgs[name]
+	fn, ok := ctx.functions[typ].byArgCount(ac)[name]
+	return fn, ok
+}
+
+// HasFunc returns true if a function with the given argument count and name exists for any function type.
+func (ctx *Context) HasFunc(ac ArgCount, name string) bool {
+	for _, funcs := range ctx.functions {
+		if _, ok := funcs.byArgCount(ac)[name]; ok {
+			return true
+		}
 	}
 
-	return fn, ok
+	return false
 }
 
-func (ctx *Context) setFunc(typ types.FunctionType, ac ArgCount, name string, fn interface{}) {
-	if ac == ArgCountOne {
-		ctx.functions[typ].singleArgs[name] = fn
-	} else {
-		ctx.functions[typ].doubleArgs[name] = fn
+func (f functionsByArgCount) byArgCount(ac ArgCount) map[string]interface{} {
+	switch ac {
+	case ArgCountOne:
+		return f.singleArgs
+	case ArgCountTwo:
+		return f.doubleArgs
+	default:
+		return f.paramArgs
 	}
 }
 
+func (ctx *Context) setFunc(typ types.FunctionType, ac ArgCount, name string, fn interface{}) {
+	ctx.functions[typ].byArgCount(ac)[name] = fn
+}
+
 // SetFunc inserts a function into the context under the given name.
+//
+// Apart from single and double argument functions SetFunc also accepts parameterized
+// functions. A parameterized function takes one or more parameters of type int, float64,
+// bool or string and returns a single argument function, optionally together with an error.
+// When used in an expression the parameters are taken from constant arguments following
+// the first argument, eg. clip(x, 0, 1) where clip is func(lo, hi float64) func(float64) float64.
 func (ctx *Context) SetFunc(name string, fn interface{}) error {
 	if err := qfstrings.CheckName(name); err != nil {
 		return qerrors.Propagate("SetFunc", err)
 	}
 
+	ac, typ, ok := funcType(fn)
+	if !ok {
+		if typ, ok = paramFuncType(fn); !ok {
+			return qerrors.New("SetFunc", "invalid function type for function \"%s\": %v", name, reflect.TypeOf(fn))
+		}
+		ac = ArgCountParams
+	}
+
+	ctx.setFunc(typ, ac, name, fn)
+	return nil
+}
+
+func funcType(fn interface{}) (ArgCount, types.FunctionType, bool) {
 	// Since there's such a flexibility in the function types that can be
 	// used and there is no static typing to support it this function
 	// acts as the gate keeper for adding new functions.
@@ -147,13 +263,13 @@ func (ctx *Context) SetFunc(name string, fn interface{}) error {
 	var typ types.FunctionType
 	switch fn.(type) {
 	// Int
-	case func(int, int) int:
+	case func(int, int) int, func(int, int) bool:
 		ac, typ = ArgCountTwo, types.FunctionTypeInt
 	case func(int) int, func(int) bool, func(int) float64, func(int) *string:
 		ac, typ = ArgCountOne, types.FunctionTypeInt
 
 	// Float
-	case func(float64, float64) float64:
+	case func(float64, float64) float64, func(float64, float64) bool:
 		ac, typ = ArgCountTwo, types.FunctionTypeFloat
 	case func(float64) float64, func(float64) int, func(float64) bool, func(float64) *string:
 		ac, typ = ArgCountOne, types.FunctionTypeFloat
@@ -165,33 +281,124 @@ func (ctx *Context) SetFunc(name string, fn interface{}) error {
 		ac, typ = ArgCountOne, types.FunctionTypeBool
 
 	// String
-	case func(*string, *string) *string:
+	case func(*string, *string) *string, func(*string, *string) bool:
 		ac, typ = ArgCountTwo, types.FunctionTypeString
 	case func(*string) *string, func(*string) int, func(*string) float64, func(*string) bool:
 		ac, typ = ArgCountOne, types.FunctionTypeString
 
+	// Time
+	case func(time.Time, time.Time) time.Time, func(time.Time, time.Time) bool:
+		ac, typ = ArgCountTwo, types.FunctionTypeTime
+	case func(time.Time) time.Time, func(time.Time) int, func(time.Time) float64, func(time.Time) bool, func(time.Time) *string:
+		ac, typ = ArgCountOne, types.FunctionTypeTime
+
 	default:
-		return qerrors.New("SetFunc", "invalid function type for function \"%s\": %v", name, reflect.TypeOf(fn))
+		return ac, typ, false
 	}
 
-	ctx.setFunc(typ, ac, name, fn)
-	return nil
+	return ac, typ, true
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func isParamType(t reflect.Type) bool {
+	switch t {
+	case reflect.TypeOf(0), reflect.TypeOf(0.0), reflect.TypeOf(false), reflect.TypeOf(""):
+		return true
+	}
+	return false
+}
+
+// paramFuncType returns the function type of the single argument function returned by fn if
+// fn is a valid parameterized function.
+func paramFuncType(fn interface{}) (types.FunctionType, bool) {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func || t.IsVariadic() || t.NumIn() == 0 {
+		return types.FunctionTypeUndefined, false
+	}
+
+	for i := 0; i < t.NumIn(); i++ {
+		if !isParamType(t.In(i)) {
+			return types.FunctionTypeUndefined, false
+		}
+	}
+
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return types.FunctionTypeUndefined, false
+	}
+
+	ac, typ, ok := funcType(reflect.Zero(t.Out(0)).Interface())
+	return typ, ok && ac == ArgCountOne
+}
+
+// Bind calls the parameterized function fn, see SetFunc, with params and returns the
+// resulting single argument function. int parameters are converted to float64 when needed.
+func Bind(fn interface{}, params ...interface{}) (interface{}, error) {
+	if _, ok := paramFuncType(fn); !ok {
+		return nil, qerrors.New("Bind", "invalid parameterized function type: %v", reflect.TypeOf(fn))
+	}
+
+	t := reflect.TypeOf(fn)
+	if len(params) != t.NumIn() {
+		return nil, qerrors.New("Bind", "expected %d parameters, was %d", t.NumIn(), len(params))
+	}
+
+	args := make([]reflect.Value, len(params))
+	for i, p := range params {
+		if s, ok := p.(*string); ok && s != nil {
+			p = *s
+		}
+
+		v := reflect.ValueOf(p)
+		switch {
+		case p == nil || v.Kind() == reflect.Ptr:
+			return nil, qerrors.New("Bind", "parameter %d must not be null", i+1)
+		case v.Type() == t.In(i):
+			args[i] = v
+		case v.Kind() == reflect.Int && t.In(i).Kind() == reflect.Float64:
+			args[i] = v.Convert(t.In(i))
+		default:
+			return nil, qerrors.New("Bind", "parameter %d must be %v, was %v", i+1, t.In(i), v.Type())
+		}
+	}
+
+	result := reflect.ValueOf(fn).Call(args)
+	if len(result) == 2 && !result[1].IsNil() {
+		return nil, qerrors.Propagate("Bind", result[1].Interface().(error))
+	}
+
+	return result[0].Interface(), nil
 }
 
 func (ctx *Context) String() string {
+	fnTypes := make([]types.FunctionType, 0, len(ctx.functions))
+	for fnType := range ctx.functions {
+		fnTypes = append(fnTypes, fnType)
+	}
+	sort.Slice(fnTypes, func(i, j int) bool { return fnTypes[i] < fnTypes[j] })
+
 	result := ""
-	for fnType, funcs := range ctx.functions {
+	for _, fnType := range fnTypes {
+		funcs := ctx.functions[fnType]
 		result += fmt.Sprintf("\n%s\n%s", fnType, strings.Repeat("-", len(fnType.String())))
-		result += "\n Single arg\n"
-		for funcName := range funcs.singleArgs {
-			result += "  " + funcName + "\n"
-		}
+		result += "\n Single arg\n" + funcNames(funcs.singleArgs)
+		result += "\n Double arg\n" + funcNames(funcs.doubleArgs)
+		result += "\n Parameterized\n" + funcNames(funcs.paramArgs)
+	}
 
-		result += "\n Double arg\n"
-		for funcName := range funcs.doubleArgs {
-			result += "  " + funcName + "\n"
-		}
+	return result
+}
+
+func funcNames(funcs map[string]interface{}) string {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
+	result := ""
+	for _, name := range names {
+		result += "  " + name + "\n"
+	}
 	return result
 }
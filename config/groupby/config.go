@@ -7,6 +7,7 @@ package groupby
 type Config struct {
 	Columns     []string
 	GroupByNull bool
+	Parallelism int
 	// dropNulls?
 }
 
@@ -47,3 +48,15 @@ func Null(b bool) ConfigFunc {
 		c.GroupByNull = b
 	}
 }
+
+// Parallelism sets the maximum number of goroutines used when grouping and aggregating.
+// Rows are distributed over the goroutines based on their hash when grouping and
+// aggregations are executed concurrently. The result is the same as for sequential
+// execution. Default is 1, eg. no parallelism.
+//
+// Functions used in aggregations must be safe to call concurrently when n > 1.
+func Parallelism(n int) ConfigFunc {
+	return func(c *Config) {
+		c.Parallelism = n
+	}
+}
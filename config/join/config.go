@@ -0,0 +1,84 @@
+package join
+
+import "github.com/yistabraq/qframe/qerrors"
+
+// Config holds configuration for join operations on QFrames.
+// It should be considered a private implementation detail and should never be
+// referenced or used directly outside of the QFrame code. To manipulate it
+// use the functions returning ConfigFunc below.
+type Config struct {
+	How         string // inner/left/right/full/semi/anti
+	Columns     []string
+	LeftSuffix  string
+	RightSuffix string
+	JoinOnNull  bool
+}
+
+// ConfigFunc is a function that operates on a Config object.
+type ConfigFunc func(c *Config)
+
+// NewConfig creates a new Config object.
+// This function should never be called from outside QFrame.
+func NewConfig(ff []ConfigFunc) (Config, error) {
+	c := Config{
+		How:         "inner",
+		LeftSuffix:  "_left",
+		RightSuffix: "_right",
+	}
+
+	for _, fn := range ff {
+		fn(&c)
+	}
+
+	switch c.How {
+	case "inner", "left", "right", "full", "semi", "anti":
+	default:
+		return c, qerrors.New("Join config", "How must be inner/left/right/full/semi/anti, was %s", c.How)
+	}
+
+	if c.LeftSuffix == c.RightSuffix {
+		return c, qerrors.New("Join config", "Left and right suffixes must differ, both were %s", c.LeftSuffix)
+	}
+
+	return c, nil
+}
+
+// How sets the type of join to perform.
+// Valid values:
+// inner - Rows from both QFrames with matching keys.
+// left  - All rows from the left QFrame, matched with rows in the right QFrame where possible.
+// right - All rows from the right QFrame, matched with rows in the left QFrame where possible.
+// full  - All rows from both QFrames, matched where possible.
+// semi  - Rows from the left QFrame that have a match in the right QFrame, only left columns are kept.
+// anti  - Rows from the left QFrame that do not have a match in the right QFrame, only left columns are kept.
+// Default value: inner
+func How(h string) ConfigFunc {
+	return func(c *Config) {
+		c.How = h
+	}
+}
+
+// On sets the key columns to join on. The columns must exist, and have the same type, in both QFrames.
+// Leaving this configuration option out will join on all columns that the QFrames have in common.
+func On(columns ...string) ConfigFunc {
+	return func(c *Config) {
+		c.Columns = columns
+	}
+}
+
+// Suffixes sets the suffixes added to non key columns that exist in both QFrames.
+// Default values: _left, _right
+func Suffixes(left, right string) ConfigFunc {
+	return func(c *Config) {
+		c.LeftSuffix = left
+		c.RightSuffix = right
+	}
+}
+
+// Null configures if null/NaN keys should match each other or not.
+// Default is false (eg. null never matches anything, as in SQL).
+func Null(b bool) ConfigFunc {
+	return func(c *Config) {
+		c.JoinOnNull = b
+	}
+}
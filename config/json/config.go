@@ -0,0 +1,40 @@
+package json
+
+// Formats that can be used when writing JSON.
+const (
+	// Records writes an array with one object per row.
+	Records = "records"
+	// NDJSON writes newline delimited JSON, one object per row and line.
+	NDJSON = "ndjson"
+	// Columns writes an object with one array of values per column.
+	Columns = "columns"
+)
+
+// Config holds configuration for writing JSON.
+// It should be considered a private implementation detail and should never be
+// referenced or used directly outside of the QFrame code. To manipulate it
+// use the functions returning ConfigFunc below.
+type Config struct {
+	Format string
+}
+
+// ConfigFunc is a function that operates on a Config object.
+type ConfigFunc func(*Config)
+
+// NewConfig creates a new Config object.
+// This function should never be called from outside QFrame.
+func NewConfig(ff []ConfigFunc) Config {
+	conf := Config{Format: Records}
+	for _, f := range ff {
+		f(&conf)
+	}
+	return conf
+}
+
+// Format configures the layout of the JSON written.
+// Valid values are Records (default), NDJSON and Columns.
+func Format(format string) ConfigFunc {
+	return func(c *Config) {
+		c.Format = format
+	}
+}
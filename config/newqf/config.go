@@ -5,8 +5,10 @@ package newqf
 // referenced or used directly outside of the QFrame code. To manipulate it
 // use the functions returning ConfigFunc below.
 type Config struct {
-	ColumnOrder []string
-	EnumColumns map[string][]string
+	ColumnOrder            []string
+	EnumColumns            map[string][]string
+	AutoEnumMaxCardinality int
+	AutoEnumMaxRatio       float64
 }
 
 // ConfigFunc is a function that operates on a Config object.
@@ -43,3 +45,25 @@ func Enums(columns map[string][]string) ConfigFunc {
 		}
 	}
 }
+
+// AutoEnum makes string columns, not listed in Enums, be stored as enums if they have at
+// most maxCardinality distinct values. Enums use a lot less memory than strings for columns
+// with repeated values and are faster to filter and group. Values of automatically
+// detected enums have no defined order.
+//
+// maxCardinality - The max number of distinct values, 0 (default) disables automatic enums.
+func AutoEnum(maxCardinality int) ConfigFunc {
+	return func(c *Config) {
+		c.AutoEnumMaxCardinality = maxCardinality
+	}
+}
+
+// AutoEnumRatio further restricts which columns are stored as enums by AutoEnum
+// to those where the number of distinct values is at most ratio times the number of rows.
+//
+// ratio - The max ratio between distinct values and rows, 0 (default) means no restriction.
+func AutoEnumRatio(ratio float64) ConfigFunc {
+	return func(c *Config) {
+		c.AutoEnumMaxRatio = ratio
+	}
+}
@@ -0,0 +1,48 @@
+package parquet
+
+import (
+	qfparquet "github.com/yistabraq/qframe/internal/io/parquet"
+)
+
+// Compression codecs that can be used when writing Parquet files.
+const (
+	Uncompressed = qfparquet.Uncompressed
+	Snappy       = qfparquet.Snappy
+	Gzip         = qfparquet.Gzip
+)
+
+// Config holds configuration for reading/writing Parquet files.
+// It should be considered a private implementation detail and should never be
+// referenced or used directly outside of the QFrame code. To manipulate it
+// use the functions returning ConfigFunc below.
+type Config qfparquet.Config
+
+// ConfigFunc is a function that operates on a Config object.
+type ConfigFunc func(*Config)
+
+// NewConfig creates a new Config object.
+// This function should never be called from outside QFrame.
+func NewConfig(ff []ConfigFunc) Config {
+	conf := Config{Compression: Snappy}
+	for _, f := range ff {
+		f(&conf)
+	}
+	return conf
+}
+
+// Columns configures which columns to read. Only the listed columns are read from the
+// file which saves both time and memory compared to dropping them after reading.
+// The columns keep the order they have in the file. All columns are read by default.
+func Columns(columns ...string) ConfigFunc {
+	return func(c *Config) {
+		c.Columns = columns
+	}
+}
+
+// Compression configures the codec used to compress pages when writing.
+// Valid values are Uncompressed, Snappy (default) and Gzip.
+func Compression(codec string) ConfigFunc {
+	return func(c *Config) {
+		c.Compression = codec
+	}
+}
@@ -31,6 +31,17 @@ func coerceFunc(cType coerceType) qsqlio.CoerceFunc {
 	return nil
 }
 
+// BulkLoader writes rows to a table using a database
+// specific bulk loading mechanism, see BulkLoad.
+type BulkLoader = qsqlio.BulkLoader
+
+// CopyIn is a BulkLoader for drivers that implement
+// COPY FROM STDIN through a prepared statement, it
+// returns the statement to prepare. With github.com/lib/pq:
+//
+//	qsql.BulkLoad(qsql.CopyIn(pq.CopyIn))
+type CopyIn = qsqlio.CopyIn
+
 // Config holds configuration parameters for reading/writing to/from a SQL DB.
 type Config qsqlio.SQLConfig
 
@@ -55,6 +66,14 @@ func Query(query string) ConfigFunc {
 	}
 }
 
+// Args sets the arguments of the query, used for
+// placeholder parameters in the query.
+func Args(args ...interface{}) ConfigFunc {
+	return func(c *Config) {
+		c.QueryArgs = args
+	}
+}
+
 // Table is the name of the table to be used
 // for generating an INSERT statement.
 func Table(table string) ConfigFunc {
@@ -70,6 +89,7 @@ func Postgres() ConfigFunc {
 	return func(c *Config) {
 		EscapeChar('"')(c)
 		Incrementing()(c)
+		c.Dialect = qsqlio.Postgres
 	}
 }
 
@@ -79,6 +99,7 @@ func Postgres() ConfigFunc {
 func SQLite() ConfigFunc {
 	return func(c *Config) {
 		EscapeChar('"')(c)
+		c.Dialect = qsqlio.SQLite
 	}
 }
 
@@ -88,6 +109,7 @@ func SQLite() ConfigFunc {
 func MySQL() ConfigFunc {
 	return func(c *Config) {
 		EscapeChar('`')(c)
+		c.Dialect = qsqlio.MySQL
 	}
 }
 
@@ -128,3 +150,112 @@ func Precision(i int) ConfigFunc {
 		c.Precision = i
 	}
 }
+
+// AutoEnum makes string columns be stored as enums if they have at most
+// maxCardinality distinct values. Enums use a lot less memory than strings
+// for columns with repeated values and are faster to filter and group.
+// 0 (default) disables automatic enums.
+func AutoEnum(maxCardinality int) ConfigFunc {
+	return func(c *Config) {
+		c.AutoEnumMaxCardinality = maxCardinality
+	}
+}
+
+// AutoEnumRatio further restricts which columns are stored as enums by AutoEnum
+// to those where the number of distinct values is at most ratio times the number
+// of rows. 0 (default) means no restriction.
+func AutoEnumRatio(ratio float64) ConfigFunc {
+	return func(c *Config) {
+		c.AutoEnumMaxRatio = ratio
+	}
+}
+
+// BatchSize sets the number of rows inserted by each INSERT
+// statement when writing to SQL. Larger batches mean fewer
+// round trips to the database. Note that most databases limit
+// the number of parameters in a statement, the batch size times
+// the number of columns must stay within that limit.
+// 0 and 1 (default) both mean one row per statement.
+func BatchSize(n int) ConfigFunc {
+	return func(c *Config) {
+		c.BatchSize = n
+	}
+}
+
+// BulkLoad makes ToSQL write rows using loader instead of
+// INSERT statements. BatchSize has no effect when a bulk
+// loader is used.
+func BulkLoad(loader BulkLoader) ConfigFunc {
+	return func(c *Config) {
+		c.BulkLoader = loader
+	}
+}
+
+// CreateTable makes ToSQL create the table before writing
+// to it. Column types are derived from the QFrame column types
+// using the dialect set by Postgres, SQLite or MySQL:
+//
+//	int    -> BIGINT (INTEGER in SQLite)
+//	float  -> DOUBLE PRECISION (REAL in SQLite, DOUBLE in MySQL)
+//	bool   -> BOOLEAN
+//	string -> TEXT
+//	enum   -> TEXT (a native ENUM in MySQL)
+//	time   -> TIMESTAMP (TIMESTAMP WITH TIME ZONE in Postgres, DATETIME in SQLite and MySQL)
+//
+// Use ColumnType to override the type of individual columns.
+//
+// ifNotExists - If set to true the table is only created if it does not already exist.
+func CreateTable(ifNotExists bool) ConfigFunc {
+	return func(c *Config) {
+		c.CreateTable = true
+		c.IfNotExists = ifNotExists
+	}
+}
+
+// ColumnType sets the SQL type of column when creating
+// the table, overriding the type derived from the QFrame.
+func ColumnType(column, sqlType string) ConfigFunc {
+	return func(c *Config) {
+		if c.ColumnTypes == nil {
+			c.ColumnTypes = map[string]string{}
+		}
+		c.ColumnTypes[column] = sqlType
+	}
+}
+
+// PrimaryKey declares the columns that make up the
+// primary key when creating the table.
+func PrimaryKey(columns ...string) ConfigFunc {
+	return func(c *Config) {
+		c.PrimaryKey = columns
+	}
+}
+
+// Upsert makes ToSQL update rows that already exist instead of failing.
+// Rows are identified by keyColumns which must make up a primary key or
+// unique constraint of the table. PostgreSQL and SQLite use
+// INSERT ... ON CONFLICT (keyColumns) DO UPDATE, MySQL/MariaDB use
+// INSERT ... ON DUPLICATE KEY UPDATE. By default all columns but the
+// key columns are updated, see UpdateColumns and OnConflictDoNothing.
+func Upsert(keyColumns ...string) ConfigFunc {
+	return func(c *Config) {
+		c.UpsertKeys = keyColumns
+	}
+}
+
+// UpdateColumns sets which columns are updated by Upsert
+// when a row already exists.
+func UpdateColumns(columns ...string) ConfigFunc {
+	return func(c *Config) {
+		c.UpdateColumns = columns
+	}
+}
+
+// OnConflictDoNothing leaves rows that already exist unchanged
+// rather than updating them. Can be used with or without Upsert,
+// for PostgreSQL and SQLite the key columns are optional.
+func OnConflictDoNothing() ConfigFunc {
+	return func(c *Config) {
+		c.DoNothing = true
+	}
+}
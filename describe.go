@@ -0,0 +1,194 @@
+package qframe
+
+import (
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/yistabraq/qframe/config/newqf"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/math/float"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// describeColumns are the columns of the QFrame returned by Describe, in order.
+var describeColumns = []string{
+	"column", "type", "count", "null_count", "distinct",
+	"min", "max", "mean", "std", "p25", "p50", "p75",
+	"top", "top_count", "min_length", "max_length"}
+
+// Describe returns a new QFrame with summary statistics, one row per column in the QFrame,
+// in column order. It is intended to give a quick overview of the content of a dataset.
+//
+// The following statistics are reported:
+//
+//	column, type        - The name and data type of the column.
+//	count, null_count   - The number of non null and null values in the column.
+//	distinct            - The number of distinct non null values in the column.
+//	min, max, mean, std - Minimum, maximum, mean and sample standard deviation. Int and float columns.
+//	p25, p50, p75       - The 25th, 50th and 75th percentile. Int and float columns.
+//	top, top_count      - The most common value and its number of occurrences, the smallest value
+//	                      on ties. String and enum columns.
+//	min_length,         - The minimum and maximum number of characters in the values.
+//	max_length            String and enum columns.
+//
+// Statistics that do not apply to a column, or that cannot be computed because the column
+// does not contain any non null values, are null.
+//
+// Time complexity O(m * n * log(n)) where m = number of columns, n = number of rows.
+func (qf QFrame) Describe() QFrame {
+	if qf.Err != nil {
+		return qf
+	}
+
+	colCount := len(qf.columns)
+	names, typeNames := make([]string, colCount), make([]string, colCount)
+	counts, nullCounts, distincts := make([]int, colCount), make([]int, colCount), make([]int, colCount)
+	stats := make(map[string][]float64, 7)
+	for _, name := range describeColumns[5:12] {
+		stats[name] = make([]float64, colCount)
+	}
+	tops, topCounts := make([]*string, colCount), make([]*int, colCount)
+	minLengths, maxLengths := make([]*int, colCount), make([]*int, colCount)
+
+	typeMap := qf.ColumnTypeMap()
+	for i, col := range qf.columns {
+		names[i], typeNames[i] = col.name, string(typeMap[col.name])
+
+		comp := col.Comparable(false, false, false)
+		for _, j := range qf.index {
+			if isNull(comp, j) {
+				nullCounts[i]++
+			}
+		}
+		counts[i] = qf.Len() - nullCounts[i]
+		forEachRun(comp, qf.index, func(index.Int) { distincts[i]++ })
+
+		values, err := qf.describeFloats(col)
+		if err != nil {
+			return qf.withErr(qerrors.Propagate("Describe", err))
+		}
+
+		minV, maxV, mean, std, p25, p50, p75 := math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()
+		if len(values) > 0 {
+			sort.Float64s(values)
+			minV, maxV = values[0], values[len(values)-1]
+			mean, std = float.Mean(values), math.Sqrt(float.Variance(values))
+			p25, p50, p75 = float.Quantile(values, 0.25), float.Quantile(values, 0.5), float.Quantile(values, 0.75)
+		}
+		stats["min"][i], stats["max"][i], stats["mean"][i], stats["std"][i] = minV, maxV, mean, std
+		stats["p25"][i], stats["p50"][i], stats["p75"][i] = p25, p50, p75
+
+		strs, err := qf.describeStrings(col)
+		if err != nil {
+			return qf.withErr(qerrors.Propagate("Describe", err))
+		}
+
+		if len(strs) > 0 {
+			tops[i], topCounts[i], minLengths[i], maxLengths[i] = describeTop(strs)
+		}
+	}
+
+	data := map[string]types.DataSlice{
+		"column":     names,
+		"type":       typeNames,
+		"count":      counts,
+		"null_count": nullCounts,
+		"distinct":   distincts,
+		"top":        tops,
+		"top_count":  topCounts,
+		"min_length": minLengths,
+		"max_length": maxLengths,
+	}
+	for name, s := range stats {
+		data[name] = s
+	}
+
+	return New(data, newqf.ColumnOrder(describeColumns...))
+}
+
+// describeFloats returns the non null values of int and float columns as floats.
+func (qf QFrame) describeFloats(col namedColumn) ([]float64, error) {
+	result := make([]float64, 0, qf.Len())
+	switch col.DataType() {
+	case types.Int:
+		view, err := qf.IntView(col.name)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < view.Len(); i++ {
+			if !view.IsNull(i) {
+				result = append(result, float64(view.ItemAt(i)))
+			}
+		}
+	case types.Float:
+		view, err := qf.FloatView(col.name)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < view.Len(); i++ {
+			if v := view.ItemAt(i); !math.IsNaN(v) {
+				result = append(result, v)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// describeStrings returns the non null values of string and enum columns.
+func (qf QFrame) describeStrings(col namedColumn) ([]string, error) {
+	var values []*string
+	switch col.DataType() {
+	case types.String:
+		view, err := qf.StringView(col.name)
+		if err != nil {
+			return nil, err
+		}
+		values = view.Slice()
+	case types.Enum:
+		view, err := qf.EnumView(col.name)
+		if err != nil {
+			return nil, err
+		}
+		values = view.Slice()
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			result = append(result, *v)
+		}
+	}
+
+	return result, nil
+}
+
+// describeTop returns the most common string, its count and the min and max length of strs.
+func describeTop(strs []string) (top *string, topCount, minLength, maxLength *int) {
+	counts := make(map[string]int)
+	minLen, maxLen := math.MaxInt32, 0
+	for _, s := range strs {
+		counts[s]++
+		l := utf8.RuneCountInString(s)
+		if l < minLen {
+			minLen = l
+		}
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+
+	var best string
+	bestCount := 0
+	for s, c := range counts {
+		if c > bestCount || (c == bestCount && s < best) {
+			best, bestCount = s, c
+		}
+	}
+
+	return &best, &bestCount, &minLen, &maxLen
+}
@@ -5,6 +5,9 @@ import (
 	"strconv"
 
 	"github.com/yistabraq/qframe/config/eval"
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/index"
 	"github.com/yistabraq/qframe/qerrors"
 	"github.com/yistabraq/qframe/types"
 )
@@ -27,6 +30,35 @@ func getFunc(ctx *eval.Context, ac eval.ArgCount, qf QFrame, colName types.Colum
 	return qf, fn
 }
 
+// promoteFuncArg converts the int column colName to a temporary float column if there is no int
+// function with the given name but there is a float function, eg. sqrt, pow or clip. The name of
+// the column to use as function argument is returned.
+func promoteFuncArg(ctx *eval.Context, ac eval.ArgCount, qf QFrame, colName types.ColumnName, funcName string) (QFrame, types.ColumnName) {
+	if qf.Err != nil {
+		return qf, colName
+	}
+
+	if typ, err := qf.functionType(string(colName)); err != nil || typ != types.FunctionTypeInt {
+		return qf, colName
+	}
+
+	if _, ok := ctx.GetFunc(types.FunctionTypeInt, ac, funcName); ok {
+		return qf, colName
+	}
+
+	if _, ok := ctx.GetFunc(types.FunctionTypeFloat, ac, funcName); !ok {
+		return qf, colName
+	}
+
+	col, err := convertColumn(qf.columnsByName[string(colName)].Column, types.Float, qf.index)
+	if err != nil {
+		return qf.withErr(qerrors.Propagate("promoteFuncArg", err)), colName
+	}
+
+	floatCol := tempColName(qf, "float")
+	return qf.setColumn(string(floatCol), col), floatCol
+}
+
 // Expression is an internal interface representing an expression that can be executed on a QFrame.
 type Expression interface {
 	execute(f QFrame, ctx *eval.Context) (QFrame, types.ColumnName)
@@ -163,13 +195,21 @@ func newUnaryExpr(x interface{}) (unaryExpr, bool) {
 }
 
 func (e unaryExpr) execute(qf QFrame, ctx *eval.Context) (QFrame, types.ColumnName) {
-	qf, fn := getFunc(ctx, eval.ArgCountOne, qf, e.srcCol, e.operation)
-	if qf.Err != nil {
-		return qf, ""
+	result, srcCol := promoteFuncArg(ctx, eval.ArgCountOne, qf, e.srcCol, e.operation)
+	result, fn := getFunc(ctx, eval.ArgCountOne, result, srcCol, e.operation)
+	if result.Err != nil {
+		return result, ""
 	}
 
-	colName := tempColName(qf, "unary")
-	return qf.Apply(Instruction{Fn: fn, DstCol: string(colName), SrcCol1: string(e.srcCol)}), colName
+	colName := tempColName(result, "unary")
+	result = result.Apply(Instruction{Fn: fn, DstCol: string(colName), SrcCol1: string(srcCol)})
+
+	// Drop promoted argument if not present in original frame
+	if !qf.Contains(string(srcCol)) {
+		result = result.Drop(string(srcCol))
+	}
+
+	return result, colName
 }
 
 func (e unaryExpr) Err() error {
@@ -242,16 +282,53 @@ func newColColExpr(x interface{}) (colColExpr, bool) {
 }
 
 func (e colColExpr) execute(qf QFrame, ctx *eval.Context) (QFrame, types.ColumnName) {
-	qf, fn := getFunc(ctx, eval.ArgCountTwo, qf, e.srcCol1, e.operation)
 	if qf.Err != nil {
 		return qf, ""
 	}
 
+	// Look up the function based on the type that the columns will be promoted to
+	// if they are of different types.
+	fnCol := e.srcCol1
+	if col1, ok := qf.columnsByName[string(e.srcCol1)]; ok {
+		if col2, ok := qf.columnsByName[string(e.srcCol2)]; ok && col1.DataType() != col2.DataType() {
+			typ, err := promotedType(col1.DataType(), col2.DataType())
+			if err != nil {
+				return qf.withErr(qerrors.Propagate(fmt.Sprintf("colColExpr '%s'", e.operation), err)), ""
+			}
+
+			if typ == col2.DataType() {
+				fnCol = e.srcCol2
+			}
+		}
+	}
+
+	// The other column is promoted to float by Apply if fnCol is promoted
+	result, promotedCol := promoteFuncArg(ctx, eval.ArgCountTwo, qf, fnCol, e.operation)
+	result, fn := getFunc(ctx, eval.ArgCountTwo, result, promotedCol, e.operation)
+	if result.Err != nil {
+		return result, ""
+	}
+
+	srcCol1, srcCol2 := e.srcCol1, e.srcCol2
+	if srcCol1 == fnCol {
+		srcCol1 = promotedCol
+	}
+
+	if srcCol2 == fnCol {
+		srcCol2 = promotedCol
+	}
+
 	// Fill temp column with the constant part and then apply col col expression.
 	// There are other ways to do this that would avoid the temp column but it would
 	// require more special case logic.
-	colName := tempColName(qf, "colcol")
-	result := qf.Apply(Instruction{Fn: fn, DstCol: string(colName), SrcCol1: string(e.srcCol1), SrcCol2: string(e.srcCol2)})
+	colName := tempColName(result, "colcol")
+	result = result.Apply(Instruction{Fn: fn, DstCol: string(colName), SrcCol1: string(srcCol1), SrcCol2: string(srcCol2)})
+
+	// Drop promoted argument if not present in original frame
+	if !qf.Contains(string(promotedCol)) {
+		result = result.Drop(string(promotedCol))
+	}
+
 	return result, colName
 }
 
@@ -347,6 +424,235 @@ func (e exprExpr2) Err() error {
 	return nil
 }
 
+// Conditional expressions, each function validates the number of arguments
+var conditionals = map[string]func(argCount int) bool{
+	"if":       func(argCount int) bool { return argCount == 3 },
+	"coalesce": func(argCount int) bool { return argCount >= 1 },
+	"case":     func(argCount int) bool { return argCount >= 2 },
+}
+
+// Selects, for each row, the value from one of a number of value arguments
+type condExpr struct {
+	operation string
+	args      []Expression
+}
+
+func newCondExpr(operation string, args []interface{}) Expression {
+	if !conditionals[operation](len(args)) {
+		return errorExpr{err: qerrors.New("newCondExpr", "invalid number of arguments to %s: %d", operation, len(args))}
+	}
+
+	exprs := make([]Expression, len(args))
+	for i, arg := range args {
+		exprs[i] = newExpr(arg)
+		if exprs[i].Err() != nil {
+			return errorExpr{err: qerrors.Propagate("newCondExpr", exprs[i].Err())}
+		}
+	}
+
+	return condExpr{operation: operation, args: exprs}
+}
+
+func (e condExpr) execute(qf QFrame, ctx *eval.Context) (QFrame, types.ColumnName) {
+	result := qf
+	argCols := make([]types.ColumnName, len(e.args))
+	for i, arg := range e.args {
+		result, argCols[i] = arg.execute(result, ctx)
+	}
+
+	if result.Err != nil {
+		return result, ""
+	}
+
+	// Split arguments into conditions and values
+	var conds, values []types.ColumnName
+	switch e.operation {
+	case "if":
+		conds, values = argCols[:1], argCols[1:]
+	case "coalesce":
+		values = argCols
+	case "case":
+		for i := 0; i+1 < len(argCols); i += 2 {
+			conds, values = append(conds, argCols[i]), append(values, argCols[i+1])
+		}
+
+		if len(argCols)%2 == 1 {
+			values = append(values, argCols[len(argCols)-1])
+		}
+	}
+
+	colName := tempColName(result, e.operation)
+	col, err := result.selectValues(e.operation, conds, values)
+	if err != nil {
+		result = result.withErr(qerrors.Propagate(e.operation, err))
+	} else {
+		result = result.setColumn(string(colName), col)
+	}
+
+	// Drop intermediate results if not present in original frame
+	dropCols := make([]string, 0)
+	for _, c := range argCols {
+		if s := string(c); !qf.Contains(s) {
+			dropCols = append(dropCols, s)
+		}
+	}
+
+	return result.Drop(dropCols...), colName
+}
+
+func (e condExpr) Err() error {
+	return nil
+}
+
+// selectValues creates a new column where each row has been selected from one of the value
+// columns. For coalesce it is the first non null value. Otherwise it is the value at the position
+// of the first true condition or, if there are more values than conditions, the last value.
+func (qf QFrame) selectValues(operation string, conds, values []types.ColumnName) (column.Column, error) {
+	valueCols := make([]column.Column, len(values))
+	typ := qf.columnsByName[string(values[0])].DataType()
+	for i, v := range values {
+		valueCols[i] = qf.columnsByName[string(v)].Column
+		var err error
+		if typ, err = promotedType(typ, valueCols[i].DataType()); err != nil {
+			return nil, qerrors.Propagate("selectValues", err)
+		}
+	}
+
+	for i, c := range valueCols {
+		var err error
+		if valueCols[i], err = convertColumn(c, typ, qf.index); err != nil {
+			return nil, qerrors.Propagate("selectValues", err)
+		}
+	}
+
+	condViews := make([]bcolumn.View, len(conds))
+	for i, c := range conds {
+		cond, ok := qf.columnsByName[string(c)].Column.(bcolumn.Column)
+		if !ok {
+			return nil, qerrors.New("selectValues", "condition must be bool, %s is %s", c, qf.columnsByName[string(c)].DataType())
+		}
+		condViews[i] = cond.View(index.NewAscending(uint32(cond.Len())))
+	}
+
+	colLen := qf.columnLen()
+	ix := make(index.Int, colLen)
+	for i := range ix {
+		ix[i] = noMatch
+	}
+
+	if operation == "coalesce" {
+		comps := make([]column.Comparable, len(valueCols))
+		for i, c := range valueCols {
+			comps[i] = c.Comparable(false, false, false)
+		}
+
+		for _, row := range qf.index {
+			for i, comp := range comps {
+				if !isNull(comp, row) {
+					ix[row] = uint32(i)*colLen + row
+					break
+				}
+			}
+		}
+	} else {
+		for _, row := range qf.index {
+			selected := len(condViews)
+			for i, v := range condViews {
+				if !v.IsNull(int(row)) && v.ItemAt(int(row)) {
+					selected = i
+					break
+				}
+			}
+
+			if selected < len(valueCols) {
+				ix[row] = uint32(selected)*colLen + row
+			}
+		}
+	}
+
+	all, err := valueCols[0].Append(valueCols[1:]...)
+	if err != nil {
+		return nil, err
+	}
+
+	return subsetWithNull(all, ix, uint32(all.Len()))
+}
+
+// Use the content of a single column and a number of constant parameters as input to a
+// parameterized function (eg. clip(x, 0, 1)). If no parameterized function with the given
+// name exists the expression is evaluated as a regular expression instead.
+type paramExpr struct {
+	operation string
+	expr      Expression
+	params    []interface{}
+}
+
+// newParamExpr returns a paramExpr if all arguments except the first are constants.
+func newParamExpr(operation string, args []interface{}) (Expression, bool) {
+	params := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		constE, ok := newConstExpr(arg)
+		if !ok {
+			return nil, false
+		}
+		params[i] = constE.value
+	}
+
+	expr := newExpr(args[0])
+	if expr.Err() != nil {
+		return errorExpr{err: qerrors.Propagate("newParamExpr", expr.Err())}, true
+	}
+
+	return paramExpr{operation: operation, expr: expr, params: params}, true
+}
+
+func (e paramExpr) execute(qf QFrame, ctx *eval.Context) (QFrame, types.ColumnName) {
+	result, srcCol := e.expr.execute(qf, ctx)
+	if result.Err != nil {
+		return result, ""
+	}
+
+	result, paramCol := promoteFuncArg(ctx, eval.ArgCountParams, result, srcCol, e.operation)
+	if result.Err != nil {
+		return result, ""
+	}
+
+	typ, err := result.functionType(string(paramCol))
+	if err != nil {
+		return result.withErr(qerrors.Propagate("paramExpr", err)), ""
+	}
+
+	var colName types.ColumnName
+	if fn, ok := ctx.GetFunc(typ, eval.ArgCountParams, e.operation); ok {
+		if typ != types.FunctionTypeUndefined {
+			if fn, err = eval.Bind(fn, e.params...); err != nil {
+				return result.withErr(qerrors.Propagate(fmt.Sprintf("paramExpr '%s'", e.operation), err)), ""
+			}
+		}
+
+		colName = tempColName(result, "param")
+		result = result.Apply(Instruction{Fn: fn, DstCol: string(colName), SrcCol1: string(paramCol)})
+	} else if _, ok := ctx.GetFunc(typ, eval.ArgCountTwo, e.operation); !ok && ctx.HasFunc(eval.ArgCountParams, e.operation) {
+		return result.withErr(qerrors.New("paramExpr", "Could not find parameterized %s with name '%s'", typ, e.operation)), ""
+	} else {
+		args := append([]interface{}{srcCol}, e.params...)
+		result, colName = chainExpr(e.operation, args...).execute(result, ctx)
+	}
+
+	// Drop intermediate results if not present in original frame
+	for _, c := range []types.ColumnName{srcCol, paramCol} {
+		if result.Contains(string(c)) && !qf.Contains(string(c)) {
+			result = result.Drop(string(c))
+		}
+	}
+
+	return result, colName
+}
+
+func (e paramExpr) Err() error {
+	return nil
+}
+
 type errorExpr struct {
 	err error
 }
@@ -377,7 +683,37 @@ func Val(value interface{}) Expression {
 //
 // Pseudo example:
 //     ["/", 18, 2, 3] is evaluated as ["/", ["/", 18, 2], 3] (= 3)
+//
+// Functions that take constant parameters, eg. clip(x, 0, 1), are called with the first argument
+// and the parameters if all arguments but the first are constants and a parameterized function
+// with the given name exists in the eval.Context, see eval.Context.SetFunc.
+//
+// The following conditional expressions are built in and available for all column types.
+// The value arguments are promoted to a common type in the same way as the arguments of
+// two argument functions, the condition arguments must be bool.
+// Null conditions are treated as false.
+//
+//	if(cond, a, b)                        - a where cond is true, b otherwise.
+//	coalesce(a, b, ...)                   - The first argument that is not null.
+//	case(cond1, a, cond2, b, ..., default) - The value following the first true condition, default
+//	                                         if no condition is true. default is optional, null is
+//	                                         used if it is left out.
 func Expr(name string, args ...interface{}) Expression {
+	if _, ok := conditionals[name]; ok {
+		return newCondExpr(name, args)
+	}
+
+	if len(args) > 1 {
+		if e, ok := newParamExpr(name, args); ok {
+			return e
+		}
+	}
+
+	return chainExpr(name, args...)
+}
+
+// chainExpr evaluates expressions with more than two arguments by pairwise application.
+func chainExpr(name string, args ...interface{}) Expression {
 	if len(args) == 0 {
 		// This is currently the case. It may change if introducing variables for example.
 		return errorExpr{err: qerrors.New("Expr", "Expressions require at least one argument")}
@@ -395,5 +731,5 @@ func Expr(name string, args ...interface{}) Expression {
 	newArgs := make([]interface{}, len(args)-1)
 	newArgs[0] = newExpr([]interface{}{name, args[0], args[1]})
 	copy(newArgs[1:], args[2:])
-	return Expr(name, newArgs...)
+	return chainExpr(name, newArgs...)
 }
@@ -1,6 +1,9 @@
 package function
 
-import "strconv"
+import (
+	"fmt"
+	"strconv"
+)
 
 // NotB returns the inverse of x
 func NotB(x bool) bool {
@@ -41,3 +44,11 @@ func IntB(x bool) int {
 
 	return 0
 }
+
+// FormatB returns a function that formats x according to format, see fmt.Sprintf.
+func FormatB(format string) func(x bool) *string {
+	return func(x bool) *string {
+		result := fmt.Sprintf(format, x)
+		return &result
+	}
+}
@@ -0,0 +1,129 @@
+package function
+
+import "time"
+
+// Comparison functions return bool. When used in QFrame.Apply and QFrame.Eval the result
+// is null if any of the compared values is null.
+
+// EqI returns x == y.
+func EqI(x, y int) bool {
+	return x == y
+}
+
+// NeqI returns x != y.
+func NeqI(x, y int) bool {
+	return x != y
+}
+
+// LtI returns x < y.
+func LtI(x, y int) bool {
+	return x < y
+}
+
+// LteI returns x <= y.
+func LteI(x, y int) bool {
+	return x <= y
+}
+
+// GtI returns x > y.
+func GtI(x, y int) bool {
+	return x > y
+}
+
+// GteI returns x >= y.
+func GteI(x, y int) bool {
+	return x >= y
+}
+
+// EqF returns x == y.
+func EqF(x, y float64) bool {
+	return x == y
+}
+
+// NeqF returns x != y.
+func NeqF(x, y float64) bool {
+	return x != y
+}
+
+// LtF returns x < y.
+func LtF(x, y float64) bool {
+	return x < y
+}
+
+// LteF returns x <= y.
+func LteF(x, y float64) bool {
+	return x <= y
+}
+
+// GtF returns x > y.
+func GtF(x, y float64) bool {
+	return x > y
+}
+
+// GteF returns x >= y.
+func GteF(x, y float64) bool {
+	return x >= y
+}
+
+// EqB returns x == y.
+func EqB(x, y bool) bool {
+	return x == y
+}
+
+func nilSafeCmp(f func(x, y string) bool) func(x, y *string) bool {
+	return func(x, y *string) bool {
+		if x == nil || y == nil {
+			return false
+		}
+
+		return f(*x, *y)
+	}
+}
+
+// EqS returns x == y, false if any of x and y is nil.
+var EqS = nilSafeCmp(func(x, y string) bool { return x == y })
+
+// NeqS returns x != y, false if any of x and y is nil.
+var NeqS = nilSafeCmp(func(x, y string) bool { return x != y })
+
+// LtS returns x < y, false if any of x and y is nil.
+var LtS = nilSafeCmp(func(x, y string) bool { return x < y })
+
+// LteS returns x <= y, false if any of x and y is nil.
+var LteS = nilSafeCmp(func(x, y string) bool { return x <= y })
+
+// GtS returns x > y, false if any of x and y is nil.
+var GtS = nilSafeCmp(func(x, y string) bool { return x > y })
+
+// GteS returns x >= y, false if any of x and y is nil.
+var GteS = nilSafeCmp(func(x, y string) bool { return x >= y })
+
+// EqT returns true if x and y represent the same time instant.
+func EqT(x, y time.Time) bool {
+	return x.Equal(y)
+}
+
+// NeqT returns true if x and y do not represent the same time instant.
+func NeqT(x, y time.Time) bool {
+	return !x.Equal(y)
+}
+
+// LtT returns true if x is before y.
+func LtT(x, y time.Time) bool {
+	return x.Before(y)
+}
+
+// LteT returns true if x is before or equal to y.
+func LteT(x, y time.Time) bool {
+	return !x.After(y)
+}
+
+// GtT returns true if x is after y.
+func GtT(x, y time.Time) bool {
+	return x.After(y)
+}
+
+// GteT returns true if x is after or equal to y.
+func GteT(x, y time.Time) bool {
+	return !x.Before(y)
+}
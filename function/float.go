@@ -1,6 +1,9 @@
 package function
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
 // PlusF returns x + y.
 func PlusF(x, y float64) float64 {
@@ -32,3 +35,32 @@ func StrF(x float64) *string {
 func IntF(x float64) int {
 	return int(x)
 }
+
+// RoundF returns a function that rounds x to n decimals, half away from zero.
+// Negative values of n round to the left of the decimal point.
+func RoundF(n int) func(x float64) float64 {
+	p := math.Pow(10, float64(n))
+	return func(x float64) float64 {
+		return math.Round(x*p) / p
+	}
+}
+
+// ClipF returns a function that limits x to the interval [lo, hi].
+func ClipF(lo, hi float64) func(x float64) float64 {
+	return func(x float64) float64 {
+		return math.Max(lo, math.Min(hi, x))
+	}
+}
+
+// FormatF returns a function that formats x according to format, see fmt.Sprintf.
+// NaN, which represents null, results in nil.
+func FormatF(format string) func(x float64) *string {
+	return func(x float64) *string {
+		if math.IsNaN(x) {
+			return nil
+		}
+
+		result := fmt.Sprintf(format, x)
+		return &result
+	}
+}
@@ -1,6 +1,9 @@
 package function
 
-import "strconv"
+import (
+	"fmt"
+	"strconv"
+)
 
 // AbsI returns the absolute value of x.
 func AbsI(x int) int {
@@ -45,3 +48,11 @@ func FloatI(x int) float64 {
 func BoolI(x int) bool {
 	return x != 0
 }
+
+// FormatI returns a function that formats x according to format, see fmt.Sprintf.
+func FormatI(format string) func(x int) *string {
+	return func(x int) *string {
+		result := fmt.Sprintf(format, x)
+		return &result
+	}
+}
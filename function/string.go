@@ -1,6 +1,13 @@
 package function
 
-import "strings"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yistabraq/qframe/qerrors"
+)
 
 func nilSafe(f func(string) string) func(*string) *string {
 	return func(s *string) *string {
@@ -19,11 +26,14 @@ var UpperS = nilSafe(strings.ToUpper)
 // LowerS returns the lower case representation of s.
 var LowerS = nilSafe(strings.ToLower)
 
+// TrimS returns s with all leading and trailing white space removed.
+var TrimS = nilSafe(strings.TrimSpace)
+
 // StrS returns s.
 //
 // This may appear useless but this can be used to convert enum columns to string
-// columns so that the two can be used as input to other functions. It is
-// currently not possible to combine enum and string as input.
+// columns, for example to be able to use the result as input to functions that
+// modify it.
 func StrS(s *string) *string {
 	return s
 }
@@ -50,3 +60,113 @@ func ConcatS(x, y *string) *string {
 	result := *x + *y
 	return &result
 }
+
+// ContainsS returns true if y is a substring of x, false if any of x and y is nil.
+var ContainsS = nilSafeCmp(strings.Contains)
+
+// StartsWithS returns true if x begins with y, false if any of x and y is nil.
+var StartsWithS = nilSafeCmp(strings.HasPrefix)
+
+// ReplaceS returns a function that replaces all occurrences of old in s with new.
+func ReplaceS(old, new string) func(s *string) *string {
+	return nilSafe(func(s string) string {
+		return strings.ReplaceAll(s, old, new)
+	})
+}
+
+// SubstrS returns a function that returns at most length characters of s starting
+// at character start, counting from 0. Negative values of length include all characters
+// from start to the end of s.
+func SubstrS(start, length int) func(s *string) *string {
+	return nilSafe(func(s string) string {
+		r := []rune(s)
+		if start < 0 || start >= len(r) {
+			return ""
+		}
+
+		end := len(r)
+		if length >= 0 && start+length < end {
+			end = start + length
+		}
+
+		return string(r[start:end])
+	})
+}
+
+// SplitPartS returns a function that splits s around sep and returns part n, counting
+// from 0. The result is nil if s has less than n + 1 parts.
+func SplitPartS(sep string, n int) func(s *string) *string {
+	return func(s *string) *string {
+		if s == nil || n < 0 {
+			return nil
+		}
+
+		parts := strings.SplitN(*s, sep, n+2)
+		if n >= len(parts) {
+			return nil
+		}
+
+		return &parts[n]
+	}
+}
+
+// RegexExtractS returns a function that returns the first match of pattern in s.
+// If pattern contains a capturing group the match of the first group is returned.
+// The result is nil if there is no match.
+func RegexExtractS(pattern string) (func(s *string) *string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, qerrors.Propagate("RegexExtractS", err)
+	}
+
+	group := 0
+	if re.NumSubexp() > 0 {
+		group = 1
+	}
+
+	return func(s *string) *string {
+		if s == nil {
+			return nil
+		}
+
+		match := re.FindStringSubmatch(*s)
+		if match == nil {
+			return nil
+		}
+
+		return &match[group]
+	}, nil
+}
+
+// PadS returns a function that pads s with fill to a length of width characters.
+// A positive width pads s on the left, a negative width pads s on the right. s is
+// returned unchanged if it is already as long as width. fill must be a single character.
+func PadS(width int, fill string) (func(s *string) *string, error) {
+	if utf8.RuneCountInString(fill) != 1 {
+		return nil, qerrors.New("PadS", "fill must be a single character, was '%s'", fill)
+	}
+
+	left := width > 0
+	if !left {
+		width = -width
+	}
+
+	return nilSafe(func(s string) string {
+		n := width - utf8.RuneCountInString(s)
+		if n <= 0 {
+			return s
+		}
+
+		if left {
+			return strings.Repeat(fill, n) + s
+		}
+		return s + strings.Repeat(fill, n)
+	}), nil
+}
+
+// FormatS returns a function that formats s according to format, see fmt.Sprintf.
+func FormatS(format string) func(s *string) *string {
+	return nilSafe(func(s string) string {
+		return fmt.Sprintf(format, s)
+	})
+}
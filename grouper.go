@@ -1,9 +1,11 @@
 package qframe
 
 import (
+	"github.com/yistabraq/qframe/config/rolling"
+	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/grouper"
-	"github.com/yistabraq/qframe/internal/icolumn"
 	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/parallel"
 	"github.com/yistabraq/qframe/qerrors"
 	"github.com/yistabraq/qframe/types"
 )
@@ -19,14 +21,26 @@ type Grouper struct {
 	groupedColumns []string
 	columns        []namedColumn
 	columnsByName  map[string]namedColumn
-	Err            error
-	Stats          GroupStats
+
+	// index is the index of the grouped QFrame. It is used by the operations that
+	// return a QFrame with the same rows as the grouped QFrame.
+	index index.Int
+
+	// parallelism is the maximum number of aggregations executed concurrently.
+	parallelism int
+	Err         error
+	Stats       GroupStats
 }
 
 // Aggregation represents a function to apply to a column.
 type Aggregation struct {
 	// Fn is the aggregation function to apply.
 	//
+	// In addition to the built in functions of the different column types the following
+	// built in functions are available: "count", "first", "last", "mode", "nunique",
+	// "median", "quantile(p)", "std" and "var". See aggregation.Quantile for an example
+	// of how to construct a quantile name.
+	//
 	// IMPORTANT: For pointer and reference types you must not assume that the data passed argument
 	// to this function is valid after the function returns. If you plan to keep it around you need
 	// to take a copy of the data.
@@ -65,8 +79,8 @@ func (g Grouper) Aggregate(aggs ...Aggregation) QFrame {
 		newColumns = append(newColumns, col)
 	}
 
-	var err error
-	for _, agg := range aggs {
+	aggCols := make([]namedColumn, len(aggs))
+	for i, agg := range aggs {
 		col, ok := g.columnsByName[agg.Column]
 		if !ok {
 			return QFrame{Err: qerrors.New("Aggregate", unknownCol(agg.Column))}
@@ -77,6 +91,7 @@ func (g Grouper) Aggregate(aggs ...Aggregation) QFrame {
 			newColumnName = agg.As
 		}
 		col.name = newColumnName
+		col.pos = len(newColumns)
 
 		_, ok = newColumnsByName[newColumnName]
 		if ok {
@@ -85,24 +100,23 @@ func (g Grouper) Aggregate(aggs ...Aggregation) QFrame {
 				"cannot aggregate on column that is part of group by or is already an aggregate: %s", newColumnName)}
 		}
 
-		if agg.Fn == "count" {
-			// Special convenience case for "count" which would normally require a cast from
-			// any other type of column to int before being executed.
-			counts := make([]int, len(g.indices))
-			for i, ix := range g.indices {
-				counts[i] = len(ix)
-			}
+		aggCols[i] = col
+		newColumnsByName[newColumnName] = col
+		newColumns = append(newColumns, col)
+	}
 
-			col.Column = icolumn.New(counts)
-		} else {
-			col.Column, err = col.Aggregate(g.indices, agg.Fn)
-			if err != nil {
-				return QFrame{Err: qerrors.Propagate("Aggregate", err)}
-			}
+	errs := make([]error, len(aggs))
+	parallel.For(len(aggs), g.parallelism, func(i int) {
+		aggCols[i].Column, errs[i] = aggregate(aggCols[i].Column, g.indices, aggs[i].Fn)
+	})
+
+	for i, col := range aggCols {
+		if errs[i] != nil {
+			return QFrame{Err: qerrors.Propagate("Aggregate", errs[i])}
 		}
 
-		newColumnsByName[newColumnName] = col
-		newColumns = append(newColumns, col)
+		newColumnsByName[col.name] = col
+		newColumns[col.pos] = col
 	}
 
 	return QFrame{columns: newColumns, columnsByName: newColumnsByName, index: index.NewAscending(uint32(len(g.indices)))}
@@ -123,3 +137,126 @@ func (g Grouper) QFrames() ([]QFrame, error) {
 	}
 	return result, nil
 }
+
+// frame returns the grouped QFrame.
+func (g Grouper) frame() QFrame {
+	if g.Err != nil {
+		return QFrame{Err: g.Err}
+	}
+
+	return QFrame{columns: g.columns, columnsByName: g.columnsByName, index: g.index}
+}
+
+// Transform applies the given aggregations to all row groups in the Grouper and assigns the
+// aggregated value of each group to all rows in the group. The returned QFrame contains the
+// rows of the grouped QFrame, in the same order, with the aggregated values stored in a column
+// named As, or Column if As is not set.
+//
+// This can be used to compare the values of individual rows with properties of their groups,
+// for example the share of the group total of each row.
+//
+// Time complexity O(m*n) where m = number of aggregations, n = number of rows.
+func (g Grouper) Transform(aggs ...Aggregation) QFrame {
+	qf := g.frame()
+	if qf.Err != nil {
+		return qf
+	}
+
+	for _, agg := range aggs {
+		dstCol := agg.Column
+		if agg.As != "" {
+			dstCol = agg.As
+		}
+
+		qf = qf.applySeries("Transform", dstCol, agg.Column, g.indices, func(col column.Column, series []index.Int) (column.Column, error) {
+			result, err := aggregate(col, series, agg.Fn)
+			if err != nil {
+				return nil, err
+			}
+
+			ix := make(index.Int, col.Len())
+			for i := range ix {
+				ix[i] = noMatch
+			}
+
+			for pos, s := range series {
+				for _, row := range s {
+					ix[row] = uint32(pos)
+				}
+			}
+
+			return subsetWithNull(result, ix, uint32(result.Len()))
+		})
+	}
+
+	return qf
+}
+
+// Rolling is like QFrame.Rolling but the windows never extend beyond the rows of a group.
+// The returned QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(m * n) where m = size of the window, n = number of rows.
+func (g Grouper) Rolling(fn types.SliceFuncOrBuiltInId, dstCol, srcCol string, configFns ...rolling.ConfigFunc) QFrame {
+	return g.frame().rolling(fn, dstCol, srcCol, g.indices, configFns)
+}
+
+// Shift is like QFrame.Shift but shifts the values within each group. The
+// returned QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(n) where n = number of rows.
+func (g Grouper) Shift(dstCol, srcCol string, n int) QFrame {
+	return g.frame().applySeries("Shift", dstCol, srcCol, g.indices, shiftFn(n))
+}
+
+// Diff is like QFrame.Diff but computes the differences within each group. The
+// returned QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(n) where n = number of rows.
+func (g Grouper) Diff(dstCol, srcCol string, n int) QFrame {
+	return g.frame().applySeries("Diff", dstCol, srcCol, g.indices, diffFn(n))
+}
+
+// PctChange is like QFrame.PctChange but computes the changes within each group. The
+// returned QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(n) where n = number of rows.
+func (g Grouper) PctChange(dstCol, srcCol string, n int) QFrame {
+	return g.frame().applySeries("PctChange", dstCol, srcCol, g.indices, pctChangeFn(n))
+}
+
+// CumSum is like QFrame.CumSum but restarts the sum for each group. The returned
+// QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(n) where n = number of rows.
+func (g Grouper) CumSum(dstCol, srcCol string) QFrame {
+	return g.frame().applySeries("CumSum", dstCol, srcCol, g.indices, cumSum)
+}
+
+// CumMax is like QFrame.CumMax but restarts the maximum for each group. The returned
+// QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(n) where n = number of rows.
+func (g Grouper) CumMax(dstCol, srcCol string) QFrame {
+	return g.frame().applySeries("CumMax", dstCol, srcCol, g.indices, cumBestFn(column.GreaterThan))
+}
+
+// CumMin is like QFrame.CumMin but restarts the minimum for each group. The returned
+// QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(n) where n = number of rows.
+func (g Grouper) CumMin(dstCol, srcCol string) QFrame {
+	return g.frame().applySeries("CumMin", dstCol, srcCol, g.indices, cumBestFn(column.LessThan))
+}
+
+// CumCount is like QFrame.CumCount but numbers the rows within each group. The returned
+// QFrame contains the rows of the grouped QFrame, in the same order.
+//
+// Time complexity O(n) where n = number of rows.
+func (g Grouper) CumCount(dstCol string) QFrame {
+	qf := g.frame()
+	if qf.Err != nil {
+		return qf
+	}
+
+	return qf.setColumn(dstCol, cumCount(qf.columnLen(), g.indices))
+}
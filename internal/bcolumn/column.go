@@ -1,9 +1,12 @@
 package bcolumn
 
 import (
+	"math/rand"
 	"reflect"
 	"strconv"
 
+	"github.com/yistabraq/qframe/filter"
+	"github.com/yistabraq/qframe/internal/bitmap"
 	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/hash"
 	"github.com/yistabraq/qframe/internal/index"
@@ -11,7 +14,36 @@ import (
 	"github.com/yistabraq/qframe/types"
 )
 
+// NewNullable creates a new column where values marked as invalid in valid are null.
+// valid may be nil if there are no null values.
+func NewNullable(d []bool, valid bitmap.Bitmap) Column {
+	return Column{data: d, valid: valid}
+}
+
+// Validity returns the validity bitmap of the column, nil if there are no null values.
+func (c Column) Validity() bitmap.Bitmap {
+	return c.valid
+}
+
+// IsNull returns true if the value at position i is null.
+func (v View) IsNull(i int) bool {
+	return v.valid.IsNull(v.index[i])
+}
+
 func (c Comparable) Compare(i, j uint32) column.CompareResult {
+	xNull, yNull := c.valid.IsNull(i), c.valid.IsNull(j)
+	if xNull || yNull {
+		if !xNull {
+			return c.nullGtValue
+		}
+
+		if !yNull {
+			return c.nullLtValue
+		}
+
+		return c.equalNullValue
+	}
+
 	x, y := c.data[i], c.data[j]
 	if x == y {
 		return column.Equal
@@ -25,6 +57,17 @@ func (c Comparable) Compare(i, j uint32) column.CompareResult {
 }
 
 func (c Comparable) Hash(i uint32, seed uint64) uint64 {
+	if c.valid.IsNull(i) {
+		if c.equalNullValue == column.NotEqual {
+			// Use a random value here to avoid hash collisions when
+			// we don't consider null to equal null.
+			return rand.Uint64()
+		}
+
+		b := [1]byte{2}
+		return hash.HashBytes(b[:], seed)
+	}
+
 	if c.data[i] {
 		b := [1]byte{1}
 		return hash.HashBytes(b[:], seed)
@@ -38,17 +81,25 @@ func (c Column) DataType() types.DataType {
 	return types.Bool
 }
 
-func (c Column) StringAt(i uint32, _ string) string {
+func (c Column) StringAt(i uint32, naRep string) string {
+	if c.valid.IsNull(i) {
+		return naRep
+	}
+
 	return strconv.FormatBool(c.data[i])
 }
 
 func (c Column) AppendByteStringAt(buf []byte, i uint32) []byte {
+	if c.valid.IsNull(i) {
+		return append(buf, "null"...)
+	}
+
 	return strconv.AppendBool(buf, c.data[i])
 }
 
 func (c Column) ByteSize() int {
-	// Slice header + data
-	return 2*8 + cap(c.data)
+	// Slice header + data + validity
+	return 2*8 + cap(c.data) + 8*cap(c.valid)
 }
 
 func (c Column) Equals(index index.Int, other column.Column, otherIndex index.Int) bool {
@@ -58,7 +109,16 @@ func (c Column) Equals(index index.Int, other column.Column, otherIndex index.In
 	}
 
 	for ix, x := range index {
-		if c.data[x] != otherI.data[otherIndex[ix]] {
+		oX := otherIndex[ix]
+		if c.valid.IsNull(x) || otherI.valid.IsNull(oX) {
+			if c.valid.IsNull(x) && otherI.valid.IsNull(oX) {
+				continue
+			}
+
+			return false
+		}
+
+		if c.data[x] != otherI.data[oX] {
 			return false
 		}
 	}
@@ -80,6 +140,12 @@ func (c Column) filterBuiltIn(index index.Int, comparator string, comparatee int
 			return qerrors.New("filter bool", "invalid comparison operator for bool, %v", comparator)
 		}
 		compFunc(index, c.data, t.data, bIndex)
+	case nil:
+		compFunc, ok := filterFuncs0[comparator]
+		if !ok {
+			return qerrors.New("filter bool", "invalid comparison operator to zero argument filter, %v", comparator)
+		}
+		compFunc(index, c, bIndex)
 	default:
 		return qerrors.New("filter bool", "invalid comparison value type %v", reflect.TypeOf(comparatee))
 	}
@@ -109,7 +175,37 @@ func (c Column) filterCustom2(index index.Int, fn func(bool, bool) bool, compara
 	return nil
 }
 
+// Filter applies the filter to the rows in index. Null values only match the isnull
+// and neq filters, same as for string columns.
 func (c Column) Filter(index index.Int, comparator interface{}, comparatee interface{}, bIndex index.Bool) error {
+	var otherValid bitmap.Bitmap
+	if otherC, ok := comparatee.(Column); ok {
+		otherValid = otherC.valid
+	}
+
+	if (c.valid == nil && otherValid == nil) || comparator == filter.IsNull || comparator == filter.IsNotNull {
+		return c.filter(index, comparator, comparatee, bIndex)
+	}
+
+	result := make([]bool, len(bIndex))
+	copy(result, bIndex)
+	if err := c.filter(index, comparator, comparatee, result); err != nil {
+		return err
+	}
+
+	for i, x := range bIndex {
+		if !x {
+			if pos := index[i]; c.valid.IsNull(pos) || otherValid.IsNull(pos) {
+				result[i] = comparator == filter.Neq
+			}
+		}
+	}
+
+	copy(bIndex, result)
+	return nil
+}
+
+func (c Column) filter(index index.Int, comparator interface{}, comparatee interface{}, bIndex index.Bool) error {
 	var err error
 	switch t := comparator.(type) {
 	case string:
@@ -129,6 +225,25 @@ func (c Column) FunctionType() types.FunctionType {
 }
 
 func (c Column) Append(cols ...column.Column) (column.Column, error) {
-	// TODO Append
-	return nil, qerrors.New("Append", "Not implemented yet")
+	// TODO Improve, currently copies all data over to a new column, this may not be the best solution...
+	newLen := c.Len()
+	boolCols := append(make([]Column, 0, len(cols)+1), c)
+	bitmaps, sizes := []bitmap.Bitmap{c.valid}, []int{c.Len()}
+	for _, col := range cols {
+		boolCol, ok := col.(Column)
+		if !ok {
+			return nil, qerrors.New("append bool", "can only append bool columns to bool column")
+		}
+		newLen += boolCol.Len()
+		boolCols = append(boolCols, boolCol)
+		bitmaps, sizes = append(bitmaps, boolCol.valid), append(sizes, boolCol.Len())
+	}
+
+	newData := make([]bool, newLen)
+	offset := 0
+	for _, col := range boolCols {
+		offset += copy(newData[offset:], col.data)
+	}
+
+	return NewNullable(newData, bitmap.Concat(bitmaps, sizes)), nil
 }
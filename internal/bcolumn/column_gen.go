@@ -9,9 +9,10 @@ package bcolumn
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
-	"github.com/yistabraq/qframe/config/rolling"
-
+	"github.com/yistabraq/qframe/internal/bitmap"
 	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/index"
 	"github.com/yistabraq/qframe/qerrors"
@@ -19,6 +20,10 @@ import (
 
 type Column struct {
 	data []bool
+
+	// valid keeps track of null values for types without a natural null value.
+	// It is nil if the column does not contain any nulls.
+	valid bitmap.Bitmap
 }
 
 func New(d []bool) Column {
@@ -42,30 +47,44 @@ func (c Column) fnName(name string) string {
 }
 
 // Apply single argument function. The result may be a column
-// of a different type than the current column.
+// of a different type than the current column. The function is not
+// applied to null values.
 func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
 	switch t := fn.(type) {
 	case func(bool) int:
 		result := make([]int, len(c.data))
 		for _, i := range ix {
+			if c.valid.IsNull(i) {
+				continue
+			}
 			result[i] = t(c.data[i])
 		}
 		return result, nil
 	case func(bool) float64:
 		result := make([]float64, len(c.data))
 		for _, i := range ix {
+			if c.valid.IsNull(i) {
+				result[i] = math.NaN()
+				continue
+			}
 			result[i] = t(c.data[i])
 		}
 		return result, nil
 	case func(bool) bool:
 		result := make([]bool, len(c.data))
 		for _, i := range ix {
+			if c.valid.IsNull(i) {
+				continue
+			}
 			result[i] = t(c.data[i])
 		}
 		return result, nil
 	case func(bool) *string:
 		result := make([]*string, len(c.data))
 		for _, i := range ix {
+			if c.valid.IsNull(i) {
+				continue
+			}
 			result[i] = t(c.data[i])
 		}
 		return result, nil
@@ -76,23 +95,40 @@ func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
 
 // Apply double argument function to two columns. Both columns must have the
 // same type. The resulting column will have the same type as this column.
-func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (column.Column, error) {
+// The result is null where any of the arguments are null.
+func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (interface{}, error) {
 	ss2, ok := s2.(Column)
 	if !ok {
 		return Column{}, qerrors.New(c.fnName("Apply2"), "invalid column type: %s", s2.DataType())
 	}
 
-	t, ok := fn.(func(bool, bool) bool)
-	if !ok {
-		return Column{}, qerrors.New("Apply2", "invalid function type: %#v", fn)
+	valid := c.valid.And(ss2.valid)
+	if t, ok := fn.(func(bool, bool) bool); ok {
+		result := make([]bool, len(c.data))
+		for _, i := range ix {
+			if valid.IsNull(i) {
+				continue
+			}
+			result[i] = t(c.data[i], ss2.data[i])
+		}
+
+		return Column{data: result, valid: valid}, nil
 	}
 
-	result := make([]bool, len(c.data))
-	for _, i := range ix {
-		result[i] = t(c.data[i], ss2.data[i])
+	// Separate type assertion since the function type above is the same for bool columns
+	if t, ok := fn.(func(bool, bool) bool); ok {
+		result := make([]bool, len(c.data))
+		for _, i := range ix {
+			if valid.IsNull(i) {
+				continue
+			}
+			result[i] = t(c.data[i], ss2.data[i])
+		}
+
+		return result, nil
 	}
 
-	return New(result), nil
+	return Column{}, qerrors.New("Apply2", "invalid function type: %#v", fn)
 }
 
 func (c Column) subset(index index.Int) Column {
@@ -101,7 +137,7 @@ func (c Column) subset(index index.Int) Column {
 		data[i] = c.data[ix]
 	}
 
-	return Column{data: data}
+	return Column{data: data, valid: c.valid.Subset(index)}
 }
 
 func (c Column) Subset(index index.Int) column.Column {
@@ -109,7 +145,7 @@ func (c Column) Subset(index index.Int) column.Column {
 }
 
 func (c Column) Comparable(reverse, equalNull, nullLast bool) column.Comparable {
-	result := Comparable{data: c.data, ltValue: column.LessThan, gtValue: column.GreaterThan, nullLtValue: column.LessThan, nullGtValue: column.GreaterThan, equalNullValue: column.NotEqual}
+	result := Comparable{data: c.data, valid: c.valid, ltValue: column.LessThan, gtValue: column.GreaterThan, nullLtValue: column.LessThan, nullGtValue: column.GreaterThan, equalNullValue: column.NotEqual}
 	if reverse {
 		result.ltValue, result.nullLtValue, result.gtValue, result.nullGtValue =
 			result.gtValue, result.nullGtValue, result.ltValue, result.nullLtValue
@@ -127,7 +163,20 @@ func (c Column) Comparable(reverse, equalNull, nullLast bool) column.Comparable
 }
 
 func (c Column) String() string {
-	return fmt.Sprintf("%v", c.data)
+	if c.valid == nil {
+		return fmt.Sprintf("%v", c.data)
+	}
+
+	strs := make([]string, len(c.data))
+	for i, v := range c.data {
+		if c.valid.IsNull(uint32(i)) {
+			strs[i] = "null"
+		} else {
+			strs[i] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	return "[" + strings.Join(strs, " ") + "]"
 }
 
 func (c Column) Len() int {
@@ -150,14 +199,27 @@ func (c Column) Aggregate(indices []index.Int, fn interface{}) (column.Column, e
 		return nil, qerrors.New(c.fnName("Aggregate"), "invalid aggregation function type: %v", t)
 	}
 
+	// Null values are left out of the aggregation, groups with only null values
+	// result in null.
 	data := make([]bool, 0, len(indices))
+	var valid bitmap.Bitmap
 	var buf []bool
-	for _, ix := range indices {
+	for i, ix := range indices {
 		subS := c.subsetWithBuf(ix, &buf)
+		if len(subS.data) == 0 {
+			if valid == nil {
+				valid = bitmap.New(len(indices))
+			}
+			valid.SetNull(uint32(i))
+
+			var nullVal bool
+			data = append(data, nullVal)
+			continue
+		}
 		data = append(data, actualFn(subS.data))
 	}
 
-	return Column{data: data}, nil
+	return Column{data: data, valid: valid}, nil
 }
 
 func (c Column) subsetWithBuf(index index.Int, buf *[]bool) Column {
@@ -167,6 +229,9 @@ func (c Column) subsetWithBuf(index index.Int, buf *[]bool) Column {
 
 	data := (*buf)[:0]
 	for _, ix := range index {
+		if c.valid.IsNull(ix) {
+			continue
+		}
 		data = append(data, c.data[ix])
 	}
 
@@ -174,15 +239,12 @@ func (c Column) subsetWithBuf(index index.Int, buf *[]bool) Column {
 }
 
 func (c Column) View(ix index.Int) View {
-	return View{data: c.data, index: ix}
-}
-
-func (c Column) Rolling(fn interface{}, ix index.Int, config rolling.Config) (column.Column, error) {
-	return c, nil
+	return View{data: c.data, valid: c.valid, index: ix}
 }
 
 type Comparable struct {
 	data           []bool
+	valid          bitmap.Bitmap
 	ltValue        column.CompareResult
 	nullLtValue    column.CompareResult
 	gtValue        column.CompareResult
@@ -193,6 +255,7 @@ type Comparable struct {
 // View is a view into a column that allows access to individual elements by index.
 type View struct {
 	data  []bool
+	valid bitmap.Bitmap
 	index index.Int
 }
 
@@ -14,3 +14,24 @@ var filterFuncs2 = map[string]func(index.Int, []bool, []bool, index.Bool){
 	filter.Eq:  eq2,
 	filter.Neq: neq2,
 }
+
+var filterFuncs0 = map[string]func(index.Int, Column, index.Bool){
+	filter.IsNull:    isNull,
+	filter.IsNotNull: isNotNull,
+}
+
+func isNull(index index.Int, c Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			bIndex[i] = c.valid.IsNull(index[i])
+		}
+	}
+}
+
+func isNotNull(index index.Int, c Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			bIndex[i] = !c.valid.IsNull(index[i])
+		}
+	}
+}
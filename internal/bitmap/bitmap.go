@@ -0,0 +1,130 @@
+package bitmap
+
+/*
+Package bitmap contains the validity bitmaps used to represent null values in
+column types that lack a natural null value (int and bool).
+
+Bit i is set if value i is valid, eg. not null. A nil Bitmap is used for
+columns without null values, all functions in this package accept nil bitmaps.
+*/
+
+import (
+	"math/bits"
+
+	"github.com/yistabraq/qframe/internal/index"
+)
+
+type Bitmap []uint64
+
+// New returns a bitmap of the given size with all values valid.
+func New(size int) Bitmap {
+	b := make(Bitmap, (size+63)/64)
+	for i := range b {
+		b[i] = ^uint64(0)
+	}
+
+	return b
+}
+
+// IsNull returns true if value i is null.
+func (b Bitmap) IsNull(i uint32) bool {
+	return b != nil && b[i>>6]&(1<<(i&63)) == 0
+}
+
+// SetNull marks value i as null.
+func (b Bitmap) SetNull(i uint32) {
+	b[i>>6] &^= 1 << (i & 63)
+}
+
+// SetValid marks value i as valid.
+func (b Bitmap) SetValid(i uint32) {
+	b[i>>6] |= 1 << (i & 63)
+}
+
+// NullCount returns the number of null values among the first size values.
+func (b Bitmap) NullCount(size int) int {
+	if b == nil {
+		return 0
+	}
+
+	count := 0
+	for i, w := range b {
+		rest := size - 64*i
+		if rest <= 0 {
+			break
+		}
+
+		if rest < 64 {
+			w |= ^uint64(0) << uint(rest)
+		}
+		count += 64 - bits.OnesCount64(w)
+	}
+
+	return count
+}
+
+// Subset returns a bitmap with the validity of the values in ix.
+// The result is nil if none of the values are null.
+func (b Bitmap) Subset(ix index.Int) Bitmap {
+	if b == nil {
+		return nil
+	}
+
+	var result Bitmap
+	for i, j := range ix {
+		if b.IsNull(j) {
+			if result == nil {
+				result = New(len(ix))
+			}
+			result.SetNull(uint32(i))
+		}
+	}
+
+	return result
+}
+
+// And returns a bitmap where values are valid if they are valid in both b and other.
+func (b Bitmap) And(other Bitmap) Bitmap {
+	if b == nil {
+		return other
+	}
+
+	if other == nil {
+		return b
+	}
+
+	result := make(Bitmap, len(b))
+	for i := range b {
+		result[i] = b[i] & other[i]
+	}
+
+	return result
+}
+
+// Concat concatenates bitmaps for columns of the given sizes.
+// The result is nil if none of the bitmaps contain nulls.
+func Concat(bitmaps []Bitmap, sizes []int) Bitmap {
+	total := 0
+	hasNulls := false
+	for i, b := range bitmaps {
+		total += sizes[i]
+		hasNulls = hasNulls || b.NullCount(sizes[i]) > 0
+	}
+
+	if !hasNulls {
+		return nil
+	}
+
+	result := New(total)
+	offset := uint32(0)
+	for i, b := range bitmaps {
+		for j := uint32(0); j < uint32(sizes[i]); j++ {
+			if b.IsNull(j) {
+				result.SetNull(offset + j)
+			}
+		}
+		offset += uint32(sizes[i])
+	}
+
+	return result
+}
@@ -3,8 +3,6 @@ package column
 import (
 	"fmt"
 
-	"github.com/yistabraq/qframe/config/rolling"
-
 	"github.com/yistabraq/qframe/internal/index"
 	"github.com/yistabraq/qframe/types"
 )
@@ -23,9 +21,7 @@ type Column interface {
 	Len() int
 
 	Apply1(fn interface{}, ix index.Int) (interface{}, error)
-	Apply2(fn interface{}, s2 Column, ix index.Int) (Column, error)
-
-	Rolling(fn interface{}, ix index.Int, config rolling.Config) (Column, error)
+	Apply2(fn interface{}, s2 Column, ix index.Int) (interface{}, error)
 
 	FunctionType() types.FunctionType
 	DataType() types.DataType
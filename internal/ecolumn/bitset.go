@@ -1,18 +1,30 @@
 package ecolumn
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Helper type for multi value filtering
-type bitset [4]uint64
+type bitset []uint64
 
-func (s *bitset) set(val enumVal) {
+func newBitset(size int) bitset {
+	return make(bitset, (size+63)>>6)
+}
+
+func (s bitset) set(val enumVal) {
 	s[val>>6] |= 1 << (val & 0x3F)
 }
 
-func (s *bitset) isSet(val enumVal) bool {
+func (s bitset) isSet(val enumVal) bool {
 	return s[val>>6]&(1<<(val&0x3F)) > 0
 }
 
-func (s *bitset) String() string {
-	return fmt.Sprintf("%X %X %X %X", s[3], s[2], s[1], s[0])
+func (s bitset) String() string {
+	words := make([]string, len(s))
+	for i, w := range s {
+		words[len(s)-1-i] = fmt.Sprintf("%X", w)
+	}
+
+	return strings.Join(words, " ")
 }
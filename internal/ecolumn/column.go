@@ -1,12 +1,12 @@
 package ecolumn
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
 
-	"github.com/yistabraq/qframe/config/rolling"
-
 	"github.com/yistabraq/qframe/filter"
 	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/hash"
@@ -17,10 +17,13 @@ import (
 	"github.com/yistabraq/qframe/types"
 )
 
-type enumVal uint8
+// enumVal is the code of a value in an enum column. The codes are stored
+// using 8, 16 or 32 bits depending on the cardinality of the column, see enumData.
+type enumVal uint32
 
-const maxCardinality = 255
-const nullValue = maxCardinality
+// Limited by the size of int on 32 bit platforms.
+const maxCardinality = math.MaxInt32
+const nullValue = math.MaxUint32
 
 func (v enumVal) isNull() bool {
 	return v == nullValue
@@ -37,7 +40,7 @@ func (v enumVal) compVal() int {
 }
 
 type Column struct {
-	data   []enumVal
+	data   enumData
 	values []string
 
 	// strict is set to true if the set of values has been defined rather than derived from the data.
@@ -69,6 +72,42 @@ func New(data []*string, values []string) (Column, error) {
 	return f.ToColumn(), nil
 }
 
+// AutoLimit returns the max number of distinct values that a column with rowCount rows may
+// have to be automatically stored as an enum. The number of distinct values must not exceed
+// maxCardinality or, if maxRatio > 0, maxRatio * rowCount.
+func AutoLimit(maxCardinality int, maxRatio float64, rowCount int) int {
+	if maxRatio > 0 {
+		if ratioLimit := int(maxRatio * float64(rowCount)); ratioLimit < maxCardinality {
+			return ratioLimit
+		}
+	}
+
+	return maxCardinality
+}
+
+// NewAuto returns an enum column with the content of data if the number of distinct values
+// is within the limit given by AutoLimit. ok is false otherwise.
+func NewAuto(data []*string, maxCardinality int, maxRatio float64) (c Column, ok bool) {
+	limit := AutoLimit(maxCardinality, maxRatio, len(data))
+	f, err := NewFactory(nil, len(data))
+	if err != nil {
+		return Column{}, false
+	}
+
+	for _, d := range data {
+		if d == nil {
+			f.AppendNil()
+			continue
+		}
+
+		if err := f.AppendString(*d); err != nil || f.Cardinality() > limit {
+			return Column{}, false
+		}
+	}
+
+	return f.ToColumn(), true
+}
+
 func NewConst(val *string, count int, values []string) (Column, error) {
 	f, err := NewFactory(values, count)
 	if err != nil {
@@ -102,16 +141,30 @@ func NewFactory(values []string, sizeHint int) (*Factory, error) {
 	}
 
 	return &Factory{column: Column{
-		data: make([]enumVal, 0, sizeHint), values: values, strict: len(values) > 0},
+		data: newEnumData(len(values), sizeHint), values: values, strict: len(values) > 0},
 		valToEnum: valToEnum}, nil
 }
 
+// NewFactoryFrom creates a factory for a new column with the same values as c.
+// Values not already in c are added to the new column unless c is strict.
+func NewFactoryFrom(c Column, sizeHint int) *Factory {
+	values := append(make([]string, 0, len(c.values)), c.values...)
+	valToEnum := make(map[string]enumVal, len(values))
+	for i, v := range values {
+		valToEnum[v] = enumVal(i)
+	}
+
+	return &Factory{column: Column{
+		data: newEnumData(len(values), sizeHint), values: values, strict: c.strict},
+		valToEnum: valToEnum}
+}
+
 func (f *Factory) AppendNil() {
 	f.AppendEnum(nullValue)
 }
 
 func (f *Factory) AppendEnum(val enumVal) {
-	f.column.data = append(f.column.data, val)
+	f.column.data.append(val)
 }
 
 func (f *Factory) AppendByteString(str []byte) error {
@@ -126,7 +179,7 @@ func (f *Factory) AppendByteString(str []byte) error {
 
 func (f *Factory) AppendString(str string) error {
 	if e, ok := f.valToEnum[str]; ok {
-		f.column.data = append(f.column.data, e)
+		f.AppendEnum(e)
 		return nil
 	}
 
@@ -137,6 +190,12 @@ func (f *Factory) newEnumVal(s string) enumVal {
 	ev := enumVal(len(f.column.values))
 	f.column.values = append(f.column.values, s)
 	f.valToEnum[s] = ev
+
+	// Switch to a wider code when the current width is exhausted
+	if w := widthFor(len(f.column.values)); w != f.column.data.width {
+		f.column.data = f.column.data.widen(w)
+	}
+
 	return ev
 }
 
@@ -169,11 +228,15 @@ func (f *Factory) appendString(str string) error {
 		return qerrors.New("append enum val", `enum max cardinality (%d) exceeded`, maxCardinality)
 	}
 
-	ev := f.newEnumVal(str)
-	f.column.data = append(f.column.data, ev)
+	f.AppendEnum(f.newEnumVal(str))
 	return nil
 }
 
+// Cardinality returns the number of distinct values in the column being built.
+func (f *Factory) Cardinality() int {
+	return len(f.column.values)
+}
+
 func (f *Factory) ToColumn() Column {
 	// Using the factory after this method has been called and the column exposed
 	// is not recommended.
@@ -199,11 +262,16 @@ func toUpper(_ index.Int, s Column) interface{} {
 }
 
 func (c Column) Len() int {
-	return len(c.data)
+	return c.data.len()
+}
+
+// Values returns the values that the enum may take, in enum order.
+func (c Column) Values() []string {
+	return c.values
 }
 
 func (c Column) StringAt(i uint32, naRep string) string {
-	v := c.data[i]
+	v := c.data.at(i)
 	if v.isNull() {
 		return naRep
 	}
@@ -212,7 +280,7 @@ func (c Column) StringAt(i uint32, naRep string) string {
 }
 
 func (c Column) AppendByteStringAt(buf []byte, i uint32) []byte {
-	enum := c.data[i]
+	enum := c.data.at(i)
 	if enum.isNull() {
 		return append(buf, "null"...)
 	}
@@ -225,7 +293,7 @@ func (c Column) ByteSize() int {
 	for _, s := range c.values {
 		totalSize += len(s)
 	}
-	totalSize += cap(c.data)
+	totalSize += c.data.byteSize()
 	return totalSize
 }
 
@@ -236,8 +304,8 @@ func (c Column) Equals(index index.Int, other column.Column, otherIndex index.In
 	}
 
 	for ix, x := range index {
-		enumVal := c.data[x]
-		oEnumVal := otherE.data[otherIndex[ix]]
+		enumVal := c.data.at(x)
+		oEnumVal := otherE.data.at(otherIndex[ix])
 		if enumVal.isNull() || oEnumVal.isNull() {
 			if enumVal == oEnumVal {
 				continue
@@ -254,7 +322,7 @@ func (c Column) Equals(index index.Int, other column.Column, otherIndex index.In
 }
 
 func (c Comparable) Compare(i, j uint32) column.CompareResult {
-	x, y := c.column.data[i], c.column.data[j]
+	x, y := c.column.data.at(i), c.column.data.at(j)
 	if x.isNull() || y.isNull() {
 		if !x.isNull() {
 			return c.nullGtValue
@@ -279,12 +347,25 @@ func (c Comparable) Compare(i, j uint32) column.CompareResult {
 }
 
 func (c Comparable) Hash(i uint32, seed uint64) uint64 {
-	b := [1]byte{byte(c.column.data[i])}
-	return hash.HashBytes(b[:], seed)
+	// Hash the stored code, using as few bytes as possible
+	data := c.column.data
+	switch data.width {
+	case width8:
+		b := [1]byte{data.codes8[i]}
+		return hash.HashBytes(b[:], seed)
+	case width16:
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], data.codes16[i])
+		return hash.HashBytes(b[:], seed)
+	default:
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], data.codes32[i])
+		return hash.HashBytes(b[:], seed)
+	}
 }
 
 func equalTypes(s1, s2 Column) bool {
-	if len(s1.values) != len(s2.values) || len(s1.data) != len(s2.data) {
+	if len(s1.values) != len(s2.values) || s1.Len() != s2.Len() {
 		return false
 	}
 
@@ -297,11 +378,11 @@ func equalTypes(s1, s2 Column) bool {
 	return true
 }
 
-func (c Column) filterWithBitset(index index.Int, bset *bitset, bIndex index.Bool) {
+func (c Column) filterWithBitset(index index.Int, bset bitset, bIndex index.Bool) {
 	for i, x := range bIndex {
 		if !x {
-			enum := c.data[index[i]]
-			bIndex[i] = bset.isSet(enum)
+			enum := c.data.at(index[i])
+			bIndex[i] = !enum.isNull() && bset.isSet(enum)
 		}
 	}
 }
@@ -417,12 +498,7 @@ func (c Column) Filter(index index.Int, comparator interface{}, comparatee inter
 }
 
 func (c Column) subset(index index.Int) Column {
-	data := make([]enumVal, 0, len(index))
-	for _, ix := range index {
-		data = append(data, c.data[ix])
-	}
-
-	return Column{data: data, values: c.values}
+	return Column{data: c.data.subset(index), values: c.values}
 }
 
 func (c Column) Subset(index index.Int) column.Column {
@@ -432,7 +508,7 @@ func (c Column) Subset(index index.Int) column.Column {
 func (c Column) stringSlice(index index.Int) []*string {
 	result := make([]*string, 0, len(index))
 	for _, ix := range index {
-		v := c.data[ix]
+		v := c.data.at(ix)
 		if v.isNull() {
 			result = append(result, nil)
 		} else {
@@ -461,9 +537,9 @@ func (c Column) Comparable(reverse, equalNull, nullLast bool) column.Comparable
 }
 
 func (c Column) String() string {
-	strs := make([]string, len(c.data))
-	for i, v := range c.data {
-		if v.isNull() {
+	strs := make([]string, c.Len())
+	for i := range strs {
+		if v := c.data.at(uint32(i)); v.isNull() {
 			// For now
 			strs[i] = "null"
 		} else {
@@ -492,10 +568,11 @@ func (c Column) Aggregate(indices []index.Int, fn interface{}) (column.Column, e
 }
 
 func (c Column) stringPtrAt(i uint32) *string {
-	if c.data[i].isNull() {
+	v := c.data.at(i)
+	if v.isNull() {
 		return nil
 	}
-	return &c.values[c.data[i]]
+	return &c.values[v]
 }
 
 func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
@@ -508,25 +585,25 @@ func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
 	*/
 	switch t := fn.(type) {
 	case func(*string) int:
-		result := make([]int, len(c.data))
+		result := make([]int, c.Len())
 		for _, i := range ix {
 			result[i] = t(c.stringPtrAt(i))
 		}
 		return result, nil
 	case func(*string) float64:
-		result := make([]float64, len(c.data))
+		result := make([]float64, c.Len())
 		for _, i := range ix {
 			result[i] = t(c.stringPtrAt(i))
 		}
 		return result, nil
 	case func(*string) bool:
-		result := make([]bool, len(c.data))
+		result := make([]bool, c.Len())
 		for _, i := range ix {
 			result[i] = t(c.stringPtrAt(i))
 		}
 		return result, nil
 	case func(*string) *string:
-		result := make([]*string, len(c.data))
+		result := make([]*string, c.Len())
 		for _, i := range ix {
 			result[i] = t(c.stringPtrAt(i))
 		}
@@ -541,7 +618,7 @@ func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
 	}
 }
 
-func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (column.Column, error) {
+func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (interface{}, error) {
 	s2S, ok := s2.(Column)
 	if !ok {
 		return nil, qerrors.New("enum.apply2", "invalid column type %s", s2.DataType())
@@ -549,7 +626,7 @@ func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (column.C
 
 	switch t := fn.(type) {
 	case func(*string, *string) *string:
-		result := make([]*string, len(c.data))
+		result := make([]*string, c.Len())
 		for _, i := range ix {
 			result[i] = t(c.stringPtrAt(i), s2S.stringPtrAt(i))
 		}
@@ -558,6 +635,12 @@ func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (column.C
 		// in unforeseen results (eg. it would not always fit in an enum, the order
 		// is not given, etc.).
 		return scolumn.New(result), nil
+	case func(*string, *string) bool:
+		result := make([]bool, c.Len())
+		for _, i := range ix {
+			result[i] = t(c.stringPtrAt(i), s2S.stringPtrAt(i))
+		}
+		return result, nil
 	case string:
 		// No built in functions for enums at this stage
 		return nil, qerrors.New("enum.apply2", "unknown built in function %s", t)
@@ -570,10 +653,6 @@ func (c Column) View(ix index.Int) View {
 	return View{column: c, index: ix}
 }
 
-func (c Column) Rolling(fn interface{}, ix index.Int, config rolling.Config) (column.Column, error) {
-	return c, nil
-}
-
 func (c Column) FunctionType() types.FunctionType {
 	return types.FunctionTypeString
 }
@@ -582,9 +661,57 @@ func (c Column) DataType() types.DataType {
 	return types.Enum
 }
 
+// Append creates a new enum column with the content of cols appended. The values of the
+// columns are merged, values not part of c are added in the order they are found.
 func (c Column) Append(cols ...column.Column) (column.Column, error) {
-	// TODO Append
-	return nil, qerrors.New("Append", "Not implemented yet")
+	newLen := c.Len()
+	enumCols := append(make([]Column, 0, len(cols)+1), c)
+	for _, col := range cols {
+		enumCol, ok := col.(Column)
+		if !ok {
+			return nil, qerrors.New("append enum", "can only append enum columns to enum column")
+		}
+		newLen += enumCol.Len()
+		enumCols = append(enumCols, enumCol)
+	}
+
+	values := append(make([]string, 0, len(c.values)), c.values...)
+	valToEnum := make(map[string]enumVal, len(values))
+	for i, v := range values {
+		valToEnum[v] = enumVal(i)
+	}
+
+	translations := make([][]enumVal, len(enumCols))
+	for j, col := range enumCols {
+		translation := make([]enumVal, len(col.values))
+		for i, v := range col.values {
+			e, ok := valToEnum[v]
+			if !ok {
+				if len(values) >= maxCardinality {
+					return nil, qerrors.New("append enum", "too many unique values, max cardinality is %d", maxCardinality)
+				}
+				e = enumVal(len(values))
+				valToEnum[v] = e
+				values = append(values, v)
+			}
+			translation[i] = e
+		}
+		translations[j] = translation
+	}
+
+	// The width of the new data is given by the merged values
+	newData := newEnumData(len(values), newLen)
+	for j, col := range enumCols {
+		for i := 0; i < col.Len(); i++ {
+			if v := col.data.at(uint32(i)); v.isNull() {
+				newData.append(v)
+			} else {
+				newData.append(translations[j][v])
+			}
+		}
+	}
+
+	return Column{data: newData, values: values, strict: c.strict}, nil
 }
 
 type Comparable struct {
@@ -0,0 +1,138 @@
+package ecolumn
+
+import (
+	"math"
+
+	"github.com/yistabraq/qframe/internal/index"
+)
+
+// codeWidth is the number of bits used to store each enum code.
+type codeWidth uint8
+
+const (
+	width8  codeWidth = 8
+	width16 codeWidth = 16
+	width32 codeWidth = 32
+)
+
+// widthFor returns the smallest width able to hold cardinality distinct values.
+// The largest code of each width is reserved for null.
+func widthFor(cardinality int) codeWidth {
+	switch {
+	case cardinality < math.MaxUint8:
+		return width8
+	case cardinality < math.MaxUint16:
+		return width16
+	default:
+		return width32
+	}
+}
+
+// enumData holds the codes of an enum column. To save memory, and to be cache
+// friendly, the codes are stored using the smallest width that fits the
+// cardinality of the column. Only the slice matching the width is used.
+type enumData struct {
+	width   codeWidth
+	codes8  []uint8
+	codes16 []uint16
+	codes32 []uint32
+}
+
+func newEnumData(cardinality, sizeHint int) enumData {
+	d := enumData{width: widthFor(cardinality)}
+	switch d.width {
+	case width8:
+		d.codes8 = make([]uint8, 0, sizeHint)
+	case width16:
+		d.codes16 = make([]uint16, 0, sizeHint)
+	default:
+		d.codes32 = make([]uint32, 0, sizeHint)
+	}
+
+	return d
+}
+
+func (d enumData) len() int {
+	switch d.width {
+	case width8:
+		return len(d.codes8)
+	case width16:
+		return len(d.codes16)
+	default:
+		return len(d.codes32)
+	}
+}
+
+func (d enumData) at(i uint32) enumVal {
+	switch d.width {
+	case width8:
+		if v := d.codes8[i]; v != math.MaxUint8 {
+			return enumVal(v)
+		}
+	case width16:
+		if v := d.codes16[i]; v != math.MaxUint16 {
+			return enumVal(v)
+		}
+	default:
+		return enumVal(d.codes32[i])
+	}
+
+	return nullValue
+}
+
+// append adds v to the data, v must fit in the width of the data.
+// Null is truncated into the largest code of the width.
+func (d *enumData) append(v enumVal) {
+	switch d.width {
+	case width8:
+		d.codes8 = append(d.codes8, uint8(v))
+	case width16:
+		d.codes16 = append(d.codes16, uint16(v))
+	default:
+		d.codes32 = append(d.codes32, uint32(v))
+	}
+}
+
+// widen returns a copy of the data stored using width w.
+func (d enumData) widen(w codeWidth) enumData {
+	result := enumData{width: w}
+	switch w {
+	case width16:
+		result.codes16 = make([]uint16, 0, cap(d.codes8))
+	default:
+		result.codes32 = make([]uint32, 0, cap(d.codes8)+cap(d.codes16))
+	}
+
+	for i := 0; i < d.len(); i++ {
+		result.append(d.at(uint32(i)))
+	}
+
+	return result
+}
+
+func (d enumData) subset(ix index.Int) enumData {
+	result := enumData{width: d.width}
+	switch d.width {
+	case width8:
+		result.codes8 = make([]uint8, len(ix))
+		for i, x := range ix {
+			result.codes8[i] = d.codes8[x]
+		}
+	case width16:
+		result.codes16 = make([]uint16, len(ix))
+		for i, x := range ix {
+			result.codes16[i] = d.codes16[x]
+		}
+	default:
+		result.codes32 = make([]uint32, len(ix))
+		for i, x := range ix {
+			result.codes32[i] = d.codes32[x]
+		}
+	}
+
+	return result
+}
+
+func (d enumData) byteSize() int {
+	return cap(d.codes8) + 2*cap(d.codes16) + 4*cap(d.codes32)
+}
@@ -7,12 +7,12 @@ import (
 	"github.com/yistabraq/qframe/qerrors"
 )
 
-var filterFuncs0 = map[string]func(index.Int, []enumVal, index.Bool){
+var filterFuncs0 = map[string]func(index.Int, enumData, index.Bool){
 	filter.IsNull:    isNull,
 	filter.IsNotNull: isNotNull,
 }
 
-var filterFuncs1 = map[string]func(index.Int, []enumVal, enumVal, index.Bool){
+var filterFuncs1 = map[string]func(index.Int, enumData, enumVal, index.Bool){
 	filter.Gt:  gt,
 	filter.Gte: gte,
 	filter.Lt:  lt,
@@ -21,7 +21,7 @@ var filterFuncs1 = map[string]func(index.Int, []enumVal, enumVal, index.Bool){
 	filter.Neq: neq,
 }
 
-var filterFuncs2 = map[string]func(index.Int, []enumVal, []enumVal, index.Bool){
+var filterFuncs2 = map[string]func(index.Int, enumData, enumData, index.Bool){
 	filter.Gt:  gt2,
 	filter.Gte: gte2,
 	filter.Lt:  lt2,
@@ -30,30 +30,30 @@ var filterFuncs2 = map[string]func(index.Int, []enumVal, []enumVal, index.Bool){
 	filter.Neq: neq2,
 }
 
-var multiFilterFuncs = map[string]func(comparatee string, values []string) (*bitset, error){
+var multiFilterFuncs = map[string]func(comparatee string, values []string) (bitset, error){
 	"like":  like,
 	"ilike": ilike,
 }
 
-var multiInputFilterFuncs = map[string]func(comparatee qfstrings.StringSet, values []string) *bitset{
+var multiInputFilterFuncs = map[string]func(comparatee qfstrings.StringSet, values []string) bitset{
 	"in": in,
 }
 
-func like(comp string, values []string) (*bitset, error) {
+func like(comp string, values []string) (bitset, error) {
 	return filterLike(comp, values, true)
 }
 
-func ilike(comp string, values []string) (*bitset, error) {
+func ilike(comp string, values []string) (bitset, error) {
 	return filterLike(comp, values, false)
 }
 
-func filterLike(comp string, values []string, caseSensitive bool) (*bitset, error) {
+func filterLike(comp string, values []string, caseSensitive bool) (bitset, error) {
 	matcher, err := qfstrings.NewMatcher(comp, caseSensitive)
 	if err != nil {
 		return nil, qerrors.Propagate("enum like", err)
 	}
 
-	bset := &bitset{}
+	bset := newBitset(len(values))
 	for i, v := range values {
 		if matcher.Matches(v) {
 			bset.set(enumVal(i))
@@ -63,8 +63,8 @@ func filterLike(comp string, values []string, caseSensitive bool) (*bitset, erro
 	return bset, nil
 }
 
-func in(comp qfstrings.StringSet, values []string) *bitset {
-	bset := &bitset{}
+func in(comp qfstrings.StringSet, values []string) bitset {
+	bset := newBitset(len(values))
 	for i, v := range values {
 		if comp.Contains(v) {
 			bset.set(enumVal(i))
@@ -74,37 +74,37 @@ func in(comp qfstrings.StringSet, values []string) *bitset {
 	return bset
 }
 
-func neq(index index.Int, column []enumVal, comparatee enumVal, bIndex index.Bool) {
+func neq(index index.Int, column enumData, comparatee enumVal, bIndex index.Bool) {
 	for i, x := range bIndex {
 		if !x {
-			enum := column[index[i]]
+			enum := column.at(index[i])
 			bIndex[i] = enum.isNull() || enum.compVal() != comparatee.compVal()
 		}
 	}
 }
 
-func neq2(index index.Int, col, col2 []enumVal, bIndex index.Bool) {
+func neq2(index index.Int, col, col2 enumData, bIndex index.Bool) {
 	for i, x := range bIndex {
 		if !x {
-			enum, enum2 := col[index[i]], col2[index[i]]
+			enum, enum2 := col.at(index[i]), col2.at(index[i])
 			bIndex[i] = enum.isNull() || enum2.isNull() || enum.compVal() != enum2.compVal()
 		}
 	}
 }
 
-func isNull(index index.Int, col []enumVal, bIndex index.Bool) {
+func isNull(index index.Int, col enumData, bIndex index.Bool) {
 	for i, x := range bIndex {
 		if !x {
-			enum := col[index[i]]
+			enum := col.at(index[i])
 			bIndex[i] = enum.isNull()
 		}
 	}
 }
 
-func isNotNull(index index.Int, col []enumVal, bIndex index.Bool) {
+func isNotNull(index index.Int, col enumData, bIndex index.Bool) {
 	for i, x := range bIndex {
 		if !x {
-			enum := col[index[i]]
+			enum := col.at(index[i])
 			bIndex[i] = !enum.isNull()
 		}
 	}
@@ -12,10 +12,10 @@ import (
 //go:generate qfgenerate -source=edoc -dst-file=doc_gen.go
 
 const basicColConstComparison = `
-func {{.name}}(index index.Int, column []enumVal, comparatee enumVal, bIndex index.Bool) {
+func {{.name}}(index index.Int, column enumData, comparatee enumVal, bIndex index.Bool) {
 	for i, x := range bIndex {
 		if !x {
-			enum := column[index[i]]
+			enum := column.at(index[i])
 			bIndex[i] = !enum.isNull() && enum.compVal() {{.operator}} comparatee.compVal()
 		}
 	}
@@ -23,10 +23,10 @@ func {{.name}}(index index.Int, column []enumVal, comparatee enumVal, bIndex ind
 `
 
 const basicColColComparison = `
-func {{.name}}(index index.Int, col, col2 []enumVal, bIndex index.Bool) {
+func {{.name}}(index index.Int, col, col2 enumData, bIndex index.Bool) {
 	for i, x := range bIndex {
 		if !x {
-			enum, enum2 := col[index[i]], col2[index[i]]
+			enum, enum2 := col.at(index[i]), col2.at(index[i])
 			bIndex[i] = !enum.isNull() && !enum2.isNull() && enum.compVal() {{.operator}} enum2.compVal()
 		}
 	}
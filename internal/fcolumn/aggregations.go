@@ -2,6 +2,8 @@ package fcolumn
 
 import "math"
 
+// Built in aggregations. NaN values are considered null and are skipped, the
+// result is NaN only if all values are NaN.
 var aggregations = map[string]func([]float64) float64{
 	"max": max,
 	"min": min,
@@ -10,34 +12,48 @@ var aggregations = map[string]func([]float64) float64{
 }
 
 func sum(values []float64) float64 {
-	result := 0.0
+	result, count := 0.0, 0
 	for _, v := range values {
-		result += v
+		if !math.IsNaN(v) {
+			result += v
+			count++
+		}
+	}
+
+	if count == 0 {
+		return math.NaN()
 	}
 	return result
 }
 
 func avg(values []float64) float64 {
-	result := 0.0
+	result, count := 0.0, 0
 	for _, v := range values {
-		result += v
+		if !math.IsNaN(v) {
+			result += v
+			count++
+		}
 	}
 
-	return result / float64(len(values))
+	return result / float64(count)
 }
 
 func max(values []float64) float64 {
-	result := values[0]
-	for _, v := range values[1:] {
-		result = math.Max(result, v)
+	result := math.NaN()
+	for _, v := range values {
+		if math.IsNaN(result) || v > result {
+			result = v
+		}
 	}
 	return result
 }
 
 func min(values []float64) float64 {
-	result := values[0]
-	for _, v := range values[1:] {
-		result = math.Min(result, v)
+	result := math.NaN()
+	for _, v := range values {
+		if math.IsNaN(result) || v < result {
+			result = v
+		}
 	}
 	return result
 }
@@ -172,6 +172,23 @@ func (c Column) FunctionType() types.FunctionType {
 }
 
 func (c Column) Append(cols ...column.Column) (column.Column, error) {
-	// TODO Append
-	return nil, qerrors.New("Append", "Not implemented yet")
+	// TODO Improve, currently copies all data over to a new column, this may not be the best solution...
+	newLen := c.Len()
+	floatCols := append(make([]Column, 0, len(cols)+1), c)
+	for _, col := range cols {
+		floatCol, ok := col.(Column)
+		if !ok {
+			return nil, qerrors.New("append float", "can only append float columns to float column")
+		}
+		newLen += floatCol.Len()
+		floatCols = append(floatCols, floatCol)
+	}
+
+	newData := make([]float64, newLen)
+	offset := 0
+	for _, col := range floatCols {
+		offset += copy(newData[offset:], col.data)
+	}
+
+	return New(newData), nil
 }
@@ -22,6 +22,8 @@ type tableEntry struct {
 	ix       index.Int
 	hash     uint32
 	firstPos uint32
+	// seq is the position in the grouped index of the first row in the group.
+	seq      uint32
 	occupied bool
 }
 
@@ -66,12 +68,11 @@ func (t *table) hash(i uint32) uint32 {
 
 const maxLoadFactor = 0.5
 
-func (t *table) insertEntry(i uint32) {
+func (t *table) insertEntry(i, hashSum, seq uint32) {
 	if t.loadFactor > maxLoadFactor {
 		t.grow()
 	}
 
-	hashSum := t.hash(i)
 	bitMask := uint64(len(t.entries) - 1)
 	startPos := uint64(hashSum) & bitMask
 	var dstEntry *tableEntry
@@ -89,6 +90,7 @@ func (t *table) insertEntry(i uint32) {
 		// Eden entry
 		dstEntry.hash = hashSum
 		dstEntry.firstPos = i
+		dstEntry.seq = seq
 		dstEntry.occupied = true
 		t.groupCount++
 		t.loadFactor = float64(t.groupCount) / float64(len(t.entries))
@@ -142,8 +144,8 @@ func calculateInitialSizeExp(ixLen int) int {
 func groupIndex(ix index.Int, comparables []column.Comparable, collectIx bool) ([]tableEntry, GroupStats) {
 	initialSizeExp := calculateInitialSizeExp(len(ix))
 	table := newTable(initialSizeExp, comparables, collectIx)
-	for _, i := range ix {
-		table.insertEntry(i)
+	for k, i := range ix {
+		table.insertEntry(i, table.hash(i), uint32(k))
 	}
 
 	stats := table.stats
@@ -154,6 +156,10 @@ func groupIndex(ix index.Int, comparables []column.Comparable, collectIx bool) (
 
 func GroupBy(ix index.Int, comparables []column.Comparable) ([]index.Int, GroupStats) {
 	entries, stats := groupIndex(ix, comparables, true)
+	return groups(entries, stats), stats
+}
+
+func groups(entries []tableEntry, stats GroupStats) []index.Int {
 	result := make([]index.Int, 0, stats.GroupCount)
 	for _, e := range entries {
 		if e.occupied {
@@ -165,7 +171,7 @@ func GroupBy(ix index.Int, comparables []column.Comparable) ([]index.Int, GroupS
 		}
 	}
 
-	return result, stats
+	return result
 }
 
 func Distinct(ix index.Int, comparables []column.Comparable) index.Int {
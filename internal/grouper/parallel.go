@@ -0,0 +1,144 @@
+package grouper
+
+import (
+	"sort"
+
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/parallel"
+)
+
+/*
+Parallel group by is done in three steps:
+
+1. The hash of every row is calculated in parallel over chunks of the index. At the same
+   time the rows are distributed into partitions based on the hash.
+2. One hash table per partition is built in parallel. Since rows that are equal have the
+   same hash all rows of a group end up in the same partition.
+3. The groups of all partitions are merged by inserting them, in the order they were first
+   seen, into a table in the same way as the sequential group by does. This results in the
+   same groups, in the same order, as a sequential group by.
+*/
+
+// Below this number of rows the overhead of parallelization is not worth it.
+const parallelMinRows = 1 << 13
+
+// GroupByParallel groups the rows in ix using up to parallelism goroutines. The result is the
+// same as that of GroupBy.
+func GroupByParallel(ix index.Int, comparables []column.Comparable, parallelism int) ([]index.Int, GroupStats) {
+	if parallelism <= 1 || len(ix) < parallelMinRows {
+		return GroupBy(ix, comparables)
+	}
+
+	hashes, partitions := partitionIndex(ix, comparables, parallelism)
+
+	// Build one table per partition
+	partEntries := make([][]tableEntry, len(partitions))
+	parallel.For(len(partitions), parallelism, func(p int) {
+		partEntries[p] = groupPartition(ix, hashes, partitions[p], comparables)
+	})
+
+	// Merge the groups in the order they were first seen
+	groupCount := 0
+	for _, entries := range partEntries {
+		groupCount += len(entries)
+	}
+
+	merged := make([]tableEntry, 0, groupCount)
+	for _, entries := range partEntries {
+		merged = append(merged, entries...)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].seq < merged[j].seq })
+
+	table := newTable(calculateInitialSizeExp(len(ix)), comparables, true)
+	for _, e := range merged {
+		table.insertNewEntry(e)
+	}
+
+	// The sequential group by grows the table when inserting the row following the one that
+	// made the load factor exceed the max.
+	if table.loadFactor > maxLoadFactor && len(merged) > 0 && int(merged[len(merged)-1].seq) < len(ix)-1 {
+		table.grow()
+	}
+
+	stats := table.stats
+	stats.LoadFactor = table.loadFactor
+	stats.GroupCount = int(table.groupCount)
+	return groups(table.entries, stats), stats
+}
+
+// partitionIndex calculates the hash of all rows in ix and distributes the positions in ix
+// of the rows into partitions. Each partition is a list of chunks, in index order.
+func partitionIndex(ix index.Int, comparables []column.Comparable, parallelism int) ([]uint32, [][][]uint32) {
+	hashes := make([]uint32, len(ix))
+	chunkSize := (len(ix) + parallelism - 1) / parallelism
+	chunkCount := (len(ix) + chunkSize - 1) / chunkSize
+
+	// partitions[p][c] holds the positions of the rows in chunk c that belong to partition p
+	partitions := make([][][]uint32, parallelism)
+	for p := range partitions {
+		partitions[p] = make([][]uint32, chunkCount)
+	}
+
+	hasher := table{comparables: comparables}
+	parallel.For(chunkCount, parallelism, func(c int) {
+		start, end := c*chunkSize, (c+1)*chunkSize
+		if end > len(ix) {
+			end = len(ix)
+		}
+
+		for k := start; k < end; k++ {
+			h := hasher.hash(ix[k])
+			hashes[k] = h
+
+			// Use the high bits of the hash to select partition since the low bits are used
+			// to select position in the tables.
+			p := (uint64(h) * uint64(parallelism)) >> 32
+			partitions[p][c] = append(partitions[p][c], uint32(k))
+		}
+	})
+
+	return hashes, partitions
+}
+
+// groupPartition groups the rows at the given positions in ix and returns the groups.
+func groupPartition(ix index.Int, hashes []uint32, chunks [][]uint32, comparables []column.Comparable) []tableEntry {
+	rowCount := 0
+	for _, chunk := range chunks {
+		rowCount += len(chunk)
+	}
+
+	table := newTable(calculateInitialSizeExp(rowCount), comparables, true)
+	for _, chunk := range chunks {
+		for _, k := range chunk {
+			table.insertEntry(ix[k], hashes[k], k)
+		}
+	}
+
+	result := make([]tableEntry, 0, table.groupCount)
+	for _, e := range table.entries {
+		if e.occupied {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+// insertNewEntry inserts an entry for a group that is not yet present in the table.
+func (t *table) insertNewEntry(e tableEntry) {
+	if t.loadFactor > maxLoadFactor {
+		t.grow()
+	}
+
+	bitMask := uint32(len(t.entries) - 1)
+	pos := e.hash & bitMask
+	for t.entries[pos].occupied {
+		t.stats.InsertCollisions++
+		pos = (pos + 1) & bitMask
+	}
+
+	t.entries[pos] = e
+	t.groupCount++
+	t.loadFactor = float64(t.groupCount) / float64(len(t.entries))
+}
@@ -1,10 +1,14 @@
 package icolumn
 
 import (
+	"math"
+	"math/rand"
 	"reflect"
 	"strconv"
 	"unsafe"
 
+	"github.com/yistabraq/qframe/filter"
+	"github.com/yistabraq/qframe/internal/bitmap"
 	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/hash"
 	"github.com/yistabraq/qframe/internal/index"
@@ -12,21 +16,40 @@ import (
 	"github.com/yistabraq/qframe/types"
 )
 
+// NewNullable creates a new column where values marked as invalid in valid are null.
+// valid may be nil if there are no null values.
+func NewNullable(d []int, valid bitmap.Bitmap) Column {
+	return Column{data: d, valid: valid}
+}
+
+// Validity returns the validity bitmap of the column, nil if there are no null values.
+func (c Column) Validity() bitmap.Bitmap {
+	return c.valid
+}
+
 func (c Column) DataType() types.DataType {
 	return types.Int
 }
 
-func (c Column) StringAt(i uint32, _ string) string {
+func (c Column) StringAt(i uint32, naRep string) string {
+	if c.valid.IsNull(i) {
+		return naRep
+	}
+
 	return strconv.FormatInt(int64(c.data[i]), 10)
 }
 
 func (c Column) AppendByteStringAt(buf []byte, i uint32) []byte {
+	if c.valid.IsNull(i) {
+		return append(buf, "null"...)
+	}
+
 	return strconv.AppendInt(buf, int64(c.data[i]), 10)
 }
 
 func (c Column) ByteSize() int {
-	// Slice header + data
-	return 2*8 + 8*cap(c.data)
+	// Slice header + data + validity
+	return 2*8 + 8*cap(c.data) + 8*cap(c.valid)
 }
 
 func (c Column) Equals(index index.Int, other column.Column, otherIndex index.Int) bool {
@@ -36,7 +59,16 @@ func (c Column) Equals(index index.Int, other column.Column, otherIndex index.In
 	}
 
 	for ix, x := range index {
-		if c.data[x] != otherI.data[otherIndex[ix]] {
+		oX := otherIndex[ix]
+		if c.valid.IsNull(x) || otherI.valid.IsNull(oX) {
+			if c.valid.IsNull(x) && otherI.valid.IsNull(oX) {
+				continue
+			}
+
+			return false
+		}
+
+		if c.data[x] != otherI.data[oX] {
 			return false
 		}
 	}
@@ -44,16 +76,39 @@ func (c Column) Equals(index index.Int, other column.Column, otherIndex index.In
 	return true
 }
 
+// FloatSlice returns the content of the column as floats, null values are returned as NaN.
 func (c Column) FloatSlice() []float64 {
 	result := make([]float64, len(c.data))
 	for i, v := range c.data {
-		result[i] = float64(v)
+		if c.valid.IsNull(uint32(i)) {
+			result[i] = math.NaN()
+		} else {
+			result[i] = float64(v)
+		}
 	}
 
 	return result
 }
 
+// IsNull returns true if the value at position i is null.
+func (v View) IsNull(i int) bool {
+	return v.valid.IsNull(v.index[i])
+}
+
 func (c Comparable) Compare(i, j uint32) column.CompareResult {
+	xNull, yNull := c.valid.IsNull(i), c.valid.IsNull(j)
+	if xNull || yNull {
+		if !xNull {
+			return c.nullGtValue
+		}
+
+		if !yNull {
+			return c.nullLtValue
+		}
+
+		return c.equalNullValue
+	}
+
 	x, y := c.data[i], c.data[j]
 	if x < y {
 		return c.ltValue
@@ -67,6 +122,17 @@ func (c Comparable) Compare(i, j uint32) column.CompareResult {
 }
 
 func (c Comparable) Hash(i uint32, seed uint64) uint64 {
+	if c.valid.IsNull(i) {
+		if c.equalNullValue == column.NotEqual {
+			// Use a random value here to avoid hash collisions when
+			// we don't consider null to equal null.
+			return rand.Uint64()
+		}
+
+		b := [1]byte{0}
+		return hash.HashBytes(b[:], seed)
+	}
+
 	x := &c.data[i]
 	b := (*[8]byte)(unsafe.Pointer(x))[:]
 	return hash.HashBytes(b, seed)
@@ -155,7 +221,7 @@ func (c Column) filterBuiltIn(index index.Int, comparator string, comparatee int
 		if !ok {
 			return qerrors.New("filter int", "invalid comparison operator to zero argument filter, %v", comparator)
 		}
-		compFunc(index, c.data, bIndex)
+		compFunc(index, c, bIndex)
 	} else {
 		return qerrors.New("filter int", "invalid comparison value type %v", reflect.TypeOf(comparatee))
 	}
@@ -186,7 +252,37 @@ func (c Column) filterCustom2(index index.Int, fn func(int, int) bool, comparate
 	return nil
 }
 
+// Filter applies the filter to the rows in index. Null values only match the isnull
+// and neq filters, same as for string columns.
 func (c Column) Filter(index index.Int, comparator interface{}, comparatee interface{}, bIndex index.Bool) error {
+	var otherValid bitmap.Bitmap
+	if otherC, ok := comparatee.(Column); ok {
+		otherValid = otherC.valid
+	}
+
+	if (c.valid == nil && otherValid == nil) || comparator == filter.IsNull || comparator == filter.IsNotNull {
+		return c.filter(index, comparator, comparatee, bIndex)
+	}
+
+	result := make([]bool, len(bIndex))
+	copy(result, bIndex)
+	if err := c.filter(index, comparator, comparatee, result); err != nil {
+		return err
+	}
+
+	for i, x := range bIndex {
+		if !x {
+			if pos := index[i]; c.valid.IsNull(pos) || otherValid.IsNull(pos) {
+				result[i] = comparator == filter.Neq
+			}
+		}
+	}
+
+	copy(bIndex, result)
+	return nil
+}
+
+func (c Column) filter(index index.Int, comparator interface{}, comparatee interface{}, bIndex index.Bool) error {
 	var err error
 	switch t := comparator.(type) {
 	case string:
@@ -209,6 +305,7 @@ func (c Column) Append(cols ...column.Column) (column.Column, error) {
 	// TODO Improve, currently copies all data over to a new column, this may not be the best solution...
 	newLen := c.Len()
 	intCols := append(make([]Column, 0, len(cols)+1), c)
+	bitmaps, sizes := []bitmap.Bitmap{c.valid}, []int{c.Len()}
 	for _, col := range cols {
 		intCol, ok := col.(Column)
 		if !ok {
@@ -216,6 +313,7 @@ func (c Column) Append(cols ...column.Column) (column.Column, error) {
 		}
 		newLen += intCol.Len()
 		intCols = append(intCols, intCol)
+		bitmaps, sizes = append(bitmaps, intCol.valid), append(sizes, intCol.Len())
 	}
 
 	newData := make([]int, newLen)
@@ -224,5 +322,5 @@ func (c Column) Append(cols ...column.Column) (column.Column, error) {
 		offset += copy(newData[offset:], col.data)
 	}
 
-	return New(newData), nil
+	return NewNullable(newData, bitmap.Concat(bitmaps, sizes)), nil
 }
@@ -0,0 +1,374 @@
+package arrow
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+/*
+This file contains a minimal implementation of the parts of the flatbuffers wire format
+needed to read and write Arrow IPC metadata. Only the little endian layout used by Arrow
+is supported.
+
+Reading is done through fbTable which gives access to the fields of a table given
+their field ids as listed in the Arrow schema files (Schema.fbs, Message.fbs).
+
+Writing is done front to back. Since flatbuffer offsets must point forward tables are
+written before the objects they reference and the offsets are patched once the position
+of the referenced objects is known.
+*/
+
+var le = binary.LittleEndian
+
+// malformedError is used to abort parsing of malformed flatbuffers. It is
+// recovered at the top level of the reader and turned into a regular error.
+type malformedError struct {
+	reason string
+}
+
+func checkBounds(buf []byte, pos, size int) {
+	if pos < 0 || size < 0 || pos+size > len(buf) {
+		panic(malformedError{reason: "flatbuffer offset out of range"})
+	}
+}
+
+type fbTable struct {
+	buf []byte
+	pos int
+}
+
+func rootTable(buf []byte) fbTable {
+	checkBounds(buf, 0, 4)
+	return fbTable{buf: buf, pos: int(le.Uint32(buf))}
+}
+
+// fieldPos returns the absolute position of field id, or 0 if the field is not present.
+func (t fbTable) fieldPos(id int) int {
+	checkBounds(t.buf, t.pos, 4)
+	vtPos := t.pos - int(int32(le.Uint32(t.buf[t.pos:])))
+	checkBounds(t.buf, vtPos, 4)
+	vtLen := int(le.Uint16(t.buf[vtPos:]))
+	entry := 4 + 2*id
+	if entry+2 > vtLen {
+		return 0
+	}
+
+	checkBounds(t.buf, vtPos+entry, 2)
+	offset := int(le.Uint16(t.buf[vtPos+entry:]))
+	if offset == 0 {
+		return 0
+	}
+
+	return t.pos + offset
+}
+
+func (t fbTable) uint8(id int, def uint8) uint8 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return def
+	}
+
+	checkBounds(t.buf, pos, 1)
+	return t.buf[pos]
+}
+
+func (t fbTable) bool(id int, def bool) bool {
+	var d uint8
+	if def {
+		d = 1
+	}
+
+	return t.uint8(id, d) != 0
+}
+
+func (t fbTable) int16(id int, def int16) int16 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return def
+	}
+
+	checkBounds(t.buf, pos, 2)
+	return int16(le.Uint16(t.buf[pos:]))
+}
+
+func (t fbTable) int32(id int, def int32) int32 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return def
+	}
+
+	checkBounds(t.buf, pos, 4)
+	return int32(le.Uint32(t.buf[pos:]))
+}
+
+func (t fbTable) int64(id int, def int64) int64 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return def
+	}
+
+	checkBounds(t.buf, pos, 8)
+	return int64(le.Uint64(t.buf[pos:]))
+}
+
+// indirect follows the offset stored at pos.
+func (t fbTable) indirect(pos int) int {
+	checkBounds(t.buf, pos, 4)
+	return pos + int(le.Uint32(t.buf[pos:]))
+}
+
+func (t fbTable) table(id int) (fbTable, bool) {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return fbTable{}, false
+	}
+
+	return fbTable{buf: t.buf, pos: t.indirect(pos)}, true
+}
+
+func (t fbTable) string(id int) string {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return ""
+	}
+
+	strPos := t.indirect(pos)
+	checkBounds(t.buf, strPos, 4)
+	strLen := int(le.Uint32(t.buf[strPos:]))
+	checkBounds(t.buf, strPos+4, strLen)
+	return string(t.buf[strPos+4 : strPos+4+strLen])
+}
+
+// vector returns the position of the first element and the length of the vector in field id.
+func (t fbTable) vector(id int) (int, int) {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return 0, 0
+	}
+
+	vecPos := t.indirect(pos)
+	checkBounds(t.buf, vecPos, 4)
+	return vecPos + 4, int(le.Uint32(t.buf[vecPos:]))
+}
+
+func (t fbTable) tables(id int) []fbTable {
+	start, length := t.vector(id)
+	checkBounds(t.buf, start, 4*length)
+	result := make([]fbTable, length)
+	for i := range result {
+		result[i] = fbTable{buf: t.buf, pos: t.indirect(start + 4*i)}
+	}
+
+	return result
+}
+
+// structs returns the raw bytes of each element in a vector of structs of the given size.
+func (t fbTable) structs(id int, size int) [][]byte {
+	start, length := t.vector(id)
+	checkBounds(t.buf, start, size*length)
+	result := make([][]byte, length)
+	for i := range result {
+		result[i] = t.buf[start+i*size : start+(i+1)*size]
+	}
+
+	return result
+}
+
+////////////////
+//// Writing ///
+////////////////
+
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) pad(alignment int) {
+	for len(b.buf)%alignment != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) putUint32(pos int, v uint32) {
+	le.PutUint32(b.buf[pos:], v)
+}
+
+func (b *fbBuilder) appendUint32(v uint32) {
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	b.putUint32(len(b.buf)-4, v)
+}
+
+// patch stores the offset from pos to target at pos.
+func (b *fbBuilder) patch(pos, target int) {
+	b.putUint32(pos, uint32(target-pos))
+}
+
+// fbObject is anything that can be written to a flatbuffer and referenced by offset.
+type fbObject interface {
+	write(b *fbBuilder) int
+}
+
+type fbField struct {
+	id    int
+	size  int
+	value uint64
+	ref   fbObject
+}
+
+// fbTableDef describes a table to write. Scalars and references are added by field id.
+type fbTableDef struct {
+	fields []fbField
+}
+
+func newTableDef() *fbTableDef {
+	return &fbTableDef{}
+}
+
+func (t *fbTableDef) scalar(id, size int, value uint64) *fbTableDef {
+	t.fields = append(t.fields, fbField{id: id, size: size, value: value})
+	return t
+}
+
+func (t *fbTableDef) uint8(id int, v uint8) *fbTableDef {
+	return t.scalar(id, 1, uint64(v))
+}
+
+func (t *fbTableDef) bool(id int, v bool) *fbTableDef {
+	if v {
+		return t.uint8(id, 1)
+	}
+
+	return t.uint8(id, 0)
+}
+
+func (t *fbTableDef) int16(id int, v int16) *fbTableDef {
+	return t.scalar(id, 2, uint64(uint16(v)))
+}
+
+func (t *fbTableDef) int32(id int, v int32) *fbTableDef {
+	return t.scalar(id, 4, uint64(uint32(v)))
+}
+
+func (t *fbTableDef) int64(id int, v int64) *fbTableDef {
+	return t.scalar(id, 8, uint64(v))
+}
+
+func (t *fbTableDef) ref(id int, obj fbObject) *fbTableDef {
+	t.fields = append(t.fields, fbField{id: id, size: 4, ref: obj})
+	return t
+}
+
+func align(pos, alignment int) int {
+	return (pos + alignment - 1) / alignment * alignment
+}
+
+func (t *fbTableDef) write(b *fbBuilder) int {
+	fieldCount := 0
+	for _, f := range t.fields {
+		if f.id+1 > fieldCount {
+			fieldCount = f.id + 1
+		}
+	}
+
+	// Lay out fields within the table, biggest first to minimize padding.
+	// Position 0 in the table holds the offset to the vtable.
+	fields := make([]fbField, len(t.fields))
+	copy(fields, t.fields)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].size > fields[j].size })
+	offsets := make([]int, len(fields))
+	tableSize := 4
+	for i, f := range fields {
+		offsets[i] = align(tableSize, f.size)
+		tableSize = offsets[i] + f.size
+	}
+
+	// The vtable goes immediately before the table which is aligned to eight bytes
+	// to allow for 64 bit fields.
+	b.pad(2)
+	vtPos := len(b.buf)
+	vtSize := 4 + 2*fieldCount
+	tablePos := align(vtPos+vtSize, 8)
+	b.buf = append(b.buf, make([]byte, tablePos-vtPos+tableSize)...)
+
+	le.PutUint16(b.buf[vtPos:], uint16(vtSize))
+	le.PutUint16(b.buf[vtPos+2:], uint16(tableSize))
+	le.PutUint32(b.buf[tablePos:], uint32(tablePos-vtPos))
+	for i, f := range fields {
+		le.PutUint16(b.buf[vtPos+4+2*f.id:], uint16(offsets[i]))
+		pos := tablePos + offsets[i]
+		switch f.size {
+		case 1:
+			b.buf[pos] = byte(f.value)
+		case 2:
+			le.PutUint16(b.buf[pos:], uint16(f.value))
+		case 4:
+			le.PutUint32(b.buf[pos:], uint32(f.value))
+		case 8:
+			le.PutUint64(b.buf[pos:], f.value)
+		}
+	}
+
+	for i, f := range fields {
+		if f.ref != nil {
+			refPos := f.ref.write(b)
+			b.patch(tablePos+offsets[i], refPos)
+		}
+	}
+
+	return tablePos
+}
+
+type fbString string
+
+func (s fbString) write(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.appendUint32(uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+type fbTableVector []fbObject
+
+func (v fbTableVector) write(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.appendUint32(uint32(len(v)))
+	elemStart := len(b.buf)
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, obj := range v {
+		objPos := obj.write(b)
+		b.patch(elemStart+4*i, objPos)
+	}
+
+	return pos
+}
+
+// fbStructVector is a vector of structs where all struct fields are 64 bit wide.
+type fbStructVector [][]int64
+
+func (v fbStructVector) write(b *fbBuilder) int {
+	// Elements must be aligned to eight bytes, the length prefix is placed right before them.
+	for (len(b.buf)+4)%8 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+
+	pos := len(b.buf)
+	b.appendUint32(uint32(len(v)))
+	for _, s := range v {
+		for _, x := range s {
+			b.buf = append(b.buf, 0, 0, 0, 0, 0, 0, 0, 0)
+			le.PutUint64(b.buf[len(b.buf)-8:], uint64(x))
+		}
+	}
+
+	return pos
+}
+
+// finish writes root as the root table of a new flatbuffer and returns the bytes.
+func finish(root fbObject) []byte {
+	b := &fbBuilder{buf: make([]byte, 4, 256)}
+	rootPos := root.write(b)
+	b.putUint32(0, uint32(rootPos))
+	return b.buf
+}
@@ -0,0 +1,96 @@
+package arrow
+
+// Constants from the Arrow flatbuffer schemas (Schema.fbs and Message.fbs).
+// Only the subset needed to map to and from QFrame columns is listed.
+
+const (
+	metadataV4 = 3
+
+	continuationMarker = 0xFFFFFFFF
+)
+
+// MessageHeader union
+const (
+	headerSchema          = 1
+	headerDictionaryBatch = 2
+	headerRecordBatch     = 3
+)
+
+// Type union
+const (
+	typeNull          = 1
+	typeInt           = 2
+	typeFloatingPoint = 3
+	typeBinary        = 4
+	typeUtf8          = 5
+	typeBool          = 6
+	typeTimestamp     = 10
+)
+
+// TimeUnit
+const (
+	unitSecond      = 0
+	unitMillisecond = 1
+	unitMicrosecond = 2
+	unitNanosecond  = 3
+)
+
+// FloatingPoint precision
+const (
+	precisionHalf   = 0
+	precisionSingle = 1
+	precisionDouble = 2
+)
+
+// Field ids of the tables used
+const (
+	// Message
+	messageVersion    = 0
+	messageHeaderType = 1
+	messageHeader     = 2
+	messageBodyLength = 3
+
+	// Schema
+	schemaFields = 1
+
+	// Field
+	fieldName       = 0
+	fieldNullable   = 1
+	fieldTypeType   = 2
+	fieldType       = 3
+	fieldDictionary = 4
+	fieldChildren   = 5
+
+	// Int
+	intBitWidth = 0
+	intIsSigned = 1
+
+	// FloatingPoint
+	floatingPointPrecision = 0
+
+	// Timestamp
+	timestampUnit     = 0
+	timestampTimezone = 1
+
+	// DictionaryEncoding
+	dictionaryEncodingID        = 0
+	dictionaryEncodingIndexType = 1
+	dictionaryEncodingIsOrdered = 2
+
+	// RecordBatch
+	recordBatchLength      = 0
+	recordBatchNodes       = 1
+	recordBatchBuffers     = 2
+	recordBatchCompression = 3
+
+	// DictionaryBatch
+	dictionaryBatchID      = 0
+	dictionaryBatchData    = 1
+	dictionaryBatchIsDelta = 2
+)
+
+// Sizes of the structs FieldNode and Buffer
+const (
+	fieldNodeSize = 16
+	bufferSize    = 16
+)
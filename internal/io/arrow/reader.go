@@ -0,0 +1,790 @@
+package arrow
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"time"
+
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/bitmap"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/ncolumn"
+	qfstrings "github.com/yistabraq/qframe/internal/strings"
+	"github.com/yistabraq/qframe/internal/tcolumn"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+type arrowType struct {
+	id        uint8
+	bitWidth  int
+	signed    bool
+	precision int16
+	unit      int16
+	timezone  string
+}
+
+type dictionaryEncoding struct {
+	id        int64
+	indexType arrowType
+}
+
+type field struct {
+	name       string
+	typ        arrowType
+	dictionary *dictionaryEncoding
+}
+
+// initialReadSize is the largest buffer allocated before any data has been read.
+const initialReadSize = 1 << 16
+
+type message struct {
+	headerType uint8
+	header     fbTable
+	body       []byte
+}
+
+// readMessage reads the next encapsulated message from the stream. Both the current
+// format, where the length is preceded by a continuation marker, and the legacy format
+// without the marker are accepted. nil is returned when the end of the stream is reached.
+func readMessage(r io.Reader) (*message, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	length := le.Uint32(prefix[:])
+	if length == continuationMarker {
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			return nil, err
+		}
+		length = le.Uint32(prefix[:])
+	}
+
+	if length == 0 {
+		// End of stream marker
+		return nil, nil
+	}
+
+	metadata, err := readBytes(r, int64(length))
+	if err != nil {
+		return nil, err
+	}
+
+	msg := rootTable(metadata)
+	if version := msg.int16(messageVersion, 0); version < metadataV4 {
+		return nil, qerrors.New("read message", "unsupported metadata version: %d", version)
+	}
+
+	header, ok := msg.table(messageHeader)
+	if !ok {
+		return nil, qerrors.New("read message", "missing message header")
+	}
+
+	bodyLength := msg.int64(messageBodyLength, 0)
+	if bodyLength < 0 {
+		return nil, qerrors.New("read message", "invalid body length: %d", bodyLength)
+	}
+
+	body, err := readBytes(r, bodyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message{headerType: msg.uint8(messageHeaderType, 0), header: header, body: body}, nil
+}
+
+// readBytes reads n bytes from r. The buffer grows as data is read, rather than
+// being allocated up front, since lengths read from malformed input can be huge.
+func readBytes(r io.Reader, n int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if n < initialReadSize {
+		buf.Grow(int(n))
+	} else {
+		buf.Grow(initialReadSize)
+	}
+
+	if _, err := io.CopyN(&buf, r, n); err != nil {
+		if err == io.EOF {
+			return nil, qerrors.New("read message", "malformed input: message length %d exceeds the stream", n)
+		}
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func readType(typeID uint8, t fbTable) arrowType {
+	result := arrowType{id: typeID}
+	switch typeID {
+	case typeInt:
+		result.bitWidth = int(t.int32(intBitWidth, 0))
+		result.signed = t.bool(intIsSigned, false)
+	case typeFloatingPoint:
+		result.precision = t.int16(floatingPointPrecision, precisionHalf)
+	case typeTimestamp:
+		result.bitWidth, result.signed = 64, true
+		result.unit = t.int16(timestampUnit, unitSecond)
+		result.timezone = t.string(timestampTimezone)
+	}
+
+	return result
+}
+
+// unitNanos returns the number of nanoseconds per unit of a timestamp.
+func unitNanos(unit int16) (int64, bool) {
+	switch unit {
+	case unitSecond:
+		return int64(time.Second), true
+	case unitMillisecond:
+		return int64(time.Millisecond), true
+	case unitMicrosecond:
+		return int64(time.Microsecond), true
+	case unitNanosecond:
+		return 1, true
+	}
+
+	return 0, false
+}
+
+// location returns the location of a timestamp timezone. Both names from the
+// time zone database and fixed offsets, eg. +01:00, are accepted. Timestamps
+// without timezone are considered to be in UTC.
+func location(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		return loc, nil
+	}
+
+	if t, err := time.Parse("-07:00", timezone); err == nil {
+		_, offset := t.Zone()
+		return time.FixedZone("", offset), nil
+	}
+
+	return nil, qerrors.New("read timestamp", "unknown timezone: %s", timezone)
+}
+
+func readField(t fbTable) (field, error) {
+	f := field{name: t.string(fieldName)}
+	if _, childCount := t.vector(fieldChildren); childCount > 0 {
+		return f, qerrors.New("read field", "nested types are not supported, column: %s", f.name)
+	}
+
+	typeTable, ok := t.table(fieldType)
+	if !ok {
+		return f, qerrors.New("read field", "missing type for column: %s", f.name)
+	}
+
+	f.typ = readType(t.uint8(fieldTypeType, 0), typeTable)
+	switch f.typ.id {
+	case typeNull, typeInt, typeBool, typeUtf8:
+	case typeTimestamp:
+		if _, ok := unitNanos(f.typ.unit); !ok {
+			return f, qerrors.New("read field", "unsupported time unit %d, column: %s", f.typ.unit, f.name)
+		}
+
+		if _, err := location(f.typ.timezone); err != nil {
+			return f, qerrors.Propagate("read field", err)
+		}
+	case typeFloatingPoint:
+		if f.typ.precision == precisionHalf {
+			return f, qerrors.New("read field", "half precision floats are not supported, column: %s", f.name)
+		}
+	default:
+		return f, qerrors.New("read field", "unsupported type id %d for column: %s", f.typ.id, f.name)
+	}
+
+	if dictTable, ok := t.table(fieldDictionary); ok {
+		// Default index type according to the specification is signed 32 bit integer
+		indexType := arrowType{id: typeInt, bitWidth: 32, signed: true}
+		if indexTable, ok := dictTable.table(dictionaryEncodingIndexType); ok {
+			indexType = readType(typeInt, indexTable)
+		}
+
+		if f.typ.id != typeUtf8 {
+			return f, qerrors.New("read field", "only string dictionaries are supported, column: %s", f.name)
+		}
+
+		f.dictionary = &dictionaryEncoding{id: dictTable.int64(dictionaryEncodingID, 0), indexType: indexType}
+	}
+
+	return f, nil
+}
+
+func readSchema(t fbTable) ([]field, error) {
+	fieldTables := t.tables(schemaFields)
+	fields := make([]field, len(fieldTables))
+	for i, ft := range fieldTables {
+		f, err := readField(ft)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = f
+	}
+
+	return fields, nil
+}
+
+// batchReader hands out the field nodes and buffers of a record batch in order.
+type batchReader struct {
+	length  int
+	nodes   [][]byte
+	buffers [][]byte
+	body    []byte
+	nodeIx  int
+	bufIx   int
+}
+
+func newBatchReader(t fbTable, body []byte) (*batchReader, error) {
+	if _, ok := t.table(recordBatchCompression); ok {
+		return nil, qerrors.New("read record batch", "compressed record batches are not supported")
+	}
+
+	length := t.int64(recordBatchLength, 0)
+	if length < 0 || length > math.MaxInt32 {
+		return nil, qerrors.New("read record batch", "malformed input: invalid length: %d", length)
+	}
+
+	return &batchReader{
+		length:  int(length),
+		nodes:   t.structs(recordBatchNodes, fieldNodeSize),
+		buffers: t.structs(recordBatchBuffers, bufferSize),
+		body:    body}, nil
+}
+
+func (br *batchReader) nextNode() (int, int, error) {
+	if br.nodeIx >= len(br.nodes) {
+		return 0, 0, qerrors.New("read record batch", "too few field nodes")
+	}
+
+	node := br.nodes[br.nodeIx]
+	br.nodeIx++
+
+	// All arrays in a batch have the length of the batch
+	length, nullCount := int64(le.Uint64(node)), int64(le.Uint64(node[8:]))
+	if length != int64(br.length) || nullCount < 0 || nullCount > length {
+		return 0, 0, qerrors.New("read record batch", "malformed input: invalid field node, length %d, null count %d", length, nullCount)
+	}
+
+	return int(length), int(nullCount), nil
+}
+
+func (br *batchReader) nextBuffer() ([]byte, error) {
+	if br.bufIx >= len(br.buffers) {
+		return nil, qerrors.New("read record batch", "too few buffers")
+	}
+
+	buf := br.buffers[br.bufIx]
+	br.bufIx++
+	offset, length := int64(le.Uint64(buf)), int64(le.Uint64(buf[8:]))
+	if offset < 0 || length < 0 || offset > int64(len(br.body)) || length > int64(len(br.body))-offset {
+		return nil, qerrors.New("read record batch", "buffer out of range")
+	}
+
+	return br.body[offset : offset+length], nil
+}
+
+// array holds the raw buffers of a primitive array.
+type array struct {
+	length    int
+	nullCount int
+	validity  []byte
+	buffers   [][]byte
+}
+
+func bitSet(bitmap []byte, i int) bool {
+	return bitmap[i>>3]&(1<<(uint(i)&7)) != 0
+}
+
+func (a array) isNull(i int) bool {
+	if a.nullCount == 0 || len(a.validity) == 0 {
+		return false
+	}
+
+	return !bitSet(a.validity, i)
+}
+
+func (a array) check(bufIx, size int) error {
+	if len(a.buffers[bufIx]) < size {
+		return qerrors.New("read array", "buffer too small, expected %d bytes, was %d", size, len(a.buffers[bufIx]))
+	}
+
+	return nil
+}
+
+func bufferCount(typ arrowType) int {
+	switch typ.id {
+	case typeNull:
+		return 0
+	case typeUtf8, typeBinary:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (br *batchReader) nextArray(typ arrowType) (array, error) {
+	length, nullCount, err := br.nextNode()
+	if err != nil {
+		return array{}, err
+	}
+
+	a := array{length: length, nullCount: nullCount}
+	if typ.id == typeNull {
+		a.nullCount = length
+		return a, nil
+	}
+
+	if a.validity, err = br.nextBuffer(); err != nil {
+		return a, err
+	}
+
+	if nullCount > 0 && len(a.validity) > 0 && len(a.validity) < (length+7)/8 {
+		return a, qerrors.New("read array", "validity bitmap too small")
+	}
+
+	for i := 0; i < bufferCount(typ); i++ {
+		buf, err := br.nextBuffer()
+		if err != nil {
+			return a, err
+		}
+		a.buffers = append(a.buffers, buf)
+	}
+
+	return a, nil
+}
+
+func (a array) ints(typ arrowType) ([]int, error) {
+	width := typ.bitWidth / 8
+	if width != 1 && width != 2 && width != 4 && width != 8 {
+		return nil, qerrors.New("read int array", "unsupported bit width: %d", typ.bitWidth)
+	}
+
+	if err := a.check(0, width*a.length); err != nil {
+		return nil, err
+	}
+
+	data := a.buffers[0]
+	result := make([]int, a.length)
+	for i := range result {
+		switch {
+		case width == 1 && typ.signed:
+			result[i] = int(int8(data[i]))
+		case width == 1:
+			result[i] = int(data[i])
+		case width == 2 && typ.signed:
+			result[i] = int(int16(le.Uint16(data[2*i:])))
+		case width == 2:
+			result[i] = int(le.Uint16(data[2*i:]))
+		case width == 4 && typ.signed:
+			result[i] = int(int32(le.Uint32(data[4*i:])))
+		case width == 4:
+			result[i] = int(le.Uint32(data[4*i:]))
+		default:
+			result[i] = int(le.Uint64(data[8*i:]))
+		}
+	}
+
+	return result, nil
+}
+
+func (a array) floats(typ arrowType) ([]float64, error) {
+	width := 8
+	if typ.precision == precisionSingle {
+		width = 4
+	}
+
+	if err := a.check(0, width*a.length); err != nil {
+		return nil, err
+	}
+
+	data := a.buffers[0]
+	result := make([]float64, a.length)
+	for i := range result {
+		switch {
+		case a.isNull(i):
+			result[i] = math.NaN()
+		case width == 4:
+			result[i] = float64(math.Float32frombits(le.Uint32(data[4*i:])))
+		default:
+			result[i] = math.Float64frombits(le.Uint64(data[8*i:]))
+		}
+	}
+
+	return result, nil
+}
+
+func (a array) bools() ([]bool, error) {
+	if err := a.check(0, (a.length+7)/8); err != nil {
+		return nil, err
+	}
+
+	result := make([]bool, a.length)
+	for i := range result {
+		result[i] = bitSet(a.buffers[0], i)
+	}
+
+	return result, nil
+}
+
+// appendStrings appends the strings in the array to the pointers and data provided.
+func (a array) appendStrings(pointers []qfstrings.Pointer, data []byte) ([]qfstrings.Pointer, []byte, error) {
+	if a.length == 0 {
+		return pointers, data, nil
+	}
+
+	if err := a.check(0, 4*(a.length+1)); err != nil {
+		return nil, nil, err
+	}
+
+	offsets, strData := a.buffers[0], a.buffers[1]
+	for i := 0; i < a.length; i++ {
+		if a.isNull(i) {
+			pointers = append(pointers, qfstrings.NewPointer(len(data), 0, true))
+			continue
+		}
+
+		start, end := int(int32(le.Uint32(offsets[4*i:]))), int(int32(le.Uint32(offsets[4*i+4:])))
+		if start < 0 || start > end || end > len(strData) {
+			return nil, nil, qerrors.New("read string array", "string offset out of range")
+		}
+
+		pointers = append(pointers, qfstrings.NewPointer(len(data), end-start, false))
+		data = append(data, strData[start:end]...)
+	}
+
+	return pointers, data, nil
+}
+
+// columnBuilder accumulates the data of one column over all record batches in the stream.
+type columnBuilder struct {
+	field     field
+	nullCount int
+	nullRows  int
+	nulls     []uint32
+	ints      []int
+	times     []int64
+	floats    []float64
+	bools     []bool
+	pointers  []qfstrings.Pointer
+	data      []byte
+
+	// For dictionary encoded columns codes refer to the values in dictValues,
+	// -1 represents null. The dictionaries of the stream are merged into dictValues.
+	codes      []int
+	dictValues []string
+	dictIndex  map[string]int
+}
+
+func (b *columnBuilder) appendArray(a array) error {
+	b.nullCount += a.nullCount
+	switch b.field.typ.id {
+	case typeNull:
+		// Pointers are created once all batches have been read, the length
+		// of null arrays is not backed by any data that has been validated.
+		b.nullRows += a.length
+	case typeInt:
+		ints, err := a.ints(b.field.typ)
+		if err != nil {
+			return err
+		}
+		b.appendNulls(a, len(b.ints))
+		b.ints = append(b.ints, ints...)
+	case typeTimestamp:
+		ints, err := a.ints(b.field.typ)
+		if err != nil {
+			return err
+		}
+
+		b.appendNulls(a, len(b.times))
+		factor, _ := unitNanos(b.field.typ.unit)
+		for _, v := range ints {
+			b.times = append(b.times, int64(v)*factor)
+		}
+	case typeFloatingPoint:
+		floats, err := a.floats(b.field.typ)
+		if err != nil {
+			return err
+		}
+		b.floats = append(b.floats, floats...)
+	case typeBool:
+		bools, err := a.bools()
+		if err != nil {
+			return err
+		}
+		b.appendNulls(a, len(b.bools))
+		b.bools = append(b.bools, bools...)
+	case typeUtf8:
+		var err error
+		b.pointers, b.data, err = a.appendStrings(b.pointers, b.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendNulls records the positions of null values in a for types that keep
+// track of nulls in a validity bitmap.
+func (b *columnBuilder) appendNulls(a array, offset int) {
+	if a.nullCount == 0 {
+		return
+	}
+
+	for i := 0; i < a.length; i++ {
+		if a.isNull(i) {
+			b.nulls = append(b.nulls, uint32(offset+i))
+		}
+	}
+}
+
+func (b *columnBuilder) validity(length int) bitmap.Bitmap {
+	if len(b.nulls) == 0 {
+		return nil
+	}
+
+	valid := bitmap.New(length)
+	for _, i := range b.nulls {
+		valid.SetNull(i)
+	}
+
+	return valid
+}
+
+func (b *columnBuilder) appendCodes(a array, dictionary []*string) error {
+	codes, err := a.ints(b.field.dictionary.indexType)
+	if err != nil {
+		return err
+	}
+
+	if b.dictIndex == nil {
+		b.dictIndex = make(map[string]int, len(dictionary))
+	}
+
+	for i, code := range codes {
+		if a.isNull(i) {
+			b.codes = append(b.codes, -1)
+			continue
+		}
+
+		if code < 0 || code >= len(dictionary) {
+			return qerrors.New("read dictionary array", "dictionary index out of range: %d", code)
+		}
+
+		value := dictionary[code]
+		if value == nil {
+			b.codes = append(b.codes, -1)
+			continue
+		}
+
+		colCode, ok := b.dictIndex[*value]
+		if !ok {
+			colCode = len(b.dictValues)
+			b.dictValues = append(b.dictValues, *value)
+			b.dictIndex[*value] = colCode
+		}
+
+		b.codes = append(b.codes, colCode)
+	}
+
+	return nil
+}
+
+func (b *columnBuilder) toData() (types.DataSlice, error) {
+	if b.field.dictionary != nil {
+		data := make([]*string, len(b.codes))
+		for i, c := range b.codes {
+			if c >= 0 {
+				data[i] = &b.dictValues[c]
+			}
+		}
+
+		if col, err := ecolumn.New(data, b.dictValues); err == nil {
+			return col, nil
+		}
+
+		// Too many distinct values to fit in an enum, fall back to a string column
+		return data, nil
+	}
+
+	switch b.field.typ.id {
+	case typeNull:
+		if b.nullRows == 0 {
+			return ncolumn.Column{}, nil
+		}
+
+		pointers := make([]qfstrings.Pointer, b.nullRows)
+		for i := range pointers {
+			pointers[i] = qfstrings.NewPointer(0, 0, true)
+		}
+		return qfstrings.StringBlob{Pointers: pointers}, nil
+	case typeInt:
+		return icolumn.NewNullable(b.ints, b.validity(len(b.ints))), nil
+	case typeFloatingPoint:
+		return b.floats, nil
+	case typeTimestamp:
+		loc, err := location(b.field.typ.timezone)
+		if err != nil {
+			return nil, err
+		}
+		return tcolumn.NewNullable(b.times, b.validity(len(b.times)), loc), nil
+	case typeBool:
+		return bcolumn.NewNullable(b.bools, b.validity(len(b.bools))), nil
+	default:
+		return qfstrings.StringBlob{Pointers: b.pointers, Data: b.data}, nil
+	}
+}
+
+func readDictionaryBatch(t fbTable, body []byte) (int64, []*string, bool, error) {
+	id := t.int64(dictionaryBatchID, 0)
+	isDelta := t.bool(dictionaryBatchIsDelta, false)
+	batch, ok := t.table(dictionaryBatchData)
+	if !ok {
+		return 0, nil, false, qerrors.New("read dictionary batch", "missing data")
+	}
+
+	br, err := newBatchReader(batch, body)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	a, err := br.nextArray(arrowType{id: typeUtf8})
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	pointers, data, err := a.appendStrings(nil, nil)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	values := make([]*string, len(pointers))
+	for i, p := range pointers {
+		if !p.IsNull() {
+			s := string(data[p.Offset() : p.Offset()+p.Len()])
+			values[i] = &s
+		}
+	}
+
+	return id, values, isDelta, nil
+}
+
+// ReadArrow reads data in the Arrow IPC stream format into a map of columns that
+// can be used to create a QFrame. The order of the columns in the schema is also returned.
+func ReadArrow(r io.Reader) (result map[string]types.DataSlice, columns []string, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			m, ok := rec.(malformedError)
+			if !ok {
+				panic(rec)
+			}
+			result, columns, err = nil, nil, qerrors.New("ReadArrow", "malformed input: %s", m.reason)
+		}
+	}()
+
+	msg, err := readMessage(r)
+	if err != nil {
+		return nil, nil, qerrors.Propagate("ReadArrow", err)
+	}
+
+	if msg == nil || msg.headerType != headerSchema {
+		return nil, nil, qerrors.New("ReadArrow", "expected stream to start with a schema")
+	}
+
+	fields, err := readSchema(msg.header)
+	if err != nil {
+		return nil, nil, qerrors.Propagate("ReadArrow", err)
+	}
+
+	builders := make([]*columnBuilder, len(fields))
+	for i, f := range fields {
+		builders[i] = &columnBuilder{field: f}
+	}
+
+	dictionaries := map[int64][]*string{}
+	for {
+		msg, err = readMessage(r)
+		if err != nil {
+			return nil, nil, qerrors.Propagate("ReadArrow", err)
+		}
+
+		if msg == nil {
+			break
+		}
+
+		switch msg.headerType {
+		case headerDictionaryBatch:
+			id, values, isDelta, err := readDictionaryBatch(msg.header, msg.body)
+			if err != nil {
+				return nil, nil, qerrors.Propagate("ReadArrow", err)
+			}
+
+			if isDelta {
+				values = append(dictionaries[id], values...)
+			}
+			dictionaries[id] = values
+		case headerRecordBatch:
+			br, err := newBatchReader(msg.header, msg.body)
+			if err != nil {
+				return nil, nil, qerrors.Propagate("ReadArrow", err)
+			}
+
+			for _, b := range builders {
+				if b.field.dictionary == nil {
+					a, err := br.nextArray(b.field.typ)
+					if err == nil {
+						err = b.appendArray(a)
+					}
+
+					if err != nil {
+						return nil, nil, qerrors.Propagate("ReadArrow", err)
+					}
+					continue
+				}
+
+				dictionary, ok := dictionaries[b.field.dictionary.id]
+				if !ok {
+					return nil, nil, qerrors.New("ReadArrow", "missing dictionary for column %s", b.field.name)
+				}
+
+				a, err := br.nextArray(b.field.dictionary.indexType)
+				if err == nil {
+					err = b.appendCodes(a, dictionary)
+				}
+
+				if err != nil {
+					return nil, nil, qerrors.Propagate("ReadArrow", err)
+				}
+			}
+		default:
+			return nil, nil, qerrors.New("ReadArrow", "unsupported message type: %d", msg.headerType)
+		}
+	}
+
+	result = make(map[string]types.DataSlice, len(fields))
+	columns = make([]string, len(fields))
+	for i, b := range builders {
+		if _, ok := result[b.field.name]; ok {
+			return nil, nil, qerrors.New("ReadArrow", "duplicate column name: %s", b.field.name)
+		}
+
+		data, err := b.toData()
+		if err != nil {
+			return nil, nil, qerrors.Propagate("ReadArrow", err)
+		}
+
+		result[b.field.name] = data
+		columns[i] = b.field.name
+	}
+
+	return result, columns, nil
+}
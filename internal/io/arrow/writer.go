@@ -0,0 +1,317 @@
+package arrow
+
+import (
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/ncolumn"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+// arrayBuilder collects the field nodes and buffers of a record batch.
+type arrayBuilder struct {
+	nodes   fbStructVector
+	buffers fbStructVector
+	body    []byte
+}
+
+func (b *arrayBuilder) addBuffer(buf []byte) {
+	b.buffers = append(b.buffers, []int64{int64(len(b.body)), int64(len(buf))})
+	b.body = append(b.body, buf...)
+	for len(b.body)%8 != 0 {
+		b.body = append(b.body, 0)
+	}
+}
+
+func (b *arrayBuilder) addArray(length, nullCount int, validity []byte, buffers ...[]byte) {
+	b.nodes = append(b.nodes, []int64{int64(length), int64(nullCount)})
+	if nullCount == 0 {
+		validity = nil
+	}
+
+	b.addBuffer(validity)
+	for _, buf := range buffers {
+		b.addBuffer(buf)
+	}
+}
+
+func (b *arrayBuilder) recordBatch(length int) fbObject {
+	return newTableDef().
+		int64(recordBatchLength, int64(length)).
+		ref(recordBatchNodes, b.nodes).
+		ref(recordBatchBuffers, b.buffers)
+}
+
+func setBit(bitmap []byte, i int) {
+	bitmap[i>>3] |= 1 << (uint(i) & 7)
+}
+
+func newBitmap(length int) []byte {
+	return make([]byte, (length+7)/8)
+}
+
+// validity converts the null flags of a view to an Arrow validity bitmap.
+func validity(length int, isNull func(i int) bool) ([]byte, int) {
+	bitmap := newBitmap(length)
+	nullCount := 0
+	for i := 0; i < length; i++ {
+		if isNull(i) {
+			nullCount++
+		} else {
+			setBit(bitmap, i)
+		}
+	}
+
+	return bitmap, nullCount
+}
+
+func (b *arrayBuilder) addInts(view icolumn.View) {
+	buf := make([]byte, 8*view.Len())
+	for i := 0; i < view.Len(); i++ {
+		le.PutUint64(buf[8*i:], uint64(view.ItemAt(i)))
+	}
+
+	valid, nullCount := validity(view.Len(), view.IsNull)
+	b.addArray(view.Len(), nullCount, valid, buf)
+}
+
+func (b *arrayBuilder) addTimes(view tcolumn.View) {
+	buf := make([]byte, 8*view.Len())
+	for i := 0; i < view.Len(); i++ {
+		if !view.IsNull(i) {
+			le.PutUint64(buf[8*i:], uint64(view.ItemAt(i).UnixNano()))
+		}
+	}
+
+	valid, nullCount := validity(view.Len(), view.IsNull)
+	b.addArray(view.Len(), nullCount, valid, buf)
+}
+
+func (b *arrayBuilder) addFloats(data []float64) {
+	buf := make([]byte, 8*len(data))
+	validity := newBitmap(len(data))
+	nullCount := 0
+	for i, x := range data {
+		if math.IsNaN(x) {
+			nullCount++
+			continue
+		}
+
+		setBit(validity, i)
+		le.PutUint64(buf[8*i:], math.Float64bits(x))
+	}
+
+	b.addArray(len(data), nullCount, validity, buf)
+}
+
+func (b *arrayBuilder) addBools(view bcolumn.View) {
+	buf := newBitmap(view.Len())
+	for i := 0; i < view.Len(); i++ {
+		if view.ItemAt(i) {
+			setBit(buf, i)
+		}
+	}
+
+	valid, nullCount := validity(view.Len(), view.IsNull)
+	b.addArray(view.Len(), nullCount, valid, buf)
+}
+
+func (b *arrayBuilder) addStrings(data []*string) {
+	offsets := make([]byte, 4*(len(data)+1))
+	validity := newBitmap(len(data))
+	strData := make([]byte, 0)
+	nullCount := 0
+	for i, s := range data {
+		if s == nil {
+			nullCount++
+		} else {
+			setBit(validity, i)
+			strData = append(strData, *s...)
+		}
+		le.PutUint32(offsets[4*(i+1):], uint32(len(strData)))
+	}
+
+	b.addArray(len(data), nullCount, validity, offsets, strData)
+}
+
+func (b *arrayBuilder) addCodes(codes []int) {
+	buf := make([]byte, 4*len(codes))
+	validity := newBitmap(len(codes))
+	nullCount := 0
+	for i, c := range codes {
+		if c < 0 {
+			nullCount++
+			continue
+		}
+
+		setBit(validity, i)
+		le.PutUint32(buf[4*i:], uint32(c))
+	}
+
+	b.addArray(len(codes), nullCount, validity, buf)
+}
+
+func intType(bitWidth int, signed bool) fbObject {
+	return newTableDef().int32(intBitWidth, int32(bitWidth)).bool(intIsSigned, signed)
+}
+
+// timestampType returns a nanosecond timestamp type in loc. Unnamed fixed zones are
+// written as offsets. The local location has no name that can be used as timezone,
+// times in it are written without timezone.
+func timestampType(loc *time.Location) fbObject {
+	def := newTableDef().int16(timestampUnit, unitNanosecond)
+	switch {
+	case loc == time.Local:
+	case loc.String() == "":
+		def.ref(timestampTimezone, fbString(time.Unix(0, 0).In(loc).Format("-07:00")))
+	default:
+		def.ref(timestampTimezone, fbString(loc.String()))
+	}
+
+	return def
+}
+
+func fieldDef(name string, typeID uint8, typ fbObject, dictionary fbObject) fbObject {
+	def := newTableDef().
+		ref(fieldName, fbString(name)).
+		bool(fieldNullable, true).
+		uint8(fieldTypeType, typeID).
+		ref(fieldType, typ).
+		ref(fieldChildren, fbTableVector{})
+	if dictionary != nil {
+		def.ref(fieldDictionary, dictionary)
+	}
+
+	return def
+}
+
+func enumCodes(c ecolumn.Column, ix index.Int) []int {
+	values := c.Values()
+	valToCode := make(map[string]int, len(values))
+	for i, v := range values {
+		valToCode[v] = i
+	}
+
+	data := c.View(ix).Slice()
+	codes := make([]int, len(data))
+	for i, s := range data {
+		if s == nil {
+			codes[i] = -1
+		} else {
+			codes[i] = valToCode[*s]
+		}
+	}
+
+	return codes
+}
+
+func messageDef(headerType uint8, header fbObject, bodyLength int) []byte {
+	return finish(newTableDef().
+		int16(messageVersion, metadataV4).
+		uint8(messageHeaderType, headerType).
+		ref(messageHeader, header).
+		int64(messageBodyLength, int64(bodyLength)))
+}
+
+func writeMessage(w io.Writer, metadata, body []byte) error {
+	// The metadata is padded so that the body starts at an eight byte boundary.
+	paddedLen := align(len(metadata)+8, 8) - 8
+	buf := make([]byte, 8, 8+paddedLen)
+	le.PutUint32(buf, continuationMarker)
+	le.PutUint32(buf[4:], uint32(paddedLen))
+	buf = append(buf, metadata...)
+	buf = append(buf, make([]byte, paddedLen-len(metadata))...)
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+
+	_, err := w.Write(body)
+	return err
+}
+
+// WriteArrow writes the columns, in the order given by ix, to w in the Arrow IPC stream format.
+// Enum columns are written as dictionary encoded string arrays, time columns as
+// nanosecond timestamps with the location of the column as timezone.
+func WriteArrow(w io.Writer, names []string, columns []column.Column, ix index.Int) error {
+	fields := make(fbTableVector, len(columns))
+	batch := &arrayBuilder{}
+	var dictionaries [][]byte
+	for i, col := range columns {
+		switch c := col.(type) {
+		case icolumn.Column:
+			fields[i] = fieldDef(names[i], typeInt, intType(64, true), nil)
+			batch.addInts(c.View(ix))
+		case fcolumn.Column:
+			fields[i] = fieldDef(names[i], typeFloatingPoint, newTableDef().int16(floatingPointPrecision, precisionDouble), nil)
+			batch.addFloats(c.View(ix).Slice())
+		case bcolumn.Column:
+			fields[i] = fieldDef(names[i], typeBool, newTableDef(), nil)
+			batch.addBools(c.View(ix))
+		case scolumn.Column:
+			fields[i] = fieldDef(names[i], typeUtf8, newTableDef(), nil)
+			batch.addStrings(c.View(ix).Slice())
+		case ecolumn.Column:
+			dictID := int64(i)
+			encoding := newTableDef().
+				int64(dictionaryEncodingID, dictID).
+				ref(dictionaryEncodingIndexType, intType(32, true))
+			fields[i] = fieldDef(names[i], typeUtf8, newTableDef(), encoding)
+			batch.addCodes(enumCodes(c, ix))
+
+			values := c.Values()
+			dictValues := make([]*string, len(values))
+			for j := range values {
+				dictValues[j] = &values[j]
+			}
+
+			dictBatch := &arrayBuilder{}
+			dictBatch.addStrings(dictValues)
+			header := newTableDef().
+				int64(dictionaryBatchID, dictID).
+				ref(dictionaryBatchData, dictBatch.recordBatch(len(dictValues)))
+			dictionaries = append(dictionaries, messageDef(headerDictionaryBatch, header, len(dictBatch.body)), dictBatch.body)
+		case tcolumn.Column:
+			fields[i] = fieldDef(names[i], typeTimestamp, timestampType(c.Location()), nil)
+			batch.addTimes(c.View(ix))
+		case ncolumn.Column:
+			fields[i] = fieldDef(names[i], typeNull, newTableDef(), nil)
+			batch.nodes = append(batch.nodes, []int64{int64(len(ix)), int64(len(ix))})
+		default:
+			return qerrors.New("WriteArrow", "unsupported column type: %s", reflect.TypeOf(col))
+		}
+	}
+
+	schema := newTableDef().ref(schemaFields, fields)
+	if err := writeMessage(w, messageDef(headerSchema, schema, 0), nil); err != nil {
+		return qerrors.Propagate("WriteArrow", err)
+	}
+
+	for i := 0; i < len(dictionaries); i += 2 {
+		if err := writeMessage(w, dictionaries[i], dictionaries[i+1]); err != nil {
+			return qerrors.Propagate("WriteArrow", err)
+		}
+	}
+
+	if err := writeMessage(w, messageDef(headerRecordBatch, batch.recordBatch(len(ix)), len(batch.body)), batch.body); err != nil {
+		return qerrors.Propagate("WriteArrow", err)
+	}
+
+	// End of stream marker
+	eos := make([]byte, 8)
+	le.PutUint32(eos, continuationMarker)
+	if _, err := w.Write(eos); err != nil {
+		return qerrors.Propagate("WriteArrow", err)
+	}
+
+	return nil
+}
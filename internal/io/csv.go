@@ -4,11 +4,17 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"time"
 
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/bitmap"
 	"github.com/yistabraq/qframe/internal/ecolumn"
 	"github.com/yistabraq/qframe/internal/fastcsv"
+	"github.com/yistabraq/qframe/internal/icolumn"
 	"github.com/yistabraq/qframe/internal/ncolumn"
+	"github.com/yistabraq/qframe/internal/parallel"
 	"github.com/yistabraq/qframe/internal/strings"
+	"github.com/yistabraq/qframe/internal/tcolumn"
 	"github.com/yistabraq/qframe/qerrors"
 	"github.com/yistabraq/qframe/types"
 )
@@ -30,6 +36,11 @@ type CSVConfig struct {
 	Headers                []string
 	RenameDuplicateColumns bool
 	MissingColumnNameAlias string
+	TimeFormat             string
+	Columns                []string
+	Parallelism            int
+	AutoEnumMaxCardinality int
+	AutoEnumMaxRatio       float64
 }
 
 //For writing CSV
@@ -42,102 +53,336 @@ func isEmptyLine(fields [][]byte) bool {
 }
 
 func ReadCSV(reader io.Reader, conf CSVConfig) (map[string]interface{}, []string, error) {
+	if conf.Parallelism > 1 {
+		return readCSVParallel(reader, conf)
+	}
+
+	r, err := NewCSVChunkReader(reader, 0, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.Read()
+}
+
+// CSVChunkReader reads CSV data in chunks of at most a given number of rows.
+//
+// The types of the columns are determined by the first chunk, unless given in the config,
+// and then kept for all following chunks. Enum columns keep their values between chunks.
+type CSVChunkReader struct {
+	reader    fastcsv.Reader
+	conf      CSVConfig
+	headers   []string
+	keep      []bool
+	chunkRows int
+	row       int
+	chunks    int
+	enums     map[string]ecolumn.Column
+	done      bool
+}
+
+// NewCSVChunkReader creates a new chunk reader, the header is read immediately.
+// A chunkRows of 0 means that all rows are read in one chunk.
+func NewCSVChunkReader(reader io.Reader, chunkRows int, conf CSVConfig) (*CSVChunkReader, error) {
+	if chunkRows < 0 {
+		return nil, qerrors.New("NewCSVChunkReader", "chunk size must not be negative, was %d", chunkRows)
+	}
+
 	r := fastcsv.NewReader(reader, conf.Delimiter)
 	headers := conf.Headers
 	if len(headers) == 0 {
-		byteHeader, err := r.Read()
-		if err != nil {
-			return nil, nil, qerrors.Propagate("ReadCSV read header", err)
+		var err error
+		if headers, err = readHeader(&r); err != nil {
+			return nil, err
 		}
+	}
 
-		headers = make([]string, len(byteHeader))
-		for i := range headers {
-			headers[i] = string(byteHeader[i])
-		}
+	return newCSVChunkReader(r, headers, chunkRows, conf)
+}
+
+func readHeader(r *fastcsv.Reader) ([]string, error) {
+	byteHeader, err := r.Read()
+	if err != nil {
+		return nil, qerrors.Propagate("ReadCSV read header", err)
 	}
 
-	colPointers := make([][]bytePointer, len(headers))
+	headers := make([]string, len(byteHeader))
 	for i := range headers {
-		colPointers[i] = []bytePointer{}
+		headers[i] = string(byteHeader[i])
+	}
+
+	return headers, nil
+}
+
+func newCSVChunkReader(r fastcsv.Reader, headers []string, chunkRows int, conf CSVConfig) (*CSVChunkReader, error) {
+	if conf.MissingColumnNameAlias != "" {
+		headers = addAliasToMissingColumnNames(headers, conf.MissingColumnNameAlias)
+	}
+
+	if conf.RenameDuplicateColumns {
+		headers = renameDuplicateColumns(headers)
+	}
+
+	// The types are filled in from the first chunk, take a copy to not modify the callers config
+	typs := make(map[string]types.DataType, len(headers))
+	for k, v := range conf.Types {
+		typs[k] = v
+	}
+	conf.Types = typs
+
+	keep, err := keepColumns(headers, conf.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Columns != nil {
+		// Enum values for columns that are not read are ignored
+		enumVals := make(map[string][]string, len(conf.EnumVals))
+		for _, c := range conf.Columns {
+			if v, ok := conf.EnumVals[c]; ok {
+				enumVals[c] = v
+			}
+		}
+		conf.EnumVals = enumVals
+	}
+
+	return &CSVChunkReader{
+		reader:    r,
+		conf:      conf,
+		headers:   headers,
+		keep:      keep,
+		chunkRows: chunkRows,
+		row:       1,
+		enums:     make(map[string]ecolumn.Column)}, nil
+}
+
+// keepColumns returns, for each header, if the column should be read. All columns are
+// read if columns is nil.
+func keepColumns(headers, columns []string) ([]bool, error) {
+	keep := make([]bool, len(headers))
+	if columns == nil {
+		for i := range keep {
+			keep[i] = true
+		}
+		return keep, nil
+	}
+
+	for _, c := range columns {
+		found := false
+		for i, h := range headers {
+			if h == c {
+				keep[i], found = true, true
+			}
+		}
+
+		if !found {
+			return nil, qerrors.New("ReadCSV", "unknown column: \"%s\"", c)
+		}
+	}
+
+	return keep, nil
+}
+
+// Read returns the data of the next chunk together with the column names.
+// The first chunk is always returned, even if it does not contain any rows, following
+// chunks are only returned if they contain at least one row. io.EOF is returned when
+// all chunks have been read.
+func (r *CSVChunkReader) Read() (map[string]interface{}, []string, error) {
+	if r.done {
+		return nil, nil, io.EOF
+	}
+
+	sizeHint := 0
+	if r.chunkRows == 0 {
+		sizeHint = r.conf.RowCountHint
+	}
+
+	chunk, err := r.tokenize(&r.reader, r.chunkRows, sizeHint)
+	r.row += chunk.records
+	if err != nil {
+		return nil, nil, r.tokenizeErr(err)
+	}
+
+	r.done = chunk.done
+	if chunk.rows == 0 && r.chunks > 0 {
+		return nil, nil, io.EOF
 	}
 
+	return r.buildData(chunk.colBytes, chunk.colPointers)
+}
+
+// tokenizedChunk holds the bytes of the fields of a number of rows, column by column.
+type tokenizedChunk struct {
 	// All bytes in a column
-	colBytes := make([][]byte, len(headers))
+	colBytes    [][]byte
+	colPointers [][]bytePointer
 
-	row := 1
-	nonEmptyRows := 0
-	for r.Next() {
-		if r.Err() != nil {
-			return nil, nil, qerrors.Propagate("ReadCSV read body", r.Err())
+	// Number of non empty rows and number of records read, including empty ones
+	rows    int
+	records int
+	done    bool
+}
+
+// columnCountError is returned when a record has the wrong number of fields. record
+// is the number of the record within the tokenized chunk.
+type columnCountError struct {
+	record, expected, was int
+}
+
+func (e columnCountError) Error() string {
+	return fmt.Sprintf("Wrong number of columns on record %d, expected %d, was %d", e.record, e.expected, e.was)
+}
+
+// tokenizeErr converts errors from tokenize into errors reported to the user. r.row
+// must be the number of the last record read.
+func (r *CSVChunkReader) tokenizeErr(err error) error {
+	if e, ok := err.(columnCountError); ok {
+		return qerrors.New("ReadCSV", "Wrong number of columns on line %d, expected %d, was %d",
+			r.row, e.expected, e.was)
+	}
+
+	return qerrors.Propagate("ReadCSV read body", err)
+}
+
+// tokenize reads at most maxRows non empty rows, or all rows if maxRows is 0, from reader.
+func (r *CSVChunkReader) tokenize(reader *fastcsv.Reader, maxRows, sizeHint int) (tokenizedChunk, error) {
+	headers := r.headers
+	chunk := tokenizedChunk{colBytes: make([][]byte, len(headers)), colPointers: make([][]bytePointer, len(headers))}
+	for i := range headers {
+		chunk.colPointers[i] = []bytePointer{}
+	}
+
+	for maxRows == 0 || chunk.rows < maxRows {
+		if !reader.Next() {
+			chunk.done = true
+			break
 		}
 
-		row++
-		fields := r.Fields()
+		if reader.Err() != nil {
+			return chunk, reader.Err()
+		}
+
+		chunk.records++
+		fields := reader.Fields()
 		if len(fields) != len(headers) {
-			if isEmptyLine(fields) && conf.IgnoreEmptyLines {
+			if isEmptyLine(fields) && r.conf.IgnoreEmptyLines {
 				continue
 			}
 
-			return nil, nil, qerrors.New("ReadCSV", "Wrong number of columns on line %d, expected %d, was %d",
-				row, len(headers), len(fields))
+			return chunk, columnCountError{record: chunk.records, expected: len(headers), was: len(fields)}
 		}
 
-		if isEmptyLine(fields) && conf.IgnoreEmptyLines {
+		if isEmptyLine(fields) && r.conf.IgnoreEmptyLines {
 			continue
 		}
 
 		for i, col := range fields {
-			start := len(colBytes[i])
-			colBytes[i] = append(colBytes[i], col...)
-			colPointers[i] = append(colPointers[i], bytePointer{start: uint32(start), end: uint32(len(colBytes[i]))})
+			if !r.keep[i] {
+				continue
+			}
+
+			start := len(chunk.colBytes[i])
+			chunk.colBytes[i] = append(chunk.colBytes[i], col...)
+			chunk.colPointers[i] = append(chunk.colPointers[i], bytePointer{start: uint32(start), end: uint32(len(chunk.colBytes[i]))})
 		}
 
-		nonEmptyRows++
-		if nonEmptyRows == 1000 && conf.RowCountHint > 2000 {
+		chunk.rows++
+		if chunk.rows == 1000 && sizeHint > 2000 {
 			// This is an optimization that can reduce allocations and copying if the number
 			// of rows is provided. Not a huge impact but 5 - 10 % faster for big CSVs.
-			resizeColBytes(colBytes, nonEmptyRows, conf.RowCountHint)
-			resizeColPointers(colPointers, conf.RowCountHint)
+			resizeColBytes(chunk.colBytes, chunk.rows, sizeHint)
+			resizeColPointers(chunk.colPointers, sizeHint)
 		}
 	}
 
-	if conf.MissingColumnNameAlias != "" {
-		headers = addAliasToMissingColumnNames(headers, conf.MissingColumnNameAlias)
-
-	}
+	return chunk, nil
+}
 
-	if conf.RenameDuplicateColumns {
-		headers = renameDuplicateColumns(headers)
+// buildData converts the bytes of all columns into data.
+func (r *CSVChunkReader) buildData(colBytes [][]byte, colPointers [][]bytePointer) (map[string]interface{}, []string, error) {
+	headers := r.headers
+	readHeaders := make([]string, 0, len(headers))
+	prevEnums := make([]*ecolumn.Column, len(headers))
+	for i, header := range headers {
+		if !r.keep[i] {
+			continue
+		}
 
+		readHeaders = append(readHeaders, header)
+		if c, ok := r.enums[header]; ok {
+			prevEnums[i] = &c
+		}
 	}
 
+	data := make([]interface{}, len(headers))
+	errs := make([]error, len(headers))
+	parallel.For(len(headers), r.conf.Parallelism, func(i int) {
+		if r.keep[i] {
+			data[i], errs[i] = columnToData(colBytes[i], colPointers[i], headers[i], r.conf, prevEnums[i])
+		}
+	})
+
 	dataMap := make(map[string]interface{}, len(headers))
 	for i, header := range headers {
-		data, err := columnToData(colBytes[i], colPointers[i], header, conf)
-		if err != nil {
-			return nil, nil, qerrors.Propagate("ReadCSV convert data", err)
+		if !r.keep[i] {
+			continue
 		}
 
-		dataMap[header] = data
-	}
+		if errs[i] != nil {
+			return nil, nil, qerrors.Propagate("ReadCSV convert data", errs[i])
+		}
 
-	if len(conf.EnumVals) > 0 {
-		return nil, nil, qerrors.New("ReadCsv", "Enum values specified for non enum column")
+		if _, ok := data[i].(ecolumn.Column); ok {
+			delete(r.conf.EnumVals, header)
+		}
+
+		dataMap[header] = data[i]
 	}
 
-	if len(headers) > len(dataMap) {
-		duplicates := make([]string, 0)
-		headerSet := strings.NewEmptyStringSet()
-		for _, h := range headers {
-			if headerSet.Contains(h) {
-				duplicates = append(duplicates, h)
-			} else {
-				headerSet.Add(h)
+	if r.chunks == 0 {
+		if len(r.conf.EnumVals) > 0 {
+			return nil, nil, qerrors.New("ReadCsv", "Enum values specified for non enum column")
+		}
+
+		if len(readHeaders) > len(dataMap) {
+			duplicates := make([]string, 0)
+			headerSet := strings.NewEmptyStringSet()
+			for _, h := range readHeaders {
+				if headerSet.Contains(h) {
+					duplicates = append(duplicates, h)
+				} else {
+					headerSet.Add(h)
+				}
 			}
+			return nil, nil, qerrors.New("ReadCsv", "Duplicate columns detected: %v", duplicates)
+		}
+	}
+
+	r.fixSchema(dataMap)
+	r.chunks++
+	return dataMap, readHeaders, nil
+}
+
+// fixSchema records the types of the columns, and the values of enum columns, so that
+// following chunks get the same schema.
+func (r *CSVChunkReader) fixSchema(dataMap map[string]interface{}) {
+	for name, data := range dataMap {
+		switch t := data.(type) {
+		case icolumn.Column:
+			r.conf.Types[name] = types.Int
+		case []float64:
+			r.conf.Types[name] = types.Float
+		case bcolumn.Column:
+			r.conf.Types[name] = types.Bool
+		case tcolumn.Column:
+			r.conf.Types[name] = types.Time
+		case strings.StringBlob:
+			r.conf.Types[name] = types.String
+		case ecolumn.Column:
+			r.conf.Types[name] = types.Enum
+			r.enums[name] = t
 		}
-		return nil, nil, qerrors.New("ReadCsv", "Duplicate columns detected: %v", duplicates)
 	}
-	return dataMap, headers, nil
 }
 
 func resizeColPointers(pointers [][]bytePointer, sizeHint int) {
@@ -204,8 +449,28 @@ func addAliasToMissingColumnNames(headers []string, alias string) []string {
 	return headers
 }
 
-// Convert bytes to data columns, try, in turn int, float, bool and last string.
-func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSVConfig) (interface{}, error) {
+// setNull marks position i as null, the bitmap is allocated on first use.
+func setNull(valid bitmap.Bitmap, i, size int) bitmap.Bitmap {
+	if valid == nil {
+		valid = bitmap.New(size)
+	}
+
+	valid.SetNull(uint32(i))
+	return valid
+}
+
+// hasValues returns true if there is at least one non null value. Columns with only
+// null values are not detected as int or bool columns.
+func hasValues(valid bitmap.Bitmap, size int) bool {
+	return valid.NullCount(size) < size
+}
+
+// Convert bytes to data columns, try, in turn int, float, bool, time (if a time format
+// has been given), enum (if auto enum is enabled) and last string.
+// If empty values are considered null int and bool columns may contain nulls.
+// Empty values are always null in time columns.
+// Enum columns are built on the values of prevEnum, if given, to keep the values stable between chunks.
+func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSVConfig, prevEnum *ecolumn.Column) (interface{}, error) {
 	var err error
 	dataType := conf.Types[colName]
 
@@ -215,7 +480,14 @@ func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSV
 
 	if dataType == types.Int || dataType == types.None {
 		intData := make([]int, 0, len(pointers))
-		for _, p := range pointers {
+		var valid bitmap.Bitmap
+		for i, p := range pointers {
+			if p.start == p.end && conf.EmptyNull {
+				valid = setNull(valid, i, len(pointers))
+				intData = append(intData, 0)
+				continue
+			}
+
 			x, intErr := strings.ParseInt(bytes[p.start:p.end])
 			if intErr != nil {
 				err = intErr
@@ -224,8 +496,8 @@ func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSV
 			intData = append(intData, x)
 		}
 
-		if err == nil {
-			return intData, nil
+		if err == nil && (dataType == types.Int || hasValues(valid, len(pointers))) {
+			return icolumn.NewNullable(intData, valid), nil
 		}
 
 		if dataType == types.Int {
@@ -262,7 +534,14 @@ func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSV
 	if dataType == types.Bool || dataType == types.None {
 		err = nil
 		boolData := make([]bool, 0, len(pointers))
-		for _, p := range pointers {
+		var valid bitmap.Bitmap
+		for i, p := range pointers {
+			if p.start == p.end && conf.EmptyNull {
+				valid = setNull(valid, i, len(pointers))
+				boolData = append(boolData, false)
+				continue
+			}
+
 			x, boolErr := strings.ParseBool(bytes[p.start:p.end])
 			if boolErr != nil {
 				err = boolErr
@@ -271,8 +550,8 @@ func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSV
 			boolData = append(boolData, x)
 		}
 
-		if err == nil {
-			return boolData, nil
+		if err == nil && (dataType == types.Bool || hasValues(valid, len(pointers))) {
+			return bcolumn.NewNullable(boolData, valid), nil
 		}
 
 		if dataType == types.Bool {
@@ -280,6 +559,50 @@ func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSV
 		}
 	}
 
+	if dataType == types.Time || (dataType == types.None && conf.TimeFormat != "") {
+		err = nil
+		layout := conf.TimeFormat
+		if layout == "" {
+			layout = time.RFC3339
+		}
+
+		timeData := make([]int64, 0, len(pointers))
+		var valid bitmap.Bitmap
+		var loc *time.Location
+		for i, p := range pointers {
+			if p.start == p.end {
+				valid = setNull(valid, i, len(pointers))
+				timeData = append(timeData, 0)
+				continue
+			}
+
+			t, timeErr := time.Parse(layout, strings.UnsafeBytesToString(bytes[p.start:p.end]))
+			if timeErr != nil {
+				err = timeErr
+				break
+			}
+
+			if loc == nil {
+				loc = t.Location()
+			}
+			timeData = append(timeData, t.UnixNano())
+		}
+
+		if err == nil && (dataType == types.Time || hasValues(valid, len(pointers))) {
+			return tcolumn.NewNullable(timeData, valid, loc), nil
+		}
+
+		if dataType == types.Time {
+			return nil, qerrors.Propagate("Create time column", err)
+		}
+	}
+
+	if dataType == types.None && conf.AutoEnumMaxCardinality > 0 {
+		if c, ok := autoEnumColumn(bytes, pointers, conf); ok {
+			return c, nil
+		}
+	}
+
 	if dataType == types.String || dataType == types.None {
 		stringPointers := make([]strings.Pointer, len(pointers))
 		for i, p := range pointers {
@@ -294,11 +617,14 @@ func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSV
 	}
 
 	if dataType == types.Enum {
-		values := conf.EnumVals[colName]
-		delete(conf.EnumVals, colName)
-		factory, err := ecolumn.NewFactory(values, len(pointers))
-		if err != nil {
-			return nil, err
+		var factory *ecolumn.Factory
+		if prevEnum != nil {
+			factory = ecolumn.NewFactoryFrom(*prevEnum, len(pointers))
+		} else {
+			factory, err = ecolumn.NewFactory(conf.EnumVals[colName], len(pointers))
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		for _, p := range pointers {
@@ -317,3 +643,26 @@ func columnToData(bytes []byte, pointers []bytePointer, colName string, conf CSV
 
 	return nil, qerrors.New("Create column", "unknown data type: %s", dataType)
 }
+
+// autoEnumColumn returns an enum column with the content of the column if the number of
+// distinct values is within the auto enum limits of conf. ok is false otherwise.
+func autoEnumColumn(bytes []byte, pointers []bytePointer, conf CSVConfig) (c ecolumn.Column, ok bool) {
+	limit := ecolumn.AutoLimit(conf.AutoEnumMaxCardinality, conf.AutoEnumMaxRatio, len(pointers))
+	factory, err := ecolumn.NewFactory(nil, len(pointers))
+	if err != nil {
+		return ecolumn.Column{}, false
+	}
+
+	for _, p := range pointers {
+		if p.start == p.end && conf.EmptyNull {
+			factory.AppendNil()
+			continue
+		}
+
+		if err := factory.AppendByteString(bytes[p.start:p.end]); err != nil || factory.Cardinality() > limit {
+			return ecolumn.Column{}, false
+		}
+	}
+
+	return factory.ToColumn(), true
+}
@@ -0,0 +1,147 @@
+package io
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/yistabraq/qframe/internal/fastcsv"
+	"github.com/yistabraq/qframe/internal/parallel"
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+/*
+Parallel CSV reading is done as follows:
+
+1. All input is read into memory.
+2. The input is split into chunks of complete lines. To avoid splitting quoted fields
+   that contain newlines the number of quotes before each split point is counted. If the
+   count is odd the split point is within a quoted field.
+3. The chunks are tokenized in parallel.
+4. The fields of all chunks are merged and converted into columns, one column per goroutine.
+*/
+
+// Number of chunks per goroutine, more chunks than goroutines evens out the load.
+const chunksPerGoroutine = 4
+
+func readCSVParallel(reader io.Reader, conf CSVConfig) (map[string]interface{}, []string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, qerrors.Propagate("ReadCSV read input", err)
+	}
+
+	headers := conf.Headers
+	if len(headers) == 0 {
+		end := lineEnd(data, 0, false)
+		r := fastcsv.NewReader(bytes.NewReader(data[:end]), conf.Delimiter)
+		if headers, err = readHeader(&r); err != nil {
+			return nil, nil, err
+		}
+		data = data[end:]
+	}
+
+	r, err := newCSVChunkReader(fastcsv.Reader{}, headers, 0, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chunks := splitLines(data, conf.Parallelism*chunksPerGoroutine, conf.Parallelism)
+	tokenized := make([]tokenizedChunk, len(chunks))
+	errs := make([]error, len(chunks))
+	parallel.For(len(chunks), conf.Parallelism, func(i int) {
+		reader := fastcsv.NewReader(bytes.NewReader(chunks[i]), conf.Delimiter)
+		tokenized[i], errs[i] = r.tokenize(&reader, 0, 0)
+	})
+
+	for i, chunk := range tokenized {
+		r.row += chunk.records
+		if errs[i] != nil {
+			return nil, nil, r.tokenizeErr(errs[i])
+		}
+	}
+
+	colBytes := make([][]byte, len(headers))
+	colPointers := make([][]bytePointer, len(headers))
+	parallel.For(len(headers), conf.Parallelism, func(i int) {
+		if r.keep[i] {
+			colBytes[i], colPointers[i] = mergeChunks(tokenized, i)
+		}
+	})
+
+	r.done = true
+	return r.buildData(colBytes, colPointers)
+}
+
+// splitLines splits data into at most n chunks of complete lines. Quotes are counted
+// using up to parallelism goroutines.
+func splitLines(data []byte, n, parallelism int) [][]byte {
+	segmentLen := len(data)/n + 1
+	quoteCounts := make([]int, n)
+	parallel.For(n, parallelism, func(i int) {
+		start, end := i*segmentLen, (i+1)*segmentLen
+		if start > len(data) {
+			start = len(data)
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		quoteCounts[i] = bytes.Count(data[start:end], []byte{'"'})
+	})
+
+	chunks := make([][]byte, 0, n)
+	start, quotes := 0, 0
+	for i := 1; i < n; i++ {
+		quotes += quoteCounts[i-1]
+		splitPos := i * segmentLen
+		if splitPos >= len(data) {
+			break
+		}
+
+		if splitPos < start {
+			continue
+		}
+
+		end := lineEnd(data, splitPos, quotes%2 == 1)
+		chunks = append(chunks, data[start:end])
+		start = end
+	}
+
+	return append(chunks, data[start:])
+}
+
+// lineEnd returns the position after the first newline, not within quotes, at or after pos.
+// inQuote tells if pos is within a quoted field.
+func lineEnd(data []byte, pos int, inQuote bool) int {
+	for ; pos < len(data); pos++ {
+		switch data[pos] {
+		case '"':
+			inQuote = !inQuote
+		case '\n':
+			if !inQuote {
+				return pos + 1
+			}
+		}
+	}
+
+	return len(data)
+}
+
+// mergeChunks concatenates the bytes, and pointers, of column col in all chunks.
+func mergeChunks(chunks []tokenizedChunk, col int) ([]byte, []bytePointer) {
+	byteCount, pointerCount := 0, 0
+	for _, c := range chunks {
+		byteCount += len(c.colBytes[col])
+		pointerCount += len(c.colPointers[col])
+	}
+
+	colBytes := make([]byte, 0, byteCount)
+	pointers := make([]bytePointer, 0, pointerCount)
+	for _, c := range chunks {
+		offset := uint32(len(colBytes))
+		colBytes = append(colBytes, c.colBytes[col]...)
+		for _, p := range c.colPointers[col] {
+			pointers = append(pointers, bytePointer{start: p.start + offset, end: p.end + offset})
+		}
+	}
+
+	return colBytes, pointers
+}
@@ -1,140 +1,382 @@
 package io
 
 import (
+	"bufio"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"unicode"
 
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/bitmap"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/ncolumn"
 	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
 )
 
 type JSONRecords []map[string]interface{}
 
 type JSONColumns map[string]json.RawMessage
 
-func fillInts(col []int, records JSONRecords, colName string) error {
-	for i := range col {
-		record := records[i]
-		value, ok := record[colName]
-		if !ok {
-			return qerrors.New("fillInts", "missing value for column %s, row %d", colName, i)
-		}
+type jsonKind int
 
-		intValue, ok := value.(int)
-		if !ok {
-			return qerrors.New("fillInts", "wrong type for column %s, row %d, expected int", colName, i)
-		}
-		col[i] = intValue
+const (
+	jsonNull jsonKind = iota
+	jsonInt
+	jsonFloat
+	jsonBool
+	jsonString
+)
+
+func (k jsonKind) String() string {
+	switch k {
+	case jsonInt:
+		return "int"
+	case jsonFloat:
+		return "float"
+	case jsonBool:
+		return "bool"
+	case jsonString:
+		return "string"
 	}
+	return "null"
+}
 
-	return nil
+// jsonColumn builds a column from JSON values. The type of the column is
+// decided by the first non null value. Ints are promoted to floats if a
+// float is encountered later on.
+type jsonColumn struct {
+	kind    jsonKind
+	size    int
+	ints    []int
+	floats  []float64
+	bools   []bool
+	strings []*string
+
+	// Positions of null values in int and bool columns
+	nulls []int
 }
 
-func fillFloats(col []float64, records JSONRecords, colName string) error {
-	for i := range col {
-		record := records[i]
-		value, ok := record[colName]
-		if !ok {
-			return qerrors.New("fillFloats", "missing value for column %s, row %d", colName, i)
-		}
+func (c *jsonColumn) appendNull() {
+	switch c.kind {
+	case jsonInt:
+		c.nulls = append(c.nulls, c.size)
+		c.ints = append(c.ints, 0)
+	case jsonFloat:
+		c.floats = append(c.floats, math.NaN())
+	case jsonBool:
+		c.nulls = append(c.nulls, c.size)
+		c.bools = append(c.bools, false)
+	case jsonString:
+		c.strings = append(c.strings, nil)
+	}
+	c.size++
+}
+
+// setKind sets the kind of a column that only contains null values.
+func (c *jsonColumn) setKind(kind jsonKind) {
+	size := c.size
+	c.kind, c.size = kind, 0
+	for i := 0; i < size; i++ {
+		c.appendNull()
+	}
+}
+
+func (c *jsonColumn) toFloat() {
+	c.floats = make([]float64, len(c.ints))
+	for i, v := range c.ints {
+		c.floats[i] = float64(v)
+	}
+
+	for _, i := range c.nulls {
+		c.floats[i] = math.NaN()
+	}
+
+	c.kind, c.ints, c.nulls = jsonFloat, nil, nil
+}
 
-		floatValue, ok := value.(float64)
-		if !ok {
-			return qerrors.New("fillFloats", "wrong type for column %s, row %d, expected float", colName, i)
+func (c *jsonColumn) append(value interface{}) error {
+	kind := jsonNull
+	switch value.(type) {
+	case nil:
+		c.appendNull()
+		return nil
+	case json.Number:
+		kind = jsonInt
+		if _, err := strconv.Atoi(string(value.(json.Number))); err != nil {
+			kind = jsonFloat
 		}
-		col[i] = floatValue
+	case float64:
+		kind = jsonFloat
+	case bool:
+		kind = jsonBool
+	case string:
+		kind = jsonString
+	default:
+		return qerrors.New("append", "unsupported value %v", value)
+	}
+
+	switch {
+	case c.kind == jsonNull:
+		c.setKind(kind)
+	case c.kind == jsonInt && kind == jsonFloat:
+		c.toFloat()
+	case c.kind == jsonFloat && kind == jsonInt:
+		kind = jsonFloat
+	case c.kind != kind:
+		return qerrors.New("append", "wrong type, expected %s, was %s", c.kind, kind)
 	}
 
+	switch v := value.(type) {
+	case json.Number:
+		if kind == jsonInt {
+			i, _ := strconv.Atoi(string(v))
+			c.ints = append(c.ints, i)
+		} else {
+			f, err := v.Float64()
+			if err != nil {
+				return qerrors.Propagate("append", err)
+			}
+			c.floats = append(c.floats, f)
+		}
+	case float64:
+		c.floats = append(c.floats, v)
+	case bool:
+		c.bools = append(c.bools, v)
+	case string:
+		c.strings = append(c.strings, &v)
+	}
+	c.size++
 	return nil
 }
 
-func fillBools(col []bool, records JSONRecords, colName string) error {
-	for i := range col {
-		record := records[i]
-		value, ok := record[colName]
-		if !ok {
-			return qerrors.New("fillBools", "wrong type for column %s, row %d", colName, i)
+func (c *jsonColumn) validity() bitmap.Bitmap {
+	if len(c.nulls) == 0 {
+		return nil
+	}
+
+	valid := bitmap.New(c.size)
+	for _, i := range c.nulls {
+		valid.SetNull(uint32(i))
+	}
+
+	return valid
+}
+
+func (c *jsonColumn) data() types.DataSlice {
+	switch c.kind {
+	case jsonInt:
+		if len(c.nulls) > 0 {
+			return icolumn.NewNullable(c.ints, c.validity())
 		}
+		return c.ints
+	case jsonFloat:
+		return c.floats
+	case jsonBool:
+		if len(c.nulls) > 0 {
+			return bcolumn.NewNullable(c.bools, c.validity())
+		}
+		return c.bools
+	case jsonString:
+		return c.strings
+	}
+
+	// Without any non null values the type cannot be decided
+	if c.size > 0 {
+		return make([]*string, c.size)
+	}
+	return ncolumn.Column{}
+}
+
+// jsonRecordBuilder builds columns from JSON records. Columns missing
+// from a record get a null value for that record.
+type jsonRecordBuilder struct {
+	columns  map[string]*jsonColumn
+	colNames []string
+	size     int
+}
+
+func newJSONRecordBuilder() *jsonRecordBuilder {
+	return &jsonRecordBuilder{columns: map[string]*jsonColumn{}}
+}
 
-		boolValue, ok := value.(bool)
-		if !ok {
-			return qerrors.New("fillBools", "wrong type for column %s, row %d, expected bool", colName, i)
+func (b *jsonRecordBuilder) appendValue(colName string, value interface{}) error {
+	col, ok := b.columns[colName]
+	if !ok {
+		// Column not present in previous records
+		col = &jsonColumn{}
+		for i := 0; i < b.size; i++ {
+			col.appendNull()
 		}
-		col[i] = boolValue
+		b.columns[colName] = col
+		b.colNames = append(b.colNames, colName)
+	}
+
+	if col.size > b.size {
+		return qerrors.New("append value", "duplicate key %s in record %d", colName, b.size)
+	}
+
+	if err := col.append(value); err != nil {
+		return qerrors.Propagate(fmt.Sprintf("column %s, record %d", colName, b.size), err)
 	}
 
 	return nil
 }
 
-func fillStrings(col []*string, records JSONRecords, colName string) error {
-	for i := range col {
-		record := records[i]
-		value, ok := record[colName]
-		if !ok {
-			return qerrors.New("fillStrings", "wrong type for column %s, row %d", colName, i)
+// endRecord fills in nulls for columns missing from the current record.
+func (b *jsonRecordBuilder) endRecord() {
+	b.size++
+	for _, col := range b.columns {
+		if col.size < b.size {
+			col.appendNull()
 		}
+	}
+}
 
-		switch t := value.(type) {
-		case string:
-			col[i] = &t
-		case nil:
-			col[i] = nil
-		default:
-			return qerrors.New("fillStrings", "wrong type for column %s, row %d, expected int", colName, i)
-		}
+func (b *jsonRecordBuilder) data() map[string]types.DataSlice {
+	result := make(map[string]types.DataSlice, len(b.columns))
+	for name, col := range b.columns {
+		result[name] = col.data()
 	}
 
-	return nil
+	return result
 }
 
 func jsonRecordsToData(records JSONRecords) (map[string]interface{}, error) {
-	result := map[string]interface{}{}
-	if len(records) == 0 {
-		return result, nil
-	}
-
-	r0 := records[0]
-	for colName, value := range r0 {
-		switch t := value.(type) {
-		case int:
-			col := make([]int, len(records))
-			if err := fillInts(col, records, colName); err != nil {
-				return nil, err
-			}
-			result[colName] = col
-		case float64:
-			col := make([]float64, len(records))
-			if err := fillFloats(col, records, colName); err != nil {
-				return nil, err
+	b := newJSONRecordBuilder()
+	for _, record := range records {
+		for colName, value := range record {
+			if err := b.appendValue(colName, value); err != nil {
+				return nil, qerrors.Propagate("jsonRecordsToData", err)
 			}
-			result[colName] = col
-		case bool:
-			col := make([]bool, len(records))
-			if err := fillBools(col, records, colName); err != nil {
-				return nil, err
-			}
-			result[colName] = col
-		case nil, string:
-			col := make([]*string, len(records))
-			if err := fillStrings(col, records, colName); err != nil {
-				return nil, err
+		}
+		b.endRecord()
+	}
+
+	return b.data(), nil
+}
+
+func jsonColumnsToData(columns JSONColumns) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(columns))
+	size := -1
+	for colName, raw := range columns {
+		var values []interface{}
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, qerrors.Propagate("jsonColumnsToData", err)
+		}
+
+		if size >= 0 && len(values) != size {
+			return nil, qerrors.New("jsonColumnsToData", "column %s has length %d, expected %d", colName, len(values), size)
+		}
+		size = len(values)
+
+		col := &jsonColumn{}
+		for i, value := range values {
+			if err := col.append(value); err != nil {
+				return nil, qerrors.Propagate(fmt.Sprintf("jsonColumnsToData column %s, row %d", colName, i), err)
 			}
-			result[colName] = col
-		default:
-			return nil, qerrors.New("jsonRecordsToData", "unknown type of %s", t)
 		}
+		result[colName] = col.data()
 	}
+
 	return result, nil
 }
 
 // UnmarshalJSON transforms JSON containing data records or columns into a map of columns
-// that can be used to create a QFrame.
+// that can be used to create a QFrame. Records are given as an array of objects, columns
+// as an object with one array per column.
 func UnmarshalJSON(r io.Reader) (map[string]interface{}, error) {
+	reader := bufio.NewReader(r)
+	first, err := peekNonSpace(reader)
+	if err != nil {
+		return nil, qerrors.Propagate("UnmarshalJSON", err)
+	}
+
+	decoder := json.NewDecoder(reader)
+	if first == '{' {
+		var columns JSONColumns
+		if err := decoder.Decode(&columns); err != nil {
+			return nil, qerrors.Propagate("UnmarshalJSON", err)
+		}
+		return jsonColumnsToData(columns)
+	}
+
 	var records JSONRecords
-	decoder := json.NewDecoder(r)
-	err := decoder.Decode(&records)
+	err = decoder.Decode(&records)
 	if err != nil {
 		return nil, qerrors.Propagate("UnmarshalJSON", err)
 	}
 
 	return jsonRecordsToData(records)
 }
+
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+
+		if !unicode.IsSpace(rune(b[0])) {
+			return b[0], nil
+		}
+
+		if _, err := r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ReadNDJSON reads newline delimited JSON, one object per record, from r. Records
+// are decoded one at a time, the types of the columns are inferred from all records.
+// The column names are returned in the order they first appear in.
+func ReadNDJSON(r io.Reader) (map[string]types.DataSlice, []string, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	b := newJSONRecordBuilder()
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, qerrors.Propagate("ReadNDJSON", err)
+		}
+
+		if delim, ok := token.(json.Delim); !ok || delim != '{' {
+			return nil, nil, qerrors.New("ReadNDJSON", "expected JSON object for record %d, was %v", b.size, token)
+		}
+
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, nil, qerrors.Propagate("ReadNDJSON", err)
+			}
+
+			value, err := decoder.Token()
+			if err != nil {
+				return nil, nil, qerrors.Propagate("ReadNDJSON", err)
+			}
+
+			if _, ok := value.(json.Delim); ok {
+				return nil, nil, qerrors.New("ReadNDJSON", "nested values are not supported, column %s, record %d", key, b.size)
+			}
+
+			if err := b.appendValue(key.(string), value); err != nil {
+				return nil, nil, qerrors.Propagate("ReadNDJSON", err)
+			}
+		}
+
+		// Closing brace of the record
+		if _, err := decoder.Token(); err != nil {
+			return nil, nil, qerrors.Propagate("ReadNDJSON", err)
+		}
+		b.endRecord()
+	}
+
+	return b.data(), b.colNames, nil
+}
@@ -0,0 +1,290 @@
+package parquet
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+// values holds decoded values of one of the physical types.
+type values struct {
+	ints   []int64
+	floats []float64
+	bools  []bool
+	bytes  [][]byte
+}
+
+func (v values) len() int {
+	return len(v.ints) + len(v.floats) + len(v.bools) + len(v.bytes)
+}
+
+// gather returns the values at the positions given by codes, used to look up dictionary values.
+func (v values) gather(codes []uint32) (values, error) {
+	var result values
+	size := uint32(v.len())
+	for _, c := range codes {
+		if c >= size {
+			return values{}, qerrors.New("dictionary lookup", "dictionary index out of range: %d", c)
+		}
+	}
+
+	switch {
+	case v.ints != nil:
+		result.ints = make([]int64, len(codes))
+		for i, c := range codes {
+			result.ints[i] = v.ints[c]
+		}
+	case v.floats != nil:
+		result.floats = make([]float64, len(codes))
+		for i, c := range codes {
+			result.floats[i] = v.floats[c]
+		}
+	case v.bools != nil:
+		result.bools = make([]bool, len(codes))
+		for i, c := range codes {
+			result.bools[i] = v.bools[c]
+		}
+	default:
+		result.bytes = make([][]byte, len(codes))
+		for i, c := range codes {
+			result.bytes[i] = v.bytes[c]
+		}
+	}
+
+	return result, nil
+}
+
+// decodePlain decodes n values of the physical type typ encoded using the plain encoding.
+func decodePlain(buf []byte, typ int64, n int) (values, error) {
+	// Every value takes at least one bit
+	if n < 0 || n > 8*len(buf) {
+		return values{}, qerrors.New("plain decode", "value count %d out of range", n)
+	}
+
+	var result values
+	var size int
+	switch typ {
+	case typeBoolean:
+		size = (n + 7) / 8
+	case typeInt32, typeFloat:
+		size = 4 * n
+	case typeByteArray:
+		// Each value is prefixed by its length
+		size = 4 * n
+	case typeInt64, typeDouble:
+		size = 8 * n
+	}
+
+	if size > len(buf) {
+		return values{}, qerrors.New("plain decode", "data out of range")
+	}
+
+	switch typ {
+	case typeBoolean:
+		result.bools = make([]bool, n)
+		for i := range result.bools {
+			result.bools[i] = buf[i>>3]&(1<<(uint(i)&7)) != 0
+		}
+	case typeInt32:
+		result.ints = make([]int64, n)
+		for i := range result.ints {
+			result.ints[i] = int64(int32(le.Uint32(buf[4*i:])))
+		}
+	case typeInt64:
+		result.ints = make([]int64, n)
+		for i := range result.ints {
+			result.ints[i] = int64(le.Uint64(buf[8*i:]))
+		}
+	case typeFloat:
+		result.floats = make([]float64, n)
+		for i := range result.floats {
+			result.floats[i] = float64(math.Float32frombits(le.Uint32(buf[4*i:])))
+		}
+	case typeDouble:
+		result.floats = make([]float64, n)
+		for i := range result.floats {
+			result.floats[i] = math.Float64frombits(le.Uint64(buf[8*i:]))
+		}
+	case typeByteArray:
+		result.bytes = make([][]byte, 0, n)
+		pos := 0
+		for i := 0; i < n; i++ {
+			if pos+4 > len(buf) {
+				return values{}, qerrors.New("plain decode", "data out of range")
+			}
+
+			l := int(le.Uint32(buf[pos:]))
+			pos += 4
+			if l < 0 || pos+l > len(buf) {
+				return values{}, qerrors.New("plain decode", "data out of range")
+			}
+
+			result.bytes = append(result.bytes, buf[pos:pos+l])
+			pos += l
+		}
+	default:
+		return values{}, qerrors.New("plain decode", "unsupported type: %d", typ)
+	}
+
+	return result, nil
+}
+
+// decodeHybrid decodes count values, of the given bit width, encoded using the
+// RLE/bit-packing hybrid encoding.
+func decodeHybrid(buf []byte, bitWidth, count int) ([]uint32, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, qerrors.New("hybrid decode", "invalid bit width: %d", bitWidth)
+	}
+
+	if count < 0 {
+		return nil, qerrors.New("hybrid decode", "invalid value count: %d", count)
+	}
+
+	// Runs may expand to any number of values, don't trust the count of malformed input
+	capacity := count
+	if capacity > 8*len(buf) {
+		capacity = 8 * len(buf)
+	}
+
+	result := make([]uint32, 0, capacity)
+	d := thriftDecoder{buf: buf}
+	for len(result) < count {
+		if d.pos >= len(buf) {
+			return nil, qerrors.New("hybrid decode", "data out of range")
+		}
+
+		header := d.varint()
+		remaining := count - len(result)
+		if header&1 == 1 {
+			// Bit packed groups of eight values
+			groups := header >> 1
+			if groups > uint64(len(buf)) {
+				return nil, qerrors.New("hybrid decode", "data out of range")
+			}
+
+			valueCount := int(groups) * 8
+			byteCount := int(groups) * bitWidth
+			if byteCount > len(buf)-d.pos {
+				return nil, qerrors.New("hybrid decode", "data out of range")
+			}
+
+			if valueCount > remaining {
+				valueCount = remaining
+			}
+
+			result = unpackBits(result, buf[d.pos:d.pos+byteCount], bitWidth, valueCount)
+			d.pos += byteCount
+			continue
+		}
+
+		// Run of repeated values
+		runLen := int(header >> 1)
+		byteCount := (bitWidth + 7) / 8
+		if d.pos+byteCount > len(buf) {
+			return nil, qerrors.New("hybrid decode", "data out of range")
+		}
+
+		var value uint32
+		for i := 0; i < byteCount; i++ {
+			value |= uint32(buf[d.pos+i]) << (8 * uint(i))
+		}
+		d.pos += byteCount
+
+		if runLen > remaining || runLen < 0 {
+			runLen = remaining
+		}
+
+		for i := 0; i < runLen; i++ {
+			result = append(result, value)
+		}
+	}
+
+	return result, nil
+}
+
+func unpackBits(dst []uint32, buf []byte, bitWidth, count int) []uint32 {
+	mask := uint64(1)<<uint(bitWidth) - 1
+	var acc uint64
+	accBits, pos := 0, 0
+	for i := 0; i < count; i++ {
+		for accBits < bitWidth {
+			acc |= uint64(buf[pos]) << uint(accBits)
+			accBits += 8
+			pos++
+		}
+
+		dst = append(dst, uint32(acc&mask))
+		acc >>= uint(bitWidth)
+		accBits -= bitWidth
+	}
+
+	return dst
+}
+
+// bitsNeeded returns the number of bits needed to represent maxValue.
+func bitsNeeded(maxValue uint32) int {
+	return bits.Len32(maxValue)
+}
+
+// encodeHybrid appends values encoded using the RLE/bit-packing hybrid encoding to buf.
+// Runs of at least eight repeated values are run length encoded, all other values are bit packed.
+func encodeHybrid(buf []byte, values []uint32, bitWidth int) []byte {
+	packStart := 0
+	for i := 0; i < len(values); {
+		run := 1
+		for i+run < len(values) && values[i+run] == values[i] {
+			run++
+		}
+
+		// Bit packed values come in groups of eight, use the start of the run to fill up the last group
+		pad := (8 - (i-packStart)%8) % 8
+		if run-pad >= 8 {
+			buf = appendBitPacked(buf, values[packStart:i+pad], bitWidth)
+			buf = appendRun(buf, values[i], run-pad, bitWidth)
+			i += run
+			packStart = i
+			continue
+		}
+
+		i += run
+	}
+
+	return appendBitPacked(buf, values[packStart:], bitWidth)
+}
+
+func appendRun(buf []byte, value uint32, runLen, bitWidth int) []byte {
+	buf = appendVarint(buf, uint64(runLen)<<1)
+	for i := 0; i < (bitWidth+7)/8; i++ {
+		buf = append(buf, byte(value>>(8*uint(i))))
+	}
+
+	return buf
+}
+
+// appendBitPacked appends values as bit packed groups of eight values. The last group
+// is padded with zeros.
+func appendBitPacked(buf []byte, values []uint32, bitWidth int) []byte {
+	if len(values) == 0 {
+		return buf
+	}
+
+	groups := (len(values) + 7) / 8
+	buf = appendVarint(buf, uint64(groups)<<1|1)
+	var acc uint64
+	accBits := 0
+	for i := 0; i < 8*groups; i++ {
+		if i < len(values) {
+			acc |= uint64(values[i]) << uint(accBits)
+		}
+
+		accBits += bitWidth
+		for accBits >= 8 {
+			buf = append(buf, byte(acc))
+			acc >>= 8
+			accBits -= 8
+		}
+	}
+
+	return buf
+}
@@ -0,0 +1,131 @@
+package parquet
+
+// Constants from the Parquet thrift definitions (parquet.thrift).
+// Only the subset needed to map to and from QFrame columns is listed.
+
+const magic = "PAR1"
+
+// Type
+const (
+	typeBoolean           = 0
+	typeInt32             = 1
+	typeInt64             = 2
+	typeInt96             = 3
+	typeFloat             = 4
+	typeDouble            = 5
+	typeByteArray         = 6
+	typeFixedLenByteArray = 7
+)
+
+// ConvertedType
+const (
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+	convertedTimestampMicros = 10
+)
+
+// FieldRepetitionType
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+	repetitionRepeated = 2
+)
+
+// Encoding
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLE             = 3
+	encodingRLEDictionary   = 8
+)
+
+// CompressionCodec
+const (
+	codecUncompressed = 0
+	codecSnappy       = 1
+	codecGzip         = 2
+)
+
+// PageType
+const (
+	pageData       = 0
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// Field ids of the structs used
+const (
+	// FileMetaData
+	fileMetaDataVersion   = 1
+	fileMetaDataSchema    = 2
+	fileMetaDataNumRows   = 3
+	fileMetaDataRowGroups = 4
+	fileMetaDataCreatedBy = 6
+
+	// SchemaElement
+	schemaElementType           = 1
+	schemaElementRepetitionType = 3
+	schemaElementName           = 4
+	schemaElementNumChildren    = 5
+	schemaElementConvertedType  = 6
+	schemaElementLogicalType    = 10
+
+	// LogicalType (union)
+	logicalTypeString    = 1
+	logicalTypeTimestamp = 8
+	logicalTypeUnknown   = 11
+
+	// TimestampType
+	timestampTypeIsAdjustedToUTC = 1
+	timestampTypeUnit            = 2
+
+	// TimeUnit (union)
+	timeUnitMillis = 1
+	timeUnitMicros = 2
+	timeUnitNanos  = 3
+
+	// RowGroup
+	rowGroupColumns       = 1
+	rowGroupTotalByteSize = 2
+	rowGroupNumRows       = 3
+
+	// ColumnChunk
+	columnChunkFileOffset = 2
+	columnChunkMetaData   = 3
+
+	// ColumnMetaData
+	columnMetaDataType                  = 1
+	columnMetaDataEncodings             = 2
+	columnMetaDataPathInSchema          = 3
+	columnMetaDataCodec                 = 4
+	columnMetaDataNumValues             = 5
+	columnMetaDataTotalUncompressedSize = 6
+	columnMetaDataTotalCompressedSize   = 7
+	columnMetaDataDataPageOffset        = 9
+	columnMetaDataDictionaryPageOffset  = 11
+
+	// PageHeader
+	pageHeaderType                 = 1
+	pageHeaderUncompressedPageSize = 2
+	pageHeaderCompressedPageSize   = 3
+	pageHeaderDataPageHeader       = 5
+	pageHeaderDictionaryPageHeader = 7
+	pageHeaderDataPageHeaderV2     = 8
+
+	// DataPageHeader
+	dataPageHeaderNumValues               = 1
+	dataPageHeaderEncoding                = 2
+	dataPageHeaderDefinitionLevelEncoding = 3
+	dataPageHeaderRepetitionLevelEncoding = 4
+
+	// DictionaryPageHeader
+	dictionaryPageHeaderNumValues = 1
+	dictionaryPageHeaderEncoding  = 2
+
+	// DataPageHeaderV2
+	dataPageHeaderV2NumValues                  = 1
+	dataPageHeaderV2Encoding                   = 4
+	dataPageHeaderV2DefinitionLevelsByteLength = 5
+	dataPageHeaderV2RepetitionLevelsByteLength = 6
+	dataPageHeaderV2IsCompressed               = 7
+)
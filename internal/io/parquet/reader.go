@@ -0,0 +1,672 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math"
+	"time"
+
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/bitmap"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/ncolumn"
+	qfstrings "github.com/yistabraq/qframe/internal/strings"
+	"github.com/yistabraq/qframe/internal/tcolumn"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// Config holds configuration for reading and writing Parquet files.
+type Config struct {
+	// Columns to read, all columns are read if empty.
+	Columns []string
+
+	// Compression is the name of the codec used to compress pages when writing, defaults to snappy.
+	Compression string
+}
+
+type leaf struct {
+	name     string
+	typ      int64
+	optional bool
+
+	// isNull is set for columns of the null (UNKNOWN) logical type, the values are always null.
+	isNull bool
+
+	// timeUnit is the number of nanoseconds per unit of timestamp columns, 0 for other columns.
+	timeUnit int64
+}
+
+// timestampUnit returns the number of nanoseconds per unit of INT64 timestamp columns,
+// given either as logical type or as the legacy converted type.
+func timestampUnit(e thriftStruct) int64 {
+	if logicalType, ok := e.structField(schemaElementLogicalType); ok {
+		timestamp, ok := logicalType.structField(logicalTypeTimestamp)
+		if !ok {
+			return 0
+		}
+
+		unit, _ := timestamp.structField(timestampTypeUnit)
+		for id, nanos := range map[int16]int64{
+			timeUnitMillis: int64(time.Millisecond),
+			timeUnitMicros: int64(time.Microsecond),
+			timeUnitNanos:  1} {
+			if _, ok := unit.field(id); ok {
+				return nanos
+			}
+		}
+		return 0
+	}
+
+	switch e.int(schemaElementConvertedType, -1) {
+	case convertedTimestampMillis:
+		return int64(time.Millisecond)
+	case convertedTimestampMicros:
+		return int64(time.Microsecond)
+	}
+	return 0
+}
+
+func readSchema(elements []thriftStruct) ([]leaf, error) {
+	if len(elements) == 0 {
+		return nil, qerrors.New("read schema", "empty schema")
+	}
+
+	childCount := int(elements[0].int(schemaElementNumChildren, 0))
+	if childCount != len(elements)-1 {
+		return nil, qerrors.New("read schema", "nested types are not supported")
+	}
+
+	leaves := make([]leaf, childCount)
+	for i, e := range elements[1:] {
+		l := leaf{
+			name:     e.string(schemaElementName),
+			typ:      e.int(schemaElementType, -1),
+			optional: e.int(schemaElementRepetitionType, repetitionRequired) == repetitionOptional}
+
+		if e.int(schemaElementNumChildren, 0) > 0 || e.int(schemaElementRepetitionType, repetitionRequired) == repetitionRepeated {
+			return nil, qerrors.New("read schema", "nested types are not supported, column: %s", l.name)
+		}
+
+		switch l.typ {
+		case typeBoolean, typeInt32, typeInt64, typeFloat, typeDouble, typeByteArray:
+		default:
+			return nil, qerrors.New("read schema", "unsupported type %d, column: %s", l.typ, l.name)
+		}
+
+		if logicalType, ok := e.structField(schemaElementLogicalType); ok {
+			_, l.isNull = logicalType.field(logicalTypeUnknown)
+		}
+
+		if l.typ == typeInt64 {
+			l.timeUnit = timestampUnit(e)
+		}
+
+		leaves[i] = l
+	}
+
+	return leaves, nil
+}
+
+func decompress(codec int64, src []byte, uncompressedSize int) ([]byte, error) {
+	var result []byte
+	var err error
+	switch codec {
+	case codecUncompressed:
+		result = src
+	case codecSnappy:
+		result, err = snappyDecode(src)
+	case codecGzip:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(src)); err == nil {
+			result, err = io.ReadAll(io.LimitReader(r, int64(uncompressedSize)+1))
+		}
+	default:
+		return nil, qerrors.New("decompress", "unsupported compression codec: %d", codec)
+	}
+
+	if err != nil {
+		return nil, qerrors.Propagate("decompress", err)
+	}
+
+	if len(result) != uncompressedSize {
+		return nil, qerrors.New("decompress", "unexpected page size %d, expected %d", len(result), uncompressedSize)
+	}
+
+	return result, nil
+}
+
+// decodeLevels decodes the definition levels of a flat, optional, column into
+// a slice telling if each value is present.
+func decodeLevels(buf []byte, count int) ([]bool, error) {
+	levels, err := decodeHybrid(buf, 1, count)
+	if err != nil {
+		return nil, qerrors.Propagate("decode definition levels", err)
+	}
+
+	valid := make([]bool, count)
+	for i, l := range levels {
+		valid[i] = l == 1
+	}
+
+	return valid, nil
+}
+
+// columnBuilder collects the values of a column from all row groups.
+type columnBuilder struct {
+	leaf   leaf
+	length int
+
+	ints   []int
+	floats []float64
+	bools  []bool
+	nulls  []uint32
+
+	pointers []qfstrings.Pointer
+	data     []byte
+
+	// Strings are kept as dictionary codes as long as all pages are dictionary encoded.
+	// The codes refer to dictValues which contains the values of all dictionaries read.
+	dictOnly        bool
+	codes           []int
+	dictValues      []string
+	dictIndex       map[string]int
+	dictionary      *values
+	dictTranslation []int
+}
+
+func newColumnBuilder(l leaf) *columnBuilder {
+	return &columnBuilder{leaf: l, dictOnly: l.typ == typeByteArray, dictIndex: map[string]int{}}
+}
+
+func (b *columnBuilder) setDictionary(dict values) {
+	b.dictionary = &dict
+	if b.leaf.typ != typeByteArray {
+		return
+	}
+
+	// Translate the codes of the dictionary to codes in the column
+	b.dictTranslation = make([]int, len(dict.bytes))
+	for i, v := range dict.bytes {
+		code, ok := b.dictIndex[string(v)]
+		if !ok {
+			code = len(b.dictValues)
+			b.dictValues = append(b.dictValues, string(v))
+			b.dictIndex[string(v)] = code
+		}
+		b.dictTranslation[i] = code
+	}
+}
+
+func (b *columnBuilder) appendString(s []byte, isNull bool) {
+	b.pointers = append(b.pointers, qfstrings.NewPointer(len(b.data), len(s), isNull))
+	b.data = append(b.data, s...)
+}
+
+// codesToStrings converts the codes collected so far to strings. Used when a column contains
+// both dictionary and plain encoded pages.
+func (b *columnBuilder) codesToStrings() {
+	for _, c := range b.codes {
+		if c < 0 {
+			b.appendString(nil, true)
+		} else {
+			b.appendString([]byte(b.dictValues[c]), false)
+		}
+	}
+
+	b.codes = nil
+	b.dictOnly = false
+}
+
+func (b *columnBuilder) appendCodes(valid []bool, count int, codes []uint32) error {
+	j := 0
+	for i := 0; i < count; i++ {
+		if valid != nil && !valid[i] {
+			b.codes = append(b.codes, -1)
+			continue
+		}
+
+		c := codes[j]
+		j++
+		if int(c) >= len(b.dictTranslation) {
+			return qerrors.New("read dictionary page", "dictionary index out of range: %d", c)
+		}
+		b.codes = append(b.codes, b.dictTranslation[c])
+	}
+
+	return nil
+}
+
+// appendValues appends count values to the column. The non null values are taken, in order, from v.
+func (b *columnBuilder) appendValues(valid []bool, count int, v values) {
+	isNull := func(i int) bool {
+		return valid != nil && !valid[i]
+	}
+
+	j := 0
+	for i := 0; i < count; i++ {
+		if isNull(i) {
+			switch b.leaf.typ {
+			case typeInt32, typeInt64:
+				b.ints = append(b.ints, 0)
+				b.nulls = append(b.nulls, uint32(b.length+i))
+			case typeBoolean:
+				b.bools = append(b.bools, false)
+				b.nulls = append(b.nulls, uint32(b.length+i))
+			case typeFloat, typeDouble:
+				b.floats = append(b.floats, math.NaN())
+			case typeByteArray:
+				b.appendString(nil, true)
+			}
+			continue
+		}
+
+		switch b.leaf.typ {
+		case typeInt32, typeInt64:
+			b.ints = append(b.ints, int(v.ints[j]))
+		case typeBoolean:
+			b.bools = append(b.bools, v.bools[j])
+		case typeFloat, typeDouble:
+			b.floats = append(b.floats, v.floats[j])
+		case typeByteArray:
+			b.appendString(v.bytes[j], false)
+		}
+		j++
+	}
+}
+
+func (b *columnBuilder) appendPage(data []byte, encoding int64, count int, valid []bool) error {
+	nonNullCount := count
+	for _, v := range valid {
+		if !v {
+			nonNullCount--
+		}
+	}
+
+	if b.leaf.isNull {
+		if nonNullCount > 0 {
+			return qerrors.New("read page", "unexpected values in null column: %s", b.leaf.name)
+		}
+
+		for i := 0; i < count; i++ {
+			b.appendString(nil, true)
+		}
+		b.length += count
+		return nil
+	}
+
+	switch encoding {
+	case encodingPlain:
+		v, err := decodePlain(data, b.leaf.typ, nonNullCount)
+		if err != nil {
+			return err
+		}
+
+		if b.dictOnly {
+			b.codesToStrings()
+		}
+		b.appendValues(valid, count, v)
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if b.dictionary == nil {
+			return qerrors.New("read page", "missing dictionary page, column: %s", b.leaf.name)
+		}
+
+		if len(data) == 0 {
+			return qerrors.New("read page", "missing bit width, column: %s", b.leaf.name)
+		}
+
+		codes, err := decodeHybrid(data[1:], int(data[0]), nonNullCount)
+		if err != nil {
+			return err
+		}
+
+		if b.dictOnly {
+			if err := b.appendCodes(valid, count, codes); err != nil {
+				return err
+			}
+			break
+		}
+
+		v, err := b.dictionary.gather(codes)
+		if err != nil {
+			return err
+		}
+		b.appendValues(valid, count, v)
+	case encodingRLE:
+		if b.leaf.typ != typeBoolean || len(data) < 4 {
+			return qerrors.New("read page", "unsupported RLE encoding, column: %s", b.leaf.name)
+		}
+
+		decoded, err := decodeHybrid(data[4:], 1, nonNullCount)
+		if err != nil {
+			return err
+		}
+
+		v := values{bools: make([]bool, len(decoded))}
+		for i, x := range decoded {
+			v.bools[i] = x == 1
+		}
+		b.appendValues(valid, count, v)
+	default:
+		return qerrors.New("read page", "unsupported encoding %d, column: %s", encoding, b.leaf.name)
+	}
+
+	b.length += count
+	return nil
+}
+
+// pageValueCount validates the number of values in a data page, it must not exceed the number
+// of values remaining in the column chunk.
+func pageValueCount(count, remaining int64, colName string) (int, error) {
+	if count < 0 || count > remaining {
+		return 0, qerrors.New("read column chunk", "page value count %d out of range, column: %s", count, colName)
+	}
+
+	return int(count), nil
+}
+
+// readChunk reads all pages of a column chunk.
+func (b *columnBuilder) readChunk(buf []byte, metaData thriftStruct) error {
+	numValues := metaData.int(columnMetaDataNumValues, 0)
+	codec := metaData.int(columnMetaDataCodec, codecUncompressed)
+	b.dictionary = nil
+	for pos, read := 0, int64(0); read < numValues; {
+		if pos >= len(buf) {
+			return qerrors.New("read column chunk", "missing pages, column: %s", b.leaf.name)
+		}
+
+		header, n := decodeStruct(buf[pos:])
+		pos += n
+		compressedSize := int(header.int(pageHeaderCompressedPageSize, -1))
+		uncompressedSize := int(header.int(pageHeaderUncompressedPageSize, -1))
+		if compressedSize < 0 || uncompressedSize < 0 || compressedSize > len(buf)-pos {
+			return qerrors.New("read column chunk", "page out of range, column: %s", b.leaf.name)
+		}
+
+		page := buf[pos : pos+compressedSize]
+		pos += compressedSize
+
+		switch header.int(pageHeaderType, -1) {
+		case pageDictionary:
+			dictHeader, _ := header.structField(pageHeaderDictionaryPageHeader)
+			data, err := decompress(codec, page, uncompressedSize)
+			if err != nil {
+				return err
+			}
+
+			dict, err := decodePlain(data, b.leaf.typ, int(dictHeader.int(dictionaryPageHeaderNumValues, 0)))
+			if err != nil {
+				return err
+			}
+			b.setDictionary(dict)
+		case pageData:
+			dataHeader, _ := header.structField(pageHeaderDataPageHeader)
+			count, err := pageValueCount(dataHeader.int(dataPageHeaderNumValues, -1), numValues-read, b.leaf.name)
+			if err != nil {
+				return err
+			}
+
+			data, err := decompress(codec, page, uncompressedSize)
+			if err != nil {
+				return err
+			}
+
+			var valid []bool
+			if b.leaf.optional {
+				if len(data) < 4 || 4+int(le.Uint32(data)) > len(data) {
+					return qerrors.New("read column chunk", "definition levels out of range, column: %s", b.leaf.name)
+				}
+
+				levelsLen := int(le.Uint32(data))
+				if valid, err = decodeLevels(data[4:4+levelsLen], count); err != nil {
+					return err
+				}
+				data = data[4+levelsLen:]
+			}
+
+			if err := b.appendPage(data, dataHeader.int(dataPageHeaderEncoding, encodingPlain), count, valid); err != nil {
+				return err
+			}
+			read += int64(count)
+		case pageDataV2:
+			dataHeader, _ := header.structField(pageHeaderDataPageHeaderV2)
+			count, err := pageValueCount(dataHeader.int(dataPageHeaderV2NumValues, -1), numValues-read, b.leaf.name)
+			if err != nil {
+				return err
+			}
+
+			levelsLen := int(dataHeader.int(dataPageHeaderV2DefinitionLevelsByteLength, 0))
+			if dataHeader.int(dataPageHeaderV2RepetitionLevelsByteLength, 0) != 0 {
+				return qerrors.New("read column chunk", "nested types are not supported, column: %s", b.leaf.name)
+			}
+
+			if levelsLen < 0 || levelsLen > len(page) {
+				return qerrors.New("read column chunk", "definition levels out of range, column: %s", b.leaf.name)
+			}
+
+			data := page[levelsLen:]
+			if dataHeader.bool(dataPageHeaderV2IsCompressed, true) {
+				if data, err = decompress(codec, data, uncompressedSize-levelsLen); err != nil {
+					return err
+				}
+			}
+
+			var valid []bool
+			if b.leaf.optional {
+				if valid, err = decodeLevels(page[:levelsLen], count); err != nil {
+					return err
+				}
+			}
+
+			if err := b.appendPage(data, dataHeader.int(dataPageHeaderV2Encoding, encodingPlain), count, valid); err != nil {
+				return err
+			}
+			read += int64(count)
+		default:
+			// Index pages, and any other pages, do not contain data
+		}
+	}
+
+	return nil
+}
+
+func (b *columnBuilder) validity() bitmap.Bitmap {
+	if len(b.nulls) == 0 {
+		return nil
+	}
+
+	valid := bitmap.New(b.length)
+	for _, i := range b.nulls {
+		valid.SetNull(i)
+	}
+
+	return valid
+}
+
+func (b *columnBuilder) toData() types.DataSlice {
+	if b.leaf.isNull && b.length == 0 {
+		return ncolumn.Column{}
+	}
+
+	if b.leaf.timeUnit > 0 {
+		times := make([]int64, len(b.ints))
+		for i, v := range b.ints {
+			times[i] = int64(v) * b.leaf.timeUnit
+		}
+		return tcolumn.NewNullable(times, b.validity(), time.UTC)
+	}
+
+	switch b.leaf.typ {
+	case typeInt32, typeInt64:
+		return icolumn.NewNullable(b.ints, b.validity())
+	case typeFloat, typeDouble:
+		return b.floats
+	case typeBoolean:
+		return bcolumn.NewNullable(b.bools, b.validity())
+	}
+
+	if b.dictOnly && len(b.codes) > 0 {
+		data := make([]*string, len(b.codes))
+		for i, c := range b.codes {
+			if c >= 0 {
+				data[i] = &b.dictValues[c]
+			}
+		}
+
+		if col, err := ecolumn.New(data, b.dictValues); err == nil {
+			return col
+		}
+
+		// Too many distinct values to fit in an enum, fall back to a string column
+		return data
+	}
+
+	return qfstrings.StringBlob{Pointers: b.pointers, Data: b.data}
+}
+
+func keepColumns(leaves []leaf, columns []string) ([]bool, error) {
+	keep := make([]bool, len(leaves))
+	for _, c := range columns {
+		found := false
+		for i, l := range leaves {
+			if l.name == c {
+				keep[i], found = true, true
+			}
+		}
+
+		if !found {
+			return nil, qerrors.New("read columns", "unknown column: %q", c)
+		}
+	}
+
+	if len(columns) == 0 {
+		for i := range keep {
+			keep[i] = true
+		}
+	}
+
+	return keep, nil
+}
+
+// ReadParquet reads a Parquet file of the given size into a map of columns that
+// can be used to create a QFrame. The order of the columns in the schema is also returned.
+func ReadParquet(r io.ReaderAt, size int64, conf Config) (result map[string]types.DataSlice, columns []string, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			m, ok := rec.(malformedError)
+			if !ok {
+				panic(rec)
+			}
+			result, columns, err = nil, nil, qerrors.New("ReadParquet", "malformed input: %s", m.reason)
+		}
+	}()
+
+	footer := make([]byte, 8)
+	if size < int64(len(magic)+len(footer)) {
+		return nil, nil, qerrors.New("ReadParquet", "file too small to be a Parquet file")
+	}
+
+	if _, err := r.ReadAt(footer, size-int64(len(footer))); err != nil {
+		return nil, nil, qerrors.Propagate("ReadParquet", err)
+	}
+
+	if string(footer[4:]) != magic {
+		return nil, nil, qerrors.New("ReadParquet", "not a Parquet file")
+	}
+
+	metaDataLen := int64(le.Uint32(footer))
+	if metaDataLen > size-int64(len(footer)+len(magic)) {
+		return nil, nil, qerrors.New("ReadParquet", "invalid metadata length: %d", metaDataLen)
+	}
+
+	buf := make([]byte, metaDataLen)
+	if _, err := r.ReadAt(buf, size-int64(len(footer))-metaDataLen); err != nil {
+		return nil, nil, qerrors.Propagate("ReadParquet", err)
+	}
+
+	metaData, _ := decodeStruct(buf)
+	leaves, err := readSchema(metaData.structList(fileMetaDataSchema))
+	if err != nil {
+		return nil, nil, qerrors.Propagate("ReadParquet", err)
+	}
+
+	keep, err := keepColumns(leaves, conf.Columns)
+	if err != nil {
+		return nil, nil, qerrors.Propagate("ReadParquet", err)
+	}
+
+	builders := make([]*columnBuilder, len(leaves))
+	for i, l := range leaves {
+		builders[i] = newColumnBuilder(l)
+	}
+
+	for _, rowGroup := range metaData.structList(fileMetaDataRowGroups) {
+		chunks := rowGroup.structList(rowGroupColumns)
+		if len(chunks) != len(leaves) {
+			return nil, nil, qerrors.New("ReadParquet", "unexpected number of column chunks: %d", len(chunks))
+		}
+
+		numRows := rowGroup.int(rowGroupNumRows, -1)
+		if numRows < 0 || numRows > math.MaxInt32 {
+			return nil, nil, qerrors.New("ReadParquet", "invalid number of rows in row group: %d", numRows)
+		}
+
+		for i, chunk := range chunks {
+			if !keep[i] {
+				continue
+			}
+
+			chunkMetaData, ok := chunk.structField(columnChunkMetaData)
+			if !ok {
+				return nil, nil, qerrors.New("ReadParquet", "missing column metadata, column: %s", leaves[i].name)
+			}
+
+			if typ := chunkMetaData.int(columnMetaDataType, -1); typ != leaves[i].typ {
+				return nil, nil, qerrors.New("ReadParquet", "unexpected column chunk type %d, column: %s", typ, leaves[i].name)
+			}
+
+			// Columns are flat, there is one value, or null, for each row
+			if numValues := chunkMetaData.int(columnMetaDataNumValues, -1); numValues != numRows {
+				return nil, nil, qerrors.New("ReadParquet", "%d values in column chunk, expected %d, column: %s", numValues, numRows, leaves[i].name)
+			}
+
+			start := chunkMetaData.int(columnMetaDataDataPageOffset, 0)
+			if dictOffset := chunkMetaData.int(columnMetaDataDictionaryPageOffset, 0); dictOffset > 0 && dictOffset < start {
+				start = dictOffset
+			}
+
+			length := chunkMetaData.int(columnMetaDataTotalCompressedSize, 0)
+			if start < 0 || length < 0 || start+length > size {
+				return nil, nil, qerrors.New("ReadParquet", "column chunk out of range, column: %s", leaves[i].name)
+			}
+
+			chunkBuf := make([]byte, length)
+			if _, err := r.ReadAt(chunkBuf, start); err != nil {
+				return nil, nil, qerrors.Propagate("ReadParquet", err)
+			}
+
+			if err := builders[i].readChunk(chunkBuf, chunkMetaData); err != nil {
+				return nil, nil, qerrors.Propagate("ReadParquet", err)
+			}
+		}
+	}
+
+	result = make(map[string]types.DataSlice, len(leaves))
+	columns = make([]string, 0, len(leaves))
+	for i, b := range builders {
+		if !keep[i] {
+			continue
+		}
+
+		if _, ok := result[b.leaf.name]; ok {
+			return nil, nil, qerrors.New("ReadParquet", "duplicate column name: %s", b.leaf.name)
+		}
+
+		result[b.leaf.name] = b.toData()
+		columns = append(columns, b.leaf.name)
+	}
+
+	return result, columns, nil
+}
@@ -0,0 +1,199 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+/*
+This file contains an implementation of the snappy block format, as used for Parquet
+pages. See https://github.com/google/snappy/blob/master/format_description.txt
+
+The encoder uses a simple hash table of four byte sequences to find matches. It does not
+compress as well as the reference implementation but the output is valid snappy.
+*/
+
+// Element tags
+const (
+	tagLiteral = 0
+	tagCopy1   = 1
+	tagCopy2   = 2
+	tagCopy4   = 3
+)
+
+const (
+	snappyMinMatch = 4
+	snappyHashBits = 14
+)
+
+func snappyDecode(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > math.MaxInt32 {
+		return nil, qerrors.New("snappy decode", "invalid length")
+	}
+
+	// Each element expands to at most 64 bytes, don't trust the length of malformed input
+	capacity := int(length)
+	if maxLen := 64 * len(src); capacity > maxLen {
+		capacity = maxLen
+	}
+
+	dst := make([]byte, 0, capacity)
+	for pos := n; pos < len(src); {
+		tag := src[pos]
+		var elemLen, offset int
+		switch tag & 0x03 {
+		case tagLiteral:
+			elemLen = int(tag >> 2)
+			pos++
+			if elemLen >= 60 {
+				byteCount := elemLen - 59
+				if pos+byteCount > len(src) {
+					return nil, qerrors.New("snappy decode", "literal length out of range")
+				}
+
+				elemLen = 0
+				for i := 0; i < byteCount; i++ {
+					elemLen |= int(src[pos+i]) << (8 * uint(i))
+				}
+				pos += byteCount
+			}
+			elemLen++
+
+			if elemLen <= 0 || pos+elemLen > len(src) || len(dst)+elemLen > int(length) {
+				return nil, qerrors.New("snappy decode", "literal out of range")
+			}
+
+			dst = append(dst, src[pos:pos+elemLen]...)
+			pos += elemLen
+			continue
+		case tagCopy1:
+			if pos+2 > len(src) {
+				return nil, qerrors.New("snappy decode", "copy out of range")
+			}
+			elemLen = 4 + int(tag>>2)&0x07
+			offset = int(tag&0xE0)<<3 | int(src[pos+1])
+			pos += 2
+		case tagCopy2:
+			if pos+3 > len(src) {
+				return nil, qerrors.New("snappy decode", "copy out of range")
+			}
+			elemLen = 1 + int(tag>>2)
+			offset = int(le.Uint16(src[pos+1:]))
+			pos += 3
+		case tagCopy4:
+			if pos+5 > len(src) {
+				return nil, qerrors.New("snappy decode", "copy out of range")
+			}
+			elemLen = 1 + int(tag>>2)
+			offset = int(le.Uint32(src[pos+1:]))
+			pos += 5
+		}
+
+		if offset <= 0 || offset > len(dst) || len(dst)+elemLen > int(length) {
+			return nil, qerrors.New("snappy decode", "invalid copy offset: %d", offset)
+		}
+
+		// The copy may overlap with the data being written, copy byte by byte
+		start := len(dst) - offset
+		for i := 0; i < elemLen; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != int(length) {
+		return nil, qerrors.New("snappy decode", "unexpected length %d, expected %d", len(dst), length)
+	}
+
+	return dst, nil
+}
+
+func snappyHash(u uint32) uint32 {
+	return (u * 0x1E35A7BD) >> (32 - snappyHashBits)
+}
+
+func appendLiteral(dst, lit []byte) []byte {
+	for len(lit) > 0 {
+		// Long literals are split to keep the length within three bytes
+		n := len(lit)
+		if n > 1<<24 {
+			n = 1 << 24
+		}
+
+		switch l := n - 1; {
+		case l < 60:
+			dst = append(dst, byte(l)<<2|tagLiteral)
+		case l < 1<<8:
+			dst = append(dst, 60<<2|tagLiteral, byte(l))
+		case l < 1<<16:
+			dst = append(dst, 61<<2|tagLiteral, byte(l), byte(l>>8))
+		default:
+			dst = append(dst, 62<<2|tagLiteral, byte(l), byte(l>>8), byte(l>>16))
+		}
+
+		dst = append(dst, lit[:n]...)
+		lit = lit[n:]
+	}
+
+	return dst
+}
+
+func appendCopy(dst []byte, offset, length int) []byte {
+	for length > 0 {
+		// At most 64 bytes per copy, avoid leaving less than the min match length
+		n := length
+		if n > 64 {
+			n = 64
+			if length-n < snappyMinMatch {
+				n = 60
+			}
+		}
+
+		switch {
+		case n < 12 && offset < 2048:
+			dst = append(dst, byte(offset>>8)<<5|byte(n-4)<<2|tagCopy1, byte(offset))
+		case offset < 1<<16:
+			dst = append(dst, byte(n-1)<<2|tagCopy2, byte(offset), byte(offset>>8))
+		default:
+			dst = append(dst, byte(n-1)<<2|tagCopy4, byte(offset), byte(offset>>8), byte(offset>>16), byte(offset>>24))
+		}
+
+		length -= n
+	}
+
+	return dst
+}
+
+func snappyEncode(src []byte) []byte {
+	dst := appendVarint(make([]byte, 0, len(src)/2+16), uint64(len(src)))
+	var table [1 << snappyHashBits]int32
+	for i := range table {
+		table[i] = -1
+	}
+
+	litStart := 0
+	for pos := 0; pos+snappyMinMatch <= len(src); {
+		u := le.Uint32(src[pos:])
+		h := snappyHash(u)
+		candidate := int(table[h])
+		table[h] = int32(pos)
+		if candidate < 0 || le.Uint32(src[candidate:]) != u {
+			pos++
+			continue
+		}
+
+		length := snappyMinMatch
+		for pos+length < len(src) && src[candidate+length] == src[pos+length] {
+			length++
+		}
+
+		dst = appendLiteral(dst, src[litStart:pos])
+		dst = appendCopy(dst, pos-candidate, length)
+		pos += length
+		litStart = pos
+	}
+
+	return appendLiteral(dst, src[litStart:])
+}
@@ -0,0 +1,361 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+/*
+This file contains a minimal implementation of the thrift compact protocol used to
+encode Parquet metadata.
+
+Structs are represented by thriftStruct, a list of fields identified by the field ids
+found in parquet.thrift, both when reading and writing. When decoding all integer types
+become int64, binary fields []byte, lists []interface{} and structs thriftStruct.
+When encoding the thrift type of a field is given by the Go type of its value.
+*/
+
+var le = binary.LittleEndian
+
+// malformedError is used to abort parsing of malformed input. It is
+// recovered at the top level of the reader and turned into a regular error.
+type malformedError struct {
+	reason string
+}
+
+// Compact protocol types
+const (
+	ctStop         = 0
+	ctBooleanTrue  = 1
+	ctBooleanFalse = 2
+	ctByte         = 3
+	ctI16          = 4
+	ctI32          = 5
+	ctI64          = 6
+	ctDouble       = 7
+	ctBinary       = 8
+	ctList         = 9
+	ctSet          = 10
+	ctMap          = 11
+	ctStruct       = 12
+)
+
+// Max nesting of structs and lists accepted when decoding.
+const maxThriftDepth = 32
+
+type thriftField struct {
+	id    int16
+	value interface{}
+}
+
+type thriftStruct []thriftField
+
+// thriftList is a list to be encoded, all values must be of the type given by elemType.
+type thriftList struct {
+	elemType byte
+	values   []interface{}
+}
+
+func (s thriftStruct) field(id int16) (interface{}, bool) {
+	for _, f := range s {
+		if f.id == id {
+			return f.value, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s thriftStruct) int(id int16, defaultVal int64) int64 {
+	if v, ok := s.field(id); ok {
+		if i, ok := v.(int64); ok {
+			return i
+		}
+	}
+
+	return defaultVal
+}
+
+func (s thriftStruct) bool(id int16, defaultVal bool) bool {
+	if v, ok := s.field(id); ok {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	}
+
+	return defaultVal
+}
+
+func (s thriftStruct) string(id int16) string {
+	if v, ok := s.field(id); ok {
+		if b, ok := v.([]byte); ok {
+			return string(b)
+		}
+	}
+
+	return ""
+}
+
+func (s thriftStruct) structField(id int16) (thriftStruct, bool) {
+	if v, ok := s.field(id); ok {
+		st, ok := v.(thriftStruct)
+		return st, ok
+	}
+
+	return nil, false
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	if v, ok := s.field(id); ok {
+		if l, ok := v.([]interface{}); ok {
+			return l
+		}
+	}
+
+	return nil
+}
+
+func (s thriftStruct) structList(id int16) []thriftStruct {
+	values := s.list(id)
+	result := make([]thriftStruct, 0, len(values))
+	for _, v := range values {
+		if st, ok := v.(thriftStruct); ok {
+			result = append(result, st)
+		}
+	}
+
+	return result
+}
+
+////////////////
+/// Decoding ///
+////////////////
+
+type thriftDecoder struct {
+	buf []byte
+	pos int
+}
+
+// decodeStruct decodes a struct from the start of buf. The number of bytes consumed is also returned.
+func decodeStruct(buf []byte) (thriftStruct, int) {
+	d := &thriftDecoder{buf: buf}
+	s := d.readStruct(0)
+	return s, d.pos
+}
+
+func (d *thriftDecoder) bytes(n int) []byte {
+	if n < 0 || n > len(d.buf)-d.pos {
+		panic(malformedError{reason: "thrift data out of range"})
+	}
+
+	result := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return result
+}
+
+func (d *thriftDecoder) byte() byte {
+	return d.bytes(1)[0]
+}
+
+func (d *thriftDecoder) varint() uint64 {
+	var result uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b := d.byte()
+		result |= uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return result
+		}
+	}
+
+	panic(malformedError{reason: "thrift varint too long"})
+}
+
+func (d *thriftDecoder) zigzag() int64 {
+	v := d.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *thriftDecoder) readStruct(depth int) thriftStruct {
+	if depth > maxThriftDepth {
+		panic(malformedError{reason: "thrift data nested too deep"})
+	}
+
+	var result thriftStruct
+	var lastID int16
+	for {
+		header := d.byte()
+		typ := header & 0x0F
+		if typ == ctStop {
+			return result
+		}
+
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(d.zigzag())
+		}
+		lastID = id
+
+		var value interface{}
+		switch typ {
+		case ctBooleanTrue:
+			value = true
+		case ctBooleanFalse:
+			value = false
+		default:
+			value = d.readValue(typ, depth)
+		}
+
+		result = append(result, thriftField{id: id, value: value})
+	}
+}
+
+func (d *thriftDecoder) readValue(typ byte, depth int) interface{} {
+	switch typ {
+	case ctBooleanTrue, ctBooleanFalse:
+		// Booleans in lists are encoded as a single byte
+		return d.byte() == ctBooleanTrue
+	case ctByte:
+		return int64(int8(d.byte()))
+	case ctI16, ctI32, ctI64:
+		return d.zigzag()
+	case ctDouble:
+		return math.Float64frombits(le.Uint64(d.bytes(8)))
+	case ctBinary:
+		return d.bytes(int(d.varint()))
+	case ctList, ctSet:
+		header := d.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(d.varint())
+		}
+
+		if size > len(d.buf)-d.pos {
+			// Every element takes at least one byte
+			panic(malformedError{reason: "thrift list size out of range"})
+		}
+
+		values := make([]interface{}, size)
+		for i := range values {
+			values[i] = d.readValue(header&0x0F, depth+1)
+		}
+		return values
+	case ctMap:
+		size := int(d.varint())
+		if size == 0 {
+			return nil
+		}
+
+		types := d.byte()
+		for i := 0; i < size; i++ {
+			d.readValue(types>>4, depth+1)
+			d.readValue(types&0x0F, depth+1)
+		}
+		return nil
+	case ctStruct:
+		return d.readStruct(depth + 1)
+	default:
+		panic(malformedError{reason: "unknown thrift type"})
+	}
+}
+
+////////////////
+/// Encoding ///
+////////////////
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(buf, byte(v))
+}
+
+func appendZigzag(buf []byte, v int64) []byte {
+	return appendVarint(buf, uint64((v<<1)^(v>>63)))
+}
+
+func compactType(value interface{}) byte {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return ctBooleanTrue
+		}
+		return ctBooleanFalse
+	case int32:
+		return ctI32
+	case int64:
+		return ctI64
+	case float64:
+		return ctDouble
+	case string, []byte:
+		return ctBinary
+	case thriftList:
+		return ctList
+	case thriftStruct:
+		return ctStruct
+	default:
+		panic("unsupported thrift value")
+	}
+}
+
+// encode appends the compact protocol encoding of s to buf.
+func (s thriftStruct) encode(buf []byte) []byte {
+	var lastID int16
+	for _, f := range s {
+		typ := compactType(f.value)
+		if delta := f.id - lastID; delta > 0 && delta <= 15 {
+			buf = append(buf, byte(delta)<<4|typ)
+		} else {
+			buf = append(buf, typ)
+			buf = appendZigzag(buf, int64(f.id))
+		}
+		lastID = f.id
+
+		if _, ok := f.value.(bool); !ok {
+			buf = appendValue(buf, f.value)
+		}
+	}
+
+	return append(buf, ctStop)
+}
+
+func appendValue(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return append(buf, ctBooleanTrue)
+		}
+		return append(buf, ctBooleanFalse)
+	case int32:
+		return appendZigzag(buf, int64(v))
+	case int64:
+		return appendZigzag(buf, v)
+	case float64:
+		var b [8]byte
+		le.PutUint64(b[:], math.Float64bits(v))
+		return append(buf, b[:]...)
+	case string:
+		buf = appendVarint(buf, uint64(len(v)))
+		return append(buf, v...)
+	case []byte:
+		buf = appendVarint(buf, uint64(len(v)))
+		return append(buf, v...)
+	case thriftList:
+		if len(v.values) < 15 {
+			buf = append(buf, byte(len(v.values))<<4|v.elemType)
+		} else {
+			buf = append(buf, 0xF0|v.elemType)
+			buf = appendVarint(buf, uint64(len(v.values)))
+		}
+
+		for _, x := range v.values {
+			buf = appendValue(buf, x)
+		}
+		return buf
+	case thriftStruct:
+		return v.encode(buf)
+	default:
+		panic("unsupported thrift value")
+	}
+}
@@ -0,0 +1,450 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/ncolumn"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+// Compression codec names accepted in Config.
+const (
+	Uncompressed = "uncompressed"
+	Snappy       = "snappy"
+	Gzip         = "gzip"
+)
+
+// Max number of rows in each data page.
+const maxPageRows = 1 << 16
+
+func codecFor(name string) (int64, error) {
+	switch name {
+	case Uncompressed:
+		return codecUncompressed, nil
+	case Snappy, "":
+		return codecSnappy, nil
+	case Gzip:
+		return codecGzip, nil
+	default:
+		return 0, qerrors.New("codec", "unknown compression codec: %s", name)
+	}
+}
+
+func compress(codec int64, src []byte) ([]byte, error) {
+	switch codec {
+	case codecSnappy:
+		return snappyEncode(src), nil
+	case codecGzip:
+		buf := &bytes.Buffer{}
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(src); err != nil {
+			return nil, err
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return src, nil
+	}
+}
+
+// countingWriter keeps track of the offset in the file being written.
+type countingWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	return n, err
+}
+
+// columnSource describes how the values of a column are written.
+type columnSource struct {
+	typ           int32
+	logicalType   thriftStruct
+	convertedType int32
+	isNull        func(i int) bool
+
+	// encode appends the non null values in rows [from, to) using the encoding
+	// of the data pages.
+	encode func(buf []byte, from, to int) []byte
+
+	// Only set for dictionary encoded columns
+	dictionary []string
+}
+
+func intSource(view icolumn.View) columnSource {
+	return columnSource{
+		typ:           typeInt64,
+		convertedType: -1,
+		isNull:        view.IsNull,
+		encode: func(buf []byte, from, to int) []byte {
+			var b [8]byte
+			for i := from; i < to; i++ {
+				if !view.IsNull(i) {
+					le.PutUint64(b[:], uint64(view.ItemAt(i)))
+					buf = append(buf, b[:]...)
+				}
+			}
+			return buf
+		}}
+}
+
+func timeSource(view tcolumn.View) columnSource {
+	return columnSource{
+		typ: typeInt64,
+		logicalType: thriftStruct{{id: logicalTypeTimestamp, value: thriftStruct{
+			{id: timestampTypeIsAdjustedToUTC, value: true},
+			{id: timestampTypeUnit, value: thriftStruct{{id: timeUnitNanos, value: thriftStruct{}}}}}}},
+		convertedType: -1,
+		isNull:        view.IsNull,
+		encode: func(buf []byte, from, to int) []byte {
+			var b [8]byte
+			for i := from; i < to; i++ {
+				if !view.IsNull(i) {
+					le.PutUint64(b[:], uint64(view.ItemAt(i).UnixNano()))
+					buf = append(buf, b[:]...)
+				}
+			}
+			return buf
+		}}
+}
+
+func floatSource(data []float64) columnSource {
+	return columnSource{
+		typ:           typeDouble,
+		convertedType: -1,
+		isNull:        func(i int) bool { return math.IsNaN(data[i]) },
+		encode: func(buf []byte, from, to int) []byte {
+			var b [8]byte
+			for _, x := range data[from:to] {
+				if !math.IsNaN(x) {
+					le.PutUint64(b[:], math.Float64bits(x))
+					buf = append(buf, b[:]...)
+				}
+			}
+			return buf
+		}}
+}
+
+func boolSource(view bcolumn.View) columnSource {
+	return columnSource{
+		typ:           typeBoolean,
+		convertedType: -1,
+		isNull:        view.IsNull,
+		encode: func(buf []byte, from, to int) []byte {
+			// Plain encoded booleans are bit packed
+			var acc byte
+			count := 0
+			for i := from; i < to; i++ {
+				if view.IsNull(i) {
+					continue
+				}
+
+				if view.ItemAt(i) {
+					acc |= 1 << uint(count)
+				}
+
+				count++
+				if count == 8 {
+					buf = append(buf, acc)
+					acc, count = 0, 0
+				}
+			}
+
+			if count > 0 {
+				buf = append(buf, acc)
+			}
+			return buf
+		}}
+}
+
+func appendByteArray(buf []byte, s string) []byte {
+	var b [4]byte
+	le.PutUint32(b[:], uint32(len(s)))
+	buf = append(buf, b[:]...)
+	return append(buf, s...)
+}
+
+func stringSource(data []*string) columnSource {
+	return columnSource{
+		typ:           typeByteArray,
+		logicalType:   thriftStruct{{id: logicalTypeString, value: thriftStruct{}}},
+		convertedType: convertedUTF8,
+		isNull:        func(i int) bool { return data[i] == nil },
+		encode: func(buf []byte, from, to int) []byte {
+			for _, s := range data[from:to] {
+				if s != nil {
+					buf = appendByteArray(buf, *s)
+				}
+			}
+			return buf
+		}}
+}
+
+func enumSource(c ecolumn.Column, ix index.Int) columnSource {
+	values := c.Values()
+	valToCode := make(map[string]uint32, len(values))
+	for i, v := range values {
+		valToCode[v] = uint32(i)
+	}
+
+	bitWidth := 0
+	if len(values) > 0 {
+		bitWidth = bitsNeeded(uint32(len(values) - 1))
+	}
+
+	data := c.View(ix).Slice()
+	return columnSource{
+		typ:           typeByteArray,
+		logicalType:   thriftStruct{{id: logicalTypeString, value: thriftStruct{}}},
+		convertedType: convertedUTF8,
+		isNull:        func(i int) bool { return data[i] == nil },
+		encode: func(buf []byte, from, to int) []byte {
+			codes := make([]uint32, 0, to-from)
+			for _, s := range data[from:to] {
+				if s != nil {
+					codes = append(codes, valToCode[*s])
+				}
+			}
+
+			buf = append(buf, byte(bitWidth))
+			return encodeHybrid(buf, codes, bitWidth)
+		},
+		dictionary: values}
+}
+
+func nullSource() columnSource {
+	return columnSource{
+		typ:           typeInt32,
+		logicalType:   thriftStruct{{id: logicalTypeUnknown, value: thriftStruct{}}},
+		convertedType: -1,
+		isNull:        func(i int) bool { return true },
+		encode: func(buf []byte, from, to int) []byte {
+			return buf
+		}}
+}
+
+func (s columnSource) schemaElement(name string) thriftStruct {
+	element := thriftStruct{
+		{id: schemaElementType, value: s.typ},
+		{id: schemaElementRepetitionType, value: int32(repetitionOptional)},
+		{id: schemaElementName, value: name}}
+
+	if s.convertedType >= 0 {
+		element = append(element, thriftField{id: schemaElementConvertedType, value: s.convertedType})
+	}
+
+	if s.logicalType != nil {
+		element = append(element, thriftField{id: schemaElementLogicalType, value: s.logicalType})
+	}
+
+	return element
+}
+
+// chunkWriter writes the pages of a column chunk.
+type chunkWriter struct {
+	w                *countingWriter
+	codec            int64
+	compressedSize   int64
+	uncompressedSize int64
+}
+
+func (cw *chunkWriter) writePage(pageType int32, pageHeaderID int16, pageHeader thriftStruct, data []byte) error {
+	compressed, err := compress(cw.codec, data)
+	if err != nil {
+		return err
+	}
+
+	header := thriftStruct{
+		{id: pageHeaderType, value: pageType},
+		{id: pageHeaderUncompressedPageSize, value: int32(len(data))},
+		{id: pageHeaderCompressedPageSize, value: int32(len(compressed))},
+		{id: pageHeaderID, value: pageHeader}}
+
+	headerBytes := header.encode(nil)
+	cw.uncompressedSize += int64(len(headerBytes) + len(data))
+	cw.compressedSize += int64(len(headerBytes) + len(compressed))
+	if _, err := cw.w.Write(headerBytes); err != nil {
+		return err
+	}
+
+	_, err = cw.w.Write(compressed)
+	return err
+}
+
+// writeChunk writes all rows of a column as one column chunk and returns the column chunk metadata.
+func writeChunk(w *countingWriter, codec int64, name string, s columnSource, rowCount int) (thriftStruct, error) {
+	cw := &chunkWriter{w: w, codec: codec}
+	encodings := []interface{}{int32(encodingPlain), int32(encodingRLE)}
+	startOffset := w.offset
+	dataEncoding := int32(encodingPlain)
+	if s.dictionary != nil {
+		var dict []byte
+		for _, v := range s.dictionary {
+			dict = appendByteArray(dict, v)
+		}
+
+		dictHeader := thriftStruct{
+			{id: dictionaryPageHeaderNumValues, value: int32(len(s.dictionary))},
+			{id: dictionaryPageHeaderEncoding, value: int32(encodingPlain)}}
+		if err := cw.writePage(pageDictionary, pageHeaderDictionaryPageHeader, dictHeader, dict); err != nil {
+			return nil, err
+		}
+
+		dataEncoding = encodingRLEDictionary
+		encodings = append(encodings, int32(encodingRLEDictionary))
+	}
+
+	dataOffset := w.offset
+	levels := make([]uint32, 0, maxPageRows)
+	for from := 0; from < rowCount; from += maxPageRows {
+		to := from + maxPageRows
+		if to > rowCount {
+			to = rowCount
+		}
+
+		levels = levels[:0]
+		for i := from; i < to; i++ {
+			if s.isNull(i) {
+				levels = append(levels, 0)
+			} else {
+				levels = append(levels, 1)
+			}
+		}
+
+		// The definition levels are prefixed by their length
+		page := encodeHybrid(make([]byte, 4), levels, 1)
+		le.PutUint32(page, uint32(len(page)-4))
+		page = s.encode(page, from, to)
+
+		dataHeader := thriftStruct{
+			{id: dataPageHeaderNumValues, value: int32(to - from)},
+			{id: dataPageHeaderEncoding, value: dataEncoding},
+			{id: dataPageHeaderDefinitionLevelEncoding, value: int32(encodingRLE)},
+			{id: dataPageHeaderRepetitionLevelEncoding, value: int32(encodingRLE)}}
+		if err := cw.writePage(pageData, pageHeaderDataPageHeader, dataHeader, page); err != nil {
+			return nil, err
+		}
+	}
+
+	metaData := thriftStruct{
+		{id: columnMetaDataType, value: s.typ},
+		{id: columnMetaDataEncodings, value: thriftList{elemType: ctI32, values: encodings}},
+		{id: columnMetaDataPathInSchema, value: thriftList{elemType: ctBinary, values: []interface{}{name}}},
+		{id: columnMetaDataCodec, value: int32(codec)},
+		{id: columnMetaDataNumValues, value: int64(rowCount)},
+		{id: columnMetaDataTotalUncompressedSize, value: cw.uncompressedSize},
+		{id: columnMetaDataTotalCompressedSize, value: cw.compressedSize},
+		{id: columnMetaDataDataPageOffset, value: dataOffset}}
+
+	if s.dictionary != nil {
+		metaData = append(metaData, thriftField{id: columnMetaDataDictionaryPageOffset, value: startOffset})
+	}
+
+	return thriftStruct{
+		{id: columnChunkFileOffset, value: startOffset},
+		{id: columnChunkMetaData, value: metaData}}, nil
+}
+
+// WriteParquet writes the columns, in the order given by ix, to w as a Parquet file.
+// All data is written as one row group. Enum columns are written dictionary encoded,
+// time columns as nanosecond timestamps adjusted to UTC.
+func WriteParquet(w io.Writer, names []string, columns []column.Column, ix index.Int, conf Config) error {
+	codec, err := codecFor(conf.Compression)
+	if err != nil {
+		return qerrors.Propagate("WriteParquet", err)
+	}
+
+	sources := make([]columnSource, len(columns))
+	for i, col := range columns {
+		switch c := col.(type) {
+		case icolumn.Column:
+			sources[i] = intSource(c.View(ix))
+		case fcolumn.Column:
+			sources[i] = floatSource(c.View(ix).Slice())
+		case bcolumn.Column:
+			sources[i] = boolSource(c.View(ix))
+		case scolumn.Column:
+			sources[i] = stringSource(c.View(ix).Slice())
+		case ecolumn.Column:
+			sources[i] = enumSource(c, ix)
+		case tcolumn.Column:
+			sources[i] = timeSource(c.View(ix))
+		case ncolumn.Column:
+			sources[i] = nullSource()
+		default:
+			return qerrors.New("WriteParquet", "unsupported column type: %s", reflect.TypeOf(col))
+		}
+	}
+
+	cw := &countingWriter{w: w}
+	if _, err := cw.Write([]byte(magic)); err != nil {
+		return qerrors.Propagate("WriteParquet", err)
+	}
+
+	schema := []interface{}{thriftStruct{
+		{id: schemaElementName, value: "schema"},
+		{id: schemaElementNumChildren, value: int32(len(columns))}}}
+
+	chunks := make([]interface{}, len(columns))
+	totalSize := int64(0)
+	for i, s := range sources {
+		schema = append(schema, s.schemaElement(names[i]))
+		if len(ix) == 0 {
+			continue
+		}
+
+		chunk, err := writeChunk(cw, codec, names[i], s, len(ix))
+		if err != nil {
+			return qerrors.Propagate("WriteParquet", err)
+		}
+
+		chunks[i] = chunk
+		metaData, _ := chunk.structField(columnChunkMetaData)
+		totalSize += metaData.int(columnMetaDataTotalUncompressedSize, 0)
+	}
+
+	// Files without rows contain no row groups
+	var rowGroups []interface{}
+	if len(ix) > 0 {
+		rowGroups = append(rowGroups, thriftStruct{
+			{id: rowGroupColumns, value: thriftList{elemType: ctStruct, values: chunks}},
+			{id: rowGroupTotalByteSize, value: totalSize},
+			{id: rowGroupNumRows, value: int64(len(ix))}})
+	}
+
+	metaData := thriftStruct{
+		{id: fileMetaDataVersion, value: int32(1)},
+		{id: fileMetaDataSchema, value: thriftList{elemType: ctStruct, values: schema}},
+		{id: fileMetaDataNumRows, value: int64(len(ix))},
+		{id: fileMetaDataRowGroups, value: thriftList{elemType: ctStruct, values: rowGroups}},
+		{id: fileMetaDataCreatedBy, value: "qframe"}}
+
+	footer := metaData.encode(nil)
+	footer = append(footer, 0, 0, 0, 0)
+	le.PutUint32(footer[len(footer)-4:], uint32(len(footer)-4))
+	footer = append(footer, magic...)
+	if _, err := cw.Write(footer); err != nil {
+		return qerrors.Propagate("WriteParquet", err)
+	}
+
+	return nil
+}
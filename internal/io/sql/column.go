@@ -3,18 +3,29 @@ package sql
 import (
 	"math"
 	"reflect"
+	"strconv"
+	"time"
 
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/bitmap"
+	"github.com/yistabraq/qframe/internal/icolumn"
 	"github.com/yistabraq/qframe/internal/math/float"
+	"github.com/yistabraq/qframe/internal/ncolumn"
 
 	"github.com/yistabraq/qframe/qerrors"
 )
 
+// timeKind is the kind of time columns.
+const timeKind = reflect.Struct
+
 // Column implements the sql.Scanner interface
 // and allows arbitrary data types to be loaded from
 // any database/sql/driver into a QFrame.
 type Column struct {
 	kind  reflect.Kind
 	nulls int
+	// positions of NULL values in int and bool columns
+	nullPositions []uint32
 	// pointer to the data slice which
 	// contains the inferred data type
 	ptr  interface{}
@@ -23,6 +34,7 @@ type Column struct {
 		Floats  []float64
 		Bools   []bool
 		Strings []*string
+		Times   []*time.Time
 	}
 	coerce    func(t interface{}) error
 	precision int
@@ -45,6 +57,14 @@ func (c *Column) Null() error {
 		c.data.Floats = append(c.data.Floats, math.NaN())
 	case reflect.String:
 		c.data.Strings = append(c.data.Strings, nil)
+	case timeKind:
+		c.data.Times = append(c.data.Times, nil)
+	case reflect.Int:
+		c.nullPositions = append(c.nullPositions, uint32(len(c.data.Ints)))
+		c.data.Ints = append(c.data.Ints, 0)
+	case reflect.Bool:
+		c.nullPositions = append(c.nullPositions, uint32(len(c.data.Bools)))
+		c.data.Bools = append(c.data.Bools, false)
 	default:
 		return qerrors.New("Column Null", "non-nullable type: %s", c.kind)
 	}
@@ -56,6 +76,11 @@ func (c *Column) Int(i int) {
 	if c.ptr == nil {
 		c.kind = reflect.Int
 		c.ptr = &c.data.Ints
+		// add any NULL ints previously scanned
+		for ; c.nulls > 0; c.nulls-- {
+			c.nullPositions = append(c.nullPositions, uint32(len(c.data.Ints)))
+			c.data.Ints = append(c.data.Ints, 0)
+		}
 	}
 	c.data.Ints = append(c.data.Ints, i)
 }
@@ -100,15 +125,58 @@ func (c *Column) Bool(b bool) {
 	if c.ptr == nil {
 		c.kind = reflect.Bool
 		c.ptr = &c.data.Bools
+		// add any NULL bools previously scanned
+		for ; c.nulls > 0; c.nulls-- {
+			c.nullPositions = append(c.nullPositions, uint32(len(c.data.Bools)))
+			c.data.Bools = append(c.data.Bools, false)
+		}
 	}
 	c.data.Bools = append(c.data.Bools, b)
 }
 
+// Time adds a new time to the underlying data slice
+func (c *Column) Time(t time.Time) {
+	if c.ptr == nil {
+		c.kind = timeKind
+		c.ptr = &c.data.Times
+		// add any NULL times previously scanned
+		for ; c.nulls > 0; c.nulls-- {
+			c.data.Times = append(c.data.Times, nil)
+		}
+	}
+	c.data.Times = append(c.data.Times, &t)
+}
+
+// newTypedColumn returns a column of the given kind,
+// decided up front rather than from the scanned values.
+func newTypedColumn(kind reflect.Kind) *Column {
+	c := &Column{}
+	switch kind {
+	case reflect.Int:
+		c.ptr = &c.data.Ints
+	case reflect.Float64:
+		c.ptr = &c.data.Floats
+	case reflect.Bool:
+		c.ptr = &c.data.Bools
+	case reflect.String:
+		c.ptr = &c.data.Strings
+	case timeKind:
+		c.ptr = &c.data.Times
+	default:
+		return c
+	}
+	c.kind = kind
+	return c
+}
+
 // Scan implements the sql.Scanner interface
 func (c *Column) Scan(t interface{}) error {
-	if c.coerce != nil {
+	if c.coerce != nil && t != nil {
 		return c.coerce(t)
 	}
+	if c.kind != reflect.Invalid && t != nil {
+		return c.scanAs(t)
+	}
 	switch v := t.(type) {
 	case bool:
 		c.Bool(v)
@@ -120,6 +188,8 @@ func (c *Column) Scan(t interface{}) error {
 		c.String(string(v))
 	case float64:
 		c.Float(v)
+	case time.Time:
+		c.Time(v)
 	case nil:
 		err := c.Null()
 		if err != nil {
@@ -132,11 +202,134 @@ func (c *Column) Scan(t interface{}) error {
 	return nil
 }
 
+// Layouts tried when parsing times stored as text
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+// scanAs converts t to the kind of the column,
+// used when the kind is known from the column type
+// or previously scanned values.
+func (c *Column) scanAs(t interface{}) error {
+	if b, ok := t.([]uint8); ok {
+		t = string(b)
+	}
+	var err error
+	switch c.kind {
+	case reflect.Int:
+		switch v := t.(type) {
+		case int64:
+			c.Int(int(v))
+			return nil
+		case string:
+			var i int
+			if i, err = strconv.Atoi(v); err == nil {
+				c.Int(i)
+				return nil
+			}
+		}
+	case reflect.Float64:
+		switch v := t.(type) {
+		case float64:
+			c.Float(v)
+			return nil
+		case int64:
+			c.Float(float64(v))
+			return nil
+		case string:
+			var f float64
+			if f, err = strconv.ParseFloat(v, 64); err == nil {
+				c.Float(f)
+				return nil
+			}
+		}
+	case reflect.Bool:
+		switch v := t.(type) {
+		case bool:
+			c.Bool(v)
+			return nil
+		case int64:
+			c.Bool(v != 0)
+			return nil
+		case string:
+			var b bool
+			if b, err = strconv.ParseBool(v); err == nil {
+				c.Bool(b)
+				return nil
+			}
+		}
+	case reflect.String:
+		switch v := t.(type) {
+		case string:
+			c.String(v)
+			return nil
+		case int64:
+			c.String(strconv.FormatInt(v, 10))
+			return nil
+		case float64:
+			c.String(strconv.FormatFloat(v, 'f', -1, 64))
+			return nil
+		case bool:
+			c.String(strconv.FormatBool(v))
+			return nil
+		case time.Time:
+			c.String(v.Format(time.RFC3339Nano))
+			return nil
+		}
+	case timeKind:
+		switch v := t.(type) {
+		case time.Time:
+			c.Time(v)
+			return nil
+		case string:
+			for _, layout := range timeLayouts {
+				var tm time.Time
+				if tm, err = time.Parse(layout, v); err == nil {
+					c.Time(tm)
+					return nil
+				}
+			}
+		}
+	}
+	return qerrors.New("Column Scan", "cannot scan %T value %v into %s column", t, t, kindName(c.kind))
+}
+
+func kindName(kind reflect.Kind) string {
+	if kind == timeKind {
+		return "time"
+	}
+	return kind.String()
+}
+
 // Data returns the underlying data slice
 func (c *Column) Data() interface{} {
 	if c.ptr == nil {
-		return nil
+		// Without any values, or a known kind, the
+		// type cannot be decided.
+		if c.nulls > 0 {
+			return make([]*string, c.nulls)
+		}
+		return ncolumn.Column{}
+	}
+
+	// Int and bool columns containing NULL values are returned as columns
+	// with a validity bitmap.
+	if len(c.nullPositions) > 0 {
+		switch c.kind {
+		case reflect.Int:
+			return icolumn.NewNullable(c.data.Ints, c.validity(len(c.data.Ints)))
+		case reflect.Bool:
+			return bcolumn.NewNullable(c.data.Bools, c.validity(len(c.data.Bools)))
+		}
 	}
+
 	// *[]<T> -> []<T>
 	return reflect.ValueOf(c.ptr).Elem().Interface()
 }
+
+func (c *Column) validity(size int) bitmap.Bitmap {
+	valid := bitmap.New(size)
+	for _, i := range c.nullPositions {
+		valid.SetNull(i)
+	}
+
+	return valid
+}
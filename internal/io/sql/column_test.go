@@ -2,7 +2,11 @@ package sql
 
 import (
 	"math"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/yistabraq/qframe/internal/ncolumn"
 )
 
 func assertEqual(t *testing.T, expected, actual interface{}) {
@@ -47,8 +51,53 @@ func TestColumn(t *testing.T) {
 	panicOnErr(col.Scan(nil))
 	panicOnErr(col.Scan(nil))
 	panicOnErr(col.Scan(nil))
-	assertEqual(t, nil, col.Data())
+	strs := col.Data().([]*string)
+	assertEqual(t, 4, len(strs))
+	assertEqual(t, (*string)(nil), strs[0])
+
+	// Column without values
+	col = &Column{}
+	assertEqual(t, ncolumn.Column{}, col.Data())
+}
+
+func TestTypedColumn(t *testing.T) {
+	// Column with all NULL values keeps its type
+	col := newTypedColumn(reflect.Float64)
+	panicOnErr(col.Scan(nil))
+	panicOnErr(col.Scan(nil))
+	data := col.Data().([]float64)
+	assertEqual(t, 2, len(data))
+	assertEqual(t, true, math.IsNaN(data[1]))
+
+	// Mixed values are converted to the column type
+	col = newTypedColumn(reflect.Float64)
+	panicOnErr(col.Scan(int64(1)))
+	panicOnErr(col.Scan(2.5))
+	panicOnErr(col.Scan([]byte("3.25")))
+	data = col.Data().([]float64)
+	assertEqual(t, 3, len(data))
+	assertEqual(t, 1.0, data[0])
+	assertEqual(t, 3.25, data[2])
+
+	col = newTypedColumn(reflect.String)
+	panicOnErr(col.Scan("a"))
+	panicOnErr(col.Scan(int64(1)))
+	panicOnErr(col.Scan(1.5))
+	strs := col.Data().([]*string)
+	assertEqual(t, "1", *strs[1])
+	assertEqual(t, "1.5", *strs[2])
+
+	col = newTypedColumn(timeKind)
+	panicOnErr(col.Scan("2020-01-02 03:04:05"))
+	panicOnErr(col.Scan(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
+	times := col.Data().([]*time.Time)
+	assertEqual(t, 2020, times[0].Year())
+	assertEqual(t, 2021, times[1].Year())
 
+	// Values that cannot be converted
+	col = newTypedColumn(reflect.Int)
+	assertEqual(t, true, col.Scan("abc") != nil)
+	assertEqual(t, true, col.Scan(1.5) != nil)
 }
 
 func TestColumnCoercion(t *testing.T) {
@@ -2,6 +2,10 @@ package sql
 
 import (
 	"database/sql"
+	"io"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/yistabraq/qframe/qerrors"
 	"github.com/yistabraq/qframe/types"
@@ -10,52 +14,170 @@ import (
 // ReadSQL returns a named map of types.DataSlice for consumption
 // by the qframe.New constructor.
 func ReadSQL(rows *sql.Rows, conf SQLConfig) (map[string]types.DataSlice, []string, error) {
-	var (
-		columns  []interface{}
-		colNames []string
-	)
-	for rows.Next() {
-		// Allocate columns for the returning query
-		if columns == nil {
-			names, err := rows.Columns()
-			if err != nil {
-				return nil, colNames, qerrors.New("ReadSQL Columns", err.Error())
-			}
-			for _, name := range names {
-				col := &Column{precision: conf.Precision}
-				if conf.CoerceMap != nil {
-					fn, ok := conf.CoerceMap[name]
-					if ok {
-						col.coerce = fn(col)
-					}
-				}
-				columns = append(columns, col)
+	r, err := NewReader(rows, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, colNames, err := r.Read(0)
+	if err == io.EOF {
+		err = nil
+	}
+	return data, colNames, err
+}
+
+// Reader reads the rows of a query result,
+// optionally a limited number of rows at a time.
+type Reader struct {
+	rows     *sql.Rows
+	conf     SQLConfig
+	colNames []string
+	kinds    []reflect.Kind
+	started  bool
+}
+
+// NewReader creates a Reader for rows. The kind of each
+// column is taken from the column types reported by the
+// driver when possible, otherwise it is inferred from
+// the first non NULL value read.
+func NewReader(rows *sql.Rows, conf SQLConfig) (*Reader, error) {
+	colNames, err := rows.Columns()
+	if err != nil {
+		return nil, qerrors.New("ReadSQL Columns", err.Error())
+	}
+	// ensure any column in the coercion map
+	// exists in the resulting columns or return
+	// an error explicitly.
+checkMap:
+	for name := range conf.CoerceMap {
+		for _, colName := range colNames {
+			if name == colName {
+				continue checkMap
 			}
-			// ensure any column in the coercion map
-			// exists in the resulting columns or return
-			// an error explicitly.
-			if conf.CoerceMap != nil {
-			checkMap:
-				for name := range conf.CoerceMap {
-					for _, colName := range colNames {
-						if name == colName {
-							continue checkMap
-						}
-						return nil, colNames, qerrors.New("ReadSQL Columns", "column %s does not exist to coerce", name)
-					}
-				}
+		}
+		return nil, qerrors.New("ReadSQL Columns", "column %s does not exist to coerce", name)
+	}
+
+	kinds := make([]reflect.Kind, len(colNames))
+	colTypes, err := rows.ColumnTypes()
+	if err == nil && len(colTypes) == len(colNames) {
+		for i, ct := range colTypes {
+			kinds[i] = columnKind(ct)
+		}
+	}
+	return &Reader{rows: rows, conf: conf, colNames: colNames, kinds: kinds}, nil
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	nullTimeType  = reflect.TypeOf(sql.NullTime{})
+	nullIntTypes  = []reflect.Type{reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}), reflect.TypeOf(sql.NullByte{})}
+	nullFloatType = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType  = reflect.TypeOf(sql.NullBool{})
+	nullStrType   = reflect.TypeOf(sql.NullString{})
+)
+
+// Database type names, as reported by common drivers, and the kind of column they are read into
+var databaseTypeKinds = map[string]reflect.Kind{
+	"INT": reflect.Int, "INTEGER": reflect.Int, "BIGINT": reflect.Int, "SMALLINT": reflect.Int,
+	"MEDIUMINT": reflect.Int, "INT2": reflect.Int, "INT4": reflect.Int, "INT8": reflect.Int,
+	"SERIAL": reflect.Int, "BIGSERIAL": reflect.Int,
+	"REAL": reflect.Float64, "FLOAT": reflect.Float64, "FLOAT4": reflect.Float64, "FLOAT8": reflect.Float64,
+	"DOUBLE": reflect.Float64, "DOUBLE PRECISION": reflect.Float64, "NUMERIC": reflect.Float64, "DECIMAL": reflect.Float64,
+	"BOOL": reflect.Bool, "BOOLEAN": reflect.Bool,
+	"TEXT": reflect.String, "VARCHAR": reflect.String, "CHAR": reflect.String, "CHARACTER": reflect.String,
+	"CHARACTER VARYING": reflect.String, "BPCHAR": reflect.String, "NVARCHAR": reflect.String, "NCHAR": reflect.String,
+	"CLOB": reflect.String, "UUID": reflect.String, "ENUM": reflect.String,
+	"DATE": timeKind, "DATETIME": timeKind, "TIMESTAMP": timeKind, "TIMESTAMPTZ": timeKind,
+}
+
+// columnKind returns the kind of column to read a
+// column of type ct into, reflect.Invalid if unknown.
+func columnKind(ct *sql.ColumnType) reflect.Kind {
+	if st := ct.ScanType(); st != nil {
+		switch st {
+		case timeType, nullTimeType:
+			return timeKind
+		case nullFloatType:
+			return reflect.Float64
+		case nullBoolType:
+			return reflect.Bool
+		case nullStrType:
+			return reflect.String
+		}
+		for _, t := range nullIntTypes {
+			if st == t {
+				return reflect.Int
 			}
-			colNames = names
 		}
+		switch st.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.Int
+		case reflect.Float32, reflect.Float64:
+			return reflect.Float64
+		case reflect.Bool:
+			return reflect.Bool
+		case reflect.String:
+			return reflect.String
+		}
+	}
+
+	// Raw bytes and interfaces, fall back to the name of the type, without any parameters
+	name := strings.ToUpper(strings.TrimSpace(ct.DatabaseTypeName()))
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	return databaseTypeKinds[name]
+}
+
+// Read reads at most maxRows rows, all remaining rows if
+// maxRows is 0. The first call always returns the columns,
+// even if there are no rows. Later calls return io.EOF
+// when there are no more rows.
+func (r *Reader) Read(maxRows int) (map[string]types.DataSlice, []string, error) {
+	columns := make([]interface{}, len(r.colNames))
+	for i, name := range r.colNames {
+		var col *Column
+		if fn, ok := r.conf.CoerceMap[name]; ok {
+			// Coercion decides the kind of the column
+			col = &Column{}
+			col.coerce = fn(col)
+		} else {
+			col = newTypedColumn(r.kinds[i])
+		}
+		col.precision = r.conf.Precision
+		columns[i] = col
+	}
+
+	count := 0
+	for (maxRows == 0 || count < maxRows) && r.rows.Next() {
 		// Scan the result into our columns
-		err := rows.Scan(columns...)
+		err := r.rows.Scan(columns...)
 		if err != nil {
-			return nil, colNames, qerrors.New("ReadSQL Scan", err.Error())
+			return nil, r.colNames, qerrors.New("ReadSQL Scan", err.Error())
 		}
+		count++
+	}
+	if err := r.rows.Err(); err != nil {
+		return nil, r.colNames, qerrors.New("ReadSQL Next", err.Error())
 	}
+	if count == 0 && r.started {
+		return nil, r.colNames, io.EOF
+	}
+	r.started = true
+
 	result := map[string]types.DataSlice{}
-	for i, column := range columns {
-		result[colNames[i]] = column.(*Column).Data()
+	for i, c := range columns {
+		col := c.(*Column)
+		result[r.colNames[i]] = col.Data()
+		// Following reads use the same kind for the column. Columns with only
+		// NULL values, and no type from the driver, are returned as strings.
+		if r.kinds[i] == reflect.Invalid && col.coerce == nil {
+			r.kinds[i] = col.kind
+			if r.kinds[i] == reflect.Invalid {
+				r.kinds[i] = reflect.String
+			}
+		}
 	}
-	return result, colNames, nil
+	return result, r.colNames, nil
 }
@@ -3,6 +3,17 @@ package sql
 import (
 	"bytes"
 	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
+	"github.com/yistabraq/qframe/qerrors"
 )
 
 func escape(s string, char rune, buf *bytes.Buffer) {
@@ -28,6 +39,14 @@ func escape(s string, char rune, buf *bytes.Buffer) {
 // PostgreSQL accepts "incrementing" markers e.g. $1..$2
 // While MySQL/MariaDB and SQLite accept ?..?.
 func Insert(colNames []string, conf SQLConfig) string {
+	return InsertBatch(colNames, 1, conf)
+}
+
+// InsertBatch generates a SQL insert statement inserting
+// rowCount rows in one statement using multiple VALUES
+// lists. Parameter markers are numbered across all rows
+// when using incrementing markers, e.g. ($1,$2),($3,$4).
+func InsertBatch(colNames []string, rowCount int, conf SQLConfig) string {
 	buf := bytes.NewBuffer(nil)
 	buf.WriteString("INSERT INTO ")
 	escape(conf.Table, conf.EscapeChar, buf)
@@ -38,17 +57,230 @@ func Insert(colNames []string, conf SQLConfig) string {
 			buf.WriteString(",")
 		}
 	}
-	buf.WriteString(") VALUES (")
-	for i := range colNames {
-		if conf.Incrementing {
-			buf.WriteString(fmt.Sprintf("$%d", i+1))
-		} else {
-			buf.WriteString("?")
+	buf.WriteString(") VALUES ")
+	for row := 0; row < rowCount; row++ {
+		buf.WriteString("(")
+		for i := range colNames {
+			if conf.Incrementing {
+				buf.WriteString(fmt.Sprintf("$%d", row*len(colNames)+i+1))
+			} else {
+				buf.WriteString("?")
+			}
+			if i+1 < len(colNames) {
+				buf.WriteString(",")
+			}
+		}
+		buf.WriteString(")")
+		if row+1 < rowCount {
+			buf.WriteString(",")
 		}
+	}
+	if conf.upsert() {
+		onConflict(colNames, conf, buf)
+	}
+	buf.WriteString(";")
+	return buf.String()
+}
+
+// updateColumns returns the columns to update
+// when an inserted row already exists.
+func updateColumns(colNames []string, conf SQLConfig) []string {
+	if conf.DoNothing {
+		return nil
+	}
+	if conf.UpdateColumns != nil {
+		return conf.UpdateColumns
+	}
+	keys := make(map[string]bool, len(conf.UpsertKeys))
+	for _, key := range conf.UpsertKeys {
+		keys[key] = true
+	}
+	var result []string
+	for _, name := range colNames {
+		if !keys[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// onConflict writes the clause deciding what happens
+// to rows that already exist. MySQL/MariaDB use
+// ON DUPLICATE KEY UPDATE, other dialects use
+// ON CONFLICT which is supported by PostgreSQL
+// and SQLite.
+func onConflict(colNames []string, conf SQLConfig, buf *bytes.Buffer) {
+	updates := updateColumns(colNames, conf)
+	if conf.Dialect == MySQL {
+		buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(updates) == 0 {
+			// Assigning a column to itself leaves the row unchanged
+			name := colNames[0]
+			if len(conf.UpsertKeys) > 0 {
+				name = conf.UpsertKeys[0]
+			}
+			escape(name, conf.EscapeChar, buf)
+			buf.WriteString("=")
+			escape(name, conf.EscapeChar, buf)
+			return
+		}
+		for i, name := range updates {
+			escape(name, conf.EscapeChar, buf)
+			buf.WriteString("=VALUES(")
+			escape(name, conf.EscapeChar, buf)
+			buf.WriteString(")")
+			if i+1 < len(updates) {
+				buf.WriteString(",")
+			}
+		}
+		return
+	}
+
+	buf.WriteString(" ON CONFLICT")
+	if len(conf.UpsertKeys) > 0 {
+		buf.WriteString(" (")
+		for i, key := range conf.UpsertKeys {
+			escape(key, conf.EscapeChar, buf)
+			if i+1 < len(conf.UpsertKeys) {
+				buf.WriteString(",")
+			}
+		}
+		buf.WriteString(")")
+	}
+	if len(updates) == 0 {
+		buf.WriteString(" DO NOTHING")
+		return
+	}
+	buf.WriteString(" DO UPDATE SET ")
+	for i, name := range updates {
+		escape(name, conf.EscapeChar, buf)
+		buf.WriteString("=excluded.")
+		escape(name, conf.EscapeChar, buf)
+		if i+1 < len(updates) {
+			buf.WriteString(",")
+		}
+	}
+}
+
+// checkUpsert verifies that all columns referred
+// to by the upsert configuration exist.
+func checkUpsert(colNames []string, conf SQLConfig) error {
+	known := make(map[string]bool, len(colNames))
+	for _, name := range colNames {
+		known[name] = true
+	}
+	for _, key := range conf.UpsertKeys {
+		if !known[key] {
+			return qerrors.New("checkUpsert", "unknown upsert key column: %s", key)
+		}
+	}
+	for _, name := range conf.UpdateColumns {
+		if !known[name] {
+			return qerrors.New("checkUpsert", "unknown update column: %s", name)
+		}
+	}
+	if len(conf.UpsertKeys) == 0 && !conf.DoNothing {
+		return qerrors.New("checkUpsert", "upsert requires key columns")
+	}
+	return nil
+}
+
+// columnType returns the SQL type used for col in
+// tables created for the given dialect.
+func columnType(col column.Column, dialect Dialect) (string, error) {
+	switch c := col.(type) {
+	case icolumn.Column:
+		if dialect == SQLite {
+			return "INTEGER", nil
+		}
+		return "BIGINT", nil
+	case fcolumn.Column:
+		switch dialect {
+		case SQLite:
+			return "REAL", nil
+		case MySQL:
+			return "DOUBLE", nil
+		}
+		return "DOUBLE PRECISION", nil
+	case bcolumn.Column:
+		return "BOOLEAN", nil
+	case scolumn.Column:
+		return "TEXT", nil
+	case ecolumn.Column:
+		if dialect != MySQL || len(c.Values()) == 0 {
+			return "TEXT", nil
+		}
+		// MySQL supports enums natively, keeping the order of values
+		values := make([]string, len(c.Values()))
+		for i, v := range c.Values() {
+			values[i] = "'" + strings.Replace(v, "'", "''", -1) + "'"
+		}
+		return fmt.Sprintf("ENUM(%s)", strings.Join(values, ",")), nil
+	case tcolumn.Column:
+		switch dialect {
+		case Postgres:
+			return "TIMESTAMP WITH TIME ZONE", nil
+		case SQLite, MySQL:
+			return "DATETIME", nil
+		}
+		return "TIMESTAMP", nil
+	}
+	return "", qerrors.New("columnType", "no SQL type for column type: %s", reflect.TypeOf(col))
+}
+
+// CreateTable generates a SQL CREATE TABLE statement
+// for a table with the given columns. Column types are
+// chosen based on conf.Dialect unless overridden in
+// conf.ColumnTypes.
+func CreateTable(colNames []string, columns []column.Column, conf SQLConfig) (string, error) {
+	known := make(map[string]bool, len(colNames))
+	for _, name := range colNames {
+		known[name] = true
+	}
+	for name := range conf.ColumnTypes {
+		if !known[name] {
+			return "", qerrors.New("CreateTable", "unknown column in column types: %s", name)
+		}
+	}
+	for _, name := range conf.PrimaryKey {
+		if !known[name] {
+			return "", qerrors.New("CreateTable", "unknown column in primary key: %s", name)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString("CREATE TABLE ")
+	if conf.IfNotExists {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	escape(conf.Table, conf.EscapeChar, buf)
+	buf.WriteString(" (")
+	for i, name := range colNames {
+		typ, ok := conf.ColumnTypes[name]
+		if !ok {
+			var err error
+			typ, err = columnType(columns[i], conf.Dialect)
+			if err != nil {
+				return "", qerrors.Propagate("CreateTable", err)
+			}
+		}
+		escape(name, conf.EscapeChar, buf)
+		buf.WriteString(" ")
+		buf.WriteString(typ)
 		if i+1 < len(colNames) {
 			buf.WriteString(",")
 		}
 	}
+	if len(conf.PrimaryKey) > 0 {
+		buf.WriteString(",PRIMARY KEY (")
+		for i, name := range conf.PrimaryKey {
+			escape(name, conf.EscapeChar, buf)
+			if i+1 < len(conf.PrimaryKey) {
+				buf.WriteString(",")
+			}
+		}
+		buf.WriteString(")")
+	}
 	buf.WriteString(");")
-	return buf.String()
+	return buf.String(), nil
 }
@@ -1,7 +1,17 @@
 package sql
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
 )
 
 func TestInsert(t *testing.T) {
@@ -22,3 +32,139 @@ func TestInsert(t *testing.T) {
 	expected = "INSERT INTO `test` (`COL1`,`COL2`) VALUES (?,?);"
 	assertEqual(t, expected, query)
 }
+
+func TestInsertBatch(t *testing.T) {
+	query := InsertBatch([]string{"COL1", "COL2"}, 3, SQLConfig{Table: "test"})
+	expected := `INSERT INTO test (COL1,COL2) VALUES (?,?),(?,?),(?,?);`
+	assertEqual(t, expected, query)
+
+	// Incrementing markers continue across rows
+	query = InsertBatch([]string{"COL1", "COL2"}, 2, SQLConfig{
+		Table: "test", EscapeChar: '"', Incrementing: true})
+	expected = "INSERT INTO \"test\" (\"COL1\",\"COL2\") VALUES ($1,$2),($3,$4);"
+	assertEqual(t, expected, query)
+}
+
+func TestCreateTable(t *testing.T) {
+	a, b := "a", "it's"
+	enum, err := ecolumn.New([]*string{&a, &b}, []string{"it's", "a"})
+	panicOnErr(err)
+	names := []string{"I", "F", "B", "S", "E", "T"}
+	columns := []column.Column{
+		icolumn.New([]int{1, 2}),
+		fcolumn.New([]float64{1.5, 2.5}),
+		bcolumn.New([]bool{true, false}),
+		scolumn.NewStrings([]string{"a", "b"}),
+		enum,
+		tcolumn.New([]time.Time{{}, {}}),
+	}
+
+	table := []struct {
+		conf     SQLConfig
+		expected string
+	}{
+		{
+			conf:     SQLConfig{Table: "test"},
+			expected: `CREATE TABLE test (I BIGINT,F DOUBLE PRECISION,B BOOLEAN,S TEXT,E TEXT,T TIMESTAMP);`,
+		},
+		{
+			conf:     SQLConfig{Table: "test", EscapeChar: '"', Dialect: Postgres, IfNotExists: true},
+			expected: `CREATE TABLE IF NOT EXISTS "test" ("I" BIGINT,"F" DOUBLE PRECISION,"B" BOOLEAN,"S" TEXT,"E" TEXT,"T" TIMESTAMP WITH TIME ZONE);`,
+		},
+		{
+			conf:     SQLConfig{Table: "test", EscapeChar: '"', Dialect: SQLite},
+			expected: `CREATE TABLE "test" ("I" INTEGER,"F" REAL,"B" BOOLEAN,"S" TEXT,"E" TEXT,"T" DATETIME);`,
+		},
+		{
+			conf:     SQLConfig{Table: "test", EscapeChar: '`', Dialect: MySQL},
+			expected: "CREATE TABLE `test` (`I` BIGINT,`F` DOUBLE,`B` BOOLEAN,`S` TEXT,`E` ENUM('it''s','a'),`T` DATETIME);",
+		},
+		{
+			conf: SQLConfig{
+				Table:       "test",
+				ColumnTypes: map[string]string{"S": "VARCHAR(10)"},
+				PrimaryKey:  []string{"S", "I"}},
+			expected: `CREATE TABLE test (I BIGINT,F DOUBLE PRECISION,B BOOLEAN,S VARCHAR(10),E TEXT,T TIMESTAMP,PRIMARY KEY (S,I));`,
+		},
+	}
+
+	for _, tc := range table {
+		query, err := CreateTable(names, columns, tc.conf)
+		panicOnErr(err)
+		assertEqual(t, tc.expected, query)
+	}
+}
+
+func TestCreateTableErrors(t *testing.T) {
+	names := []string{"I"}
+	columns := []column.Column{icolumn.New([]int{1})}
+	_, err := CreateTable(names, columns, SQLConfig{Table: "test", PrimaryKey: []string{"X"}})
+	assertEqual(t, true, err != nil && strings.Contains(err.Error(), "unknown column in primary key"))
+
+	_, err = CreateTable(names, columns, SQLConfig{Table: "test", ColumnTypes: map[string]string{"X": "TEXT"}})
+	assertEqual(t, true, err != nil && strings.Contains(err.Error(), "unknown column in column types"))
+}
+
+func TestInsertUpsert(t *testing.T) {
+	names := []string{"ID", "COL1", "COL2"}
+	table := []struct {
+		conf     SQLConfig
+		rowCount int
+		expected string
+	}{
+		{
+			conf:     SQLConfig{Table: "test", EscapeChar: '"', Incrementing: true, Dialect: Postgres, UpsertKeys: []string{"ID"}},
+			rowCount: 2,
+			expected: `INSERT INTO "test" ("ID","COL1","COL2") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT ("ID") DO UPDATE SET "COL1"=excluded."COL1","COL2"=excluded."COL2";`,
+		},
+		{
+			conf:     SQLConfig{Table: "test", EscapeChar: '"', Dialect: SQLite, UpsertKeys: []string{"ID", "COL1"}, UpdateColumns: []string{"COL2"}},
+			rowCount: 1,
+			expected: `INSERT INTO "test" ("ID","COL1","COL2") VALUES (?,?,?) ON CONFLICT ("ID","COL1") DO UPDATE SET "COL2"=excluded."COL2";`,
+		},
+		{
+			conf:     SQLConfig{Table: "test", Dialect: Postgres, UpsertKeys: []string{"ID"}, DoNothing: true},
+			rowCount: 1,
+			expected: `INSERT INTO test (ID,COL1,COL2) VALUES (?,?,?) ON CONFLICT (ID) DO NOTHING;`,
+		},
+		{
+			conf:     SQLConfig{Table: "test", Dialect: Postgres, DoNothing: true},
+			rowCount: 1,
+			expected: `INSERT INTO test (ID,COL1,COL2) VALUES (?,?,?) ON CONFLICT DO NOTHING;`,
+		},
+		{
+			conf:     SQLConfig{Table: "test", EscapeChar: '`', Dialect: MySQL, UpsertKeys: []string{"ID"}},
+			rowCount: 2,
+			expected: "INSERT INTO `test` (`ID`,`COL1`,`COL2`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `COL1`=VALUES(`COL1`),`COL2`=VALUES(`COL2`);",
+		},
+		{
+			conf:     SQLConfig{Table: "test", EscapeChar: '`', Dialect: MySQL, UpsertKeys: []string{"ID"}, DoNothing: true},
+			rowCount: 1,
+			expected: "INSERT INTO `test` (`ID`,`COL1`,`COL2`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `ID`=`ID`;",
+		},
+		{
+			// All columns are keys, nothing to update
+			conf:     SQLConfig{Table: "test", UpsertKeys: []string{"ID", "COL1", "COL2"}},
+			rowCount: 1,
+			expected: `INSERT INTO test (ID,COL1,COL2) VALUES (?,?,?) ON CONFLICT (ID,COL1,COL2) DO NOTHING;`,
+		},
+	}
+
+	for _, tc := range table {
+		assertEqual(t, tc.expected, InsertBatch(names, tc.rowCount, tc.conf))
+	}
+}
+
+func TestCheckUpsert(t *testing.T) {
+	names := []string{"ID", "COL1"}
+	assertEqual(t, nil, checkUpsert(names, SQLConfig{UpsertKeys: []string{"ID"}, UpdateColumns: []string{"COL1"}}))
+
+	err := checkUpsert(names, SQLConfig{UpsertKeys: []string{"X"}})
+	assertEqual(t, true, err != nil && strings.Contains(err.Error(), "unknown upsert key column"))
+
+	err = checkUpsert(names, SQLConfig{UpsertKeys: []string{"ID"}, UpdateColumns: []string{"X"}})
+	assertEqual(t, true, err != nil && strings.Contains(err.Error(), "unknown update column"))
+
+	err = checkUpsert(names, SQLConfig{UpdateColumns: []string{"COL1"}})
+	assertEqual(t, true, err != nil && strings.Contains(err.Error(), "requires key columns"))
+}
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 
@@ -11,14 +12,29 @@ import (
 	"github.com/yistabraq/qframe/internal/icolumn"
 	"github.com/yistabraq/qframe/internal/index"
 	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
 	"github.com/yistabraq/qframe/qerrors"
 )
 
+// Dialect is the SQL dialect of a database, it
+// decides the column types used when creating tables.
+type Dialect int
+
+const (
+	// Generic uses standard SQL types.
+	Generic Dialect = iota
+	Postgres
+	SQLite
+	MySQL
+)
+
 type SQLConfig struct {
 	// Query is a Raw SQL statement which must return
 	// appropriate types which can be inferred
 	// and loaded into a new QFrame.
 	Query string
+	// QueryArgs are the arguments given to Query.
+	QueryArgs []interface{}
 	// Incrementing indicates the PostgreSQL variant
 	// of parameter markers will be used, e.g. $1..$2.
 	// The default style is ?..?.
@@ -37,6 +53,49 @@ type SQLConfig struct {
 	// Precision specifies how much precision float values
 	// should have. 0 has no effect.
 	Precision int
+	// AutoEnumMaxCardinality is the max number of distinct
+	// values for string columns to be stored as enums.
+	// 0 disables automatic enums.
+	AutoEnumMaxCardinality int
+	// AutoEnumMaxRatio is the max ratio between distinct values
+	// and rows for string columns to be stored as enums.
+	// 0 has no effect.
+	AutoEnumMaxRatio float64
+	// BatchSize is the number of rows inserted by each
+	// INSERT statement. 0 and 1 both mean one row per statement.
+	BatchSize int
+	// BulkLoader, if set, is used to write rows instead
+	// of INSERT statements.
+	BulkLoader BulkLoader
+	// Dialect decides which column types are used
+	// when generating a CREATE TABLE statement.
+	Dialect Dialect
+	// CreateTable indicates that the table should
+	// be created before writing to it.
+	CreateTable bool
+	// IfNotExists only creates the table if it
+	// does not already exist.
+	IfNotExists bool
+	// ColumnTypes overrides the SQL type of
+	// columns when creating the table.
+	ColumnTypes map[string]string
+	// PrimaryKey lists the columns that make
+	// up the primary key of created tables.
+	PrimaryKey []string
+	// UpsertKeys are the columns identifying rows
+	// when inserting rows that may already exist.
+	UpsertKeys []string
+	// UpdateColumns are the columns updated when a
+	// row already exists. Defaults to all columns not
+	// in UpsertKeys.
+	UpdateColumns []string
+	// DoNothing leaves rows that already exist
+	// unchanged instead of updating them.
+	DoNothing bool
+}
+
+func (c SQLConfig) upsert() bool {
+	return len(c.UpsertKeys) > 0 || c.DoNothing
 }
 
 type ArgBuilder func(ix index.Int, i int) interface{}
@@ -44,10 +103,22 @@ type ArgBuilder func(ix index.Int, i int) interface{}
 func NewArgBuilder(col column.Column) (ArgBuilder, error) {
 	switch c := col.(type) {
 	case bcolumn.Column:
+		if c.Validity() != nil {
+			return func(ix index.Int, i int) interface{} {
+				view := c.View(ix)
+				return sql.NullBool{Bool: view.ItemAt(i), Valid: !view.IsNull(i)}
+			}, nil
+		}
 		return func(ix index.Int, i int) interface{} {
 			return c.View(ix).ItemAt(i)
 		}, nil
 	case icolumn.Column:
+		if c.Validity() != nil {
+			return func(ix index.Int, i int) interface{} {
+				view := c.View(ix)
+				return sql.NullInt64{Int64: int64(view.ItemAt(i)), Valid: !view.IsNull(i)}
+			}, nil
+		}
 		return func(ix index.Int, i int) interface{} {
 			return c.View(ix).ItemAt(i)
 		}, nil
@@ -63,6 +134,16 @@ func NewArgBuilder(col column.Column) (ArgBuilder, error) {
 		return func(ix index.Int, i int) interface{} {
 			return c.View(ix).ItemAt(i)
 		}, nil
+	case tcolumn.Column:
+		if c.Validity() != nil {
+			return func(ix index.Int, i int) interface{} {
+				view := c.View(ix)
+				return sql.NullTime{Time: view.ItemAt(i), Valid: !view.IsNull(i)}
+			}, nil
+		}
+		return func(ix index.Int, i int) interface{} {
+			return c.View(ix).ItemAt(i)
+		}, nil
 	}
 	return nil, qerrors.New("NewArgBuilder", fmt.Sprintf("bad column type: %s", reflect.TypeOf(col).Name()))
 }
@@ -0,0 +1,131 @@
+package sql
+
+import (
+	"database/sql"
+
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+// BulkLoader writes rows to a table using a database
+// specific bulk loading mechanism rather than INSERT
+// statements, e.g. COPY FROM STDIN in PostgreSQL.
+type BulkLoader interface {
+	// Load writes all rows to table. next fills args with
+	// the values of the next row, one per column, and
+	// returns false when there are no more rows.
+	Load(tx *sql.Tx, table string, colNames []string, next func(args []interface{}) bool) error
+}
+
+// CopyIn is a BulkLoader for drivers that implement
+// COPY FROM STDIN through a prepared statement, like
+// github.com/lib/pq. The function returns the statement
+// to prepare, e.g. pq.CopyIn. Each row is sent by executing
+// the statement, it is flushed by a final execution without
+// arguments.
+type CopyIn func(table string, columns ...string) string
+
+// Load implements BulkLoader.
+func (c CopyIn) Load(tx *sql.Tx, table string, colNames []string, next func(args []interface{}) bool) error {
+	stmt, err := tx.Prepare(c(table, colNames...))
+	if err != nil {
+		return qerrors.Propagate("CopyIn Prepare", err)
+	}
+	defer stmt.Close()
+
+	args := make([]interface{}, len(colNames))
+	for next(args) {
+		if _, err := stmt.Exec(args...); err != nil {
+			return qerrors.Propagate("CopyIn Exec", err)
+		}
+	}
+
+	if _, err := stmt.Exec(); err != nil {
+		return qerrors.Propagate("CopyIn Flush", err)
+	}
+
+	return stmt.Close()
+}
+
+// WriteSQL writes the rows given by ix to the table
+// in conf. Each element of builders produces the
+// argument for the column with the same position in
+// colNames. Rows are inserted conf.BatchSize at a time
+// using one prepared statement for all full batches, or
+// handed to conf.BulkLoader if set.
+func WriteSQL(tx *sql.Tx, colNames []string, builders []ArgBuilder, ix index.Int, conf SQLConfig) error {
+	if conf.upsert() || conf.UpdateColumns != nil {
+		if conf.BulkLoader != nil {
+			return qerrors.New("WriteSQL", "upsert is not supported when using a bulk loader")
+		}
+		if err := checkUpsert(colNames, conf); err != nil {
+			return qerrors.Propagate("WriteSQL", err)
+		}
+	}
+
+	if conf.BulkLoader != nil {
+		i := 0
+		next := func(args []interface{}) bool {
+			if i == len(ix) {
+				return false
+			}
+			for j, b := range builders {
+				args[j] = b(ix, i)
+			}
+			i++
+			return true
+		}
+		if err := conf.BulkLoader.Load(tx, conf.Table, colNames, next); err != nil {
+			return qerrors.Propagate("WriteSQL BulkLoader", err)
+		}
+		return nil
+	}
+
+	batchSize := conf.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if batchSize > len(ix) {
+		batchSize = len(ix)
+	}
+
+	var stmt *sql.Stmt
+	args := make([]interface{}, 0, batchSize*len(builders))
+	for start := 0; start < len(ix); start += batchSize {
+		end := start + batchSize
+		if end > len(ix) {
+			end = len(ix)
+		}
+
+		args = args[:0]
+		for i := start; i < end; i++ {
+			for _, b := range builders {
+				args = append(args, b(ix, i))
+			}
+		}
+
+		if end-start < batchSize {
+			// The last batch is smaller than the others
+			// and needs a statement of its own.
+			if _, err := tx.Exec(InsertBatch(colNames, end-start, conf), args...); err != nil {
+				return qerrors.Propagate("WriteSQL Exec", err)
+			}
+			break
+		}
+
+		if stmt == nil {
+			var err error
+			stmt, err = tx.Prepare(InsertBatch(colNames, batchSize, conf))
+			if err != nil {
+				return qerrors.Propagate("WriteSQL Prepare", err)
+			}
+			defer stmt.Close()
+		}
+
+		if _, err := stmt.Exec(args...); err != nil {
+			return qerrors.Propagate("WriteSQL Exec", err)
+		}
+	}
+
+	return nil
+}
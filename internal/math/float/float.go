@@ -12,3 +12,40 @@ func Fixed(num float64, precision int) float64 {
 	i := math.Pow(10, float64(precision))
 	return float64(Round(num*i)) / i
 }
+
+// Quantile returns the p quantile, 0 <= p <= 1, of sorted using linear interpolation
+// between the closest values. sorted must not be empty.
+func Quantile(sorted []float64, p float64) float64 {
+	h := float64(len(sorted)-1) * p
+	lo := int(math.Floor(h))
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	return sorted[lo] + (h-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// Mean returns the arithmetic mean of values, NaN if values is empty.
+func Mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum / float64(len(values))
+}
+
+// Variance returns the sample variance of values, NaN if there are less than two values.
+func Variance(values []float64) float64 {
+	if len(values) < 2 {
+		return math.NaN()
+	}
+
+	mean := Mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+
+	return sum / float64(len(values)-1)
+}
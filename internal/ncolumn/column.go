@@ -7,7 +7,6 @@ It is for example used when reading zero row CSVs without type hints.
 */
 
 import (
-	"github.com/yistabraq/qframe/config/rolling"
 	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/index"
 	"github.com/yistabraq/qframe/qerrors"
@@ -60,11 +59,7 @@ func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
 	return c, nil
 }
 
-func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (column.Column, error) {
-	return c, nil
-}
-
-func (c Column) Rolling(fn interface{}, ix index.Int, config rolling.Config) (column.Column, error) {
+func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (interface{}, error) {
 	return c, nil
 }
 
@@ -87,6 +82,11 @@ func (c Comparable) Hash(i uint32, seed uint64) uint64 {
 }
 
 func (c Column) Append(cols ...column.Column) (column.Column, error) {
-	// TODO Append
-	return nil, qerrors.New("Append", "Not implemented yet")
+	for _, col := range cols {
+		if _, ok := col.(Column); !ok {
+			return nil, qerrors.New("append null", "can only append null columns to null column")
+		}
+	}
+
+	return c, nil
 }
@@ -0,0 +1,29 @@
+package parallel
+
+import "sync"
+
+// For calls fn for all i in [0, n) using up to parallelism goroutines. fn is called
+// sequentially, in order, if parallelism <= 1.
+func For(n, parallelism int, fn func(i int)) {
+	if parallelism <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
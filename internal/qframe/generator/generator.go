@@ -71,6 +71,7 @@ func GenerateQFrame() (*bytes.Buffer, error) {
 		view("Bool", "bcolumn"),
 		view("String", "scolumn"),
 		view("Enum", "ecolumn"),
+		view("Time", "tcolumn"),
 	}, []string{
 		"github.com/yistabraq/qframe/qerrors",
 		"github.com/yistabraq/qframe/internal/icolumn",
@@ -78,5 +79,6 @@ func GenerateQFrame() (*bytes.Buffer, error) {
 		"github.com/yistabraq/qframe/internal/bcolumn",
 		"github.com/yistabraq/qframe/internal/scolumn",
 		"github.com/yistabraq/qframe/internal/ecolumn",
+		"github.com/yistabraq/qframe/internal/tcolumn",
 	})
 }
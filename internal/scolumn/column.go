@@ -6,7 +6,6 @@ import (
 	"math/rand"
 	"reflect"
 
-	"github.com/yistabraq/qframe/config/rolling"
 	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/hash"
 	"github.com/yistabraq/qframe/internal/index"
@@ -408,7 +407,7 @@ func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
 	}
 }
 
-func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (column.Column, error) {
+func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (interface{}, error) {
 	s2S, ok := s2.(Column)
 	if !ok {
 		return nil, qerrors.New("string.apply2", "invalid column type %v", reflect.TypeOf(s2))
@@ -421,6 +420,12 @@ func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (column.C
 			result[i] = t(stringToPtr(c.stringAt(i)), stringToPtr(s2S.stringAt(i)))
 		}
 		return New(result), nil
+	case func(*string, *string) bool:
+		result := make([]bool, len(c.pointers))
+		for _, i := range ix {
+			result[i] = t(stringToPtr(c.stringAt(i)), stringToPtr(s2S.stringAt(i)))
+		}
+		return result, nil
 	case string:
 		// No built in functions for strings at this stage
 		return nil, qerrors.New("string.apply2", "unknown built in function %s", t)
@@ -433,10 +438,6 @@ func (c Column) View(ix index.Int) View {
 	return View{column: c, index: ix}
 }
 
-func (c Column) Rolling(fn interface{}, ix index.Int, config rolling.Config) (column.Column, error) {
-	return c, nil
-}
-
 func (c Column) FunctionType() types.FunctionType {
 	return types.FunctionTypeString
 }
@@ -446,8 +447,29 @@ func (c Column) DataType() types.DataType {
 }
 
 func (c Column) Append(cols ...column.Column) (column.Column, error) {
-	// TODO Append
-	return nil, qerrors.New("Append", "Not implemented yet")
+	newLen, dataLen := c.Len(), len(c.data)
+	stringCols := append(make([]Column, 0, len(cols)+1), c)
+	for _, col := range cols {
+		stringCol, ok := col.(Column)
+		if !ok {
+			return nil, qerrors.New("append string", "can only append string columns to string column")
+		}
+		newLen += stringCol.Len()
+		dataLen += len(stringCol.data)
+		stringCols = append(stringCols, stringCol)
+	}
+
+	pointers := make([]qfstrings.Pointer, 0, newLen)
+	data := make([]byte, 0, dataLen)
+	for _, col := range stringCols {
+		offset := len(data)
+		for _, p := range col.pointers {
+			pointers = append(pointers, qfstrings.NewPointer(offset+p.Offset(), p.Len(), p.IsNull()))
+		}
+		data = append(data, col.data...)
+	}
+
+	return NewBytes(pointers, data), nil
 }
 
 type Comparable struct {
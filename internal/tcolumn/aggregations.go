@@ -0,0 +1,28 @@
+package tcolumn
+
+import "time"
+
+var aggregations = map[string]func([]time.Time) time.Time{
+	"max": max,
+	"min": min,
+}
+
+func max(values []time.Time) time.Time {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v.After(result) {
+			result = v
+		}
+	}
+	return result
+}
+
+func min(values []time.Time) time.Time {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v.Before(result) {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,447 @@
+package tcolumn
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/yistabraq/qframe/internal/bitmap"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/hash"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// Column holds points in time as nanoseconds since the Unix epoch together with the
+// location used when presenting them. Null values are tracked in a validity bitmap.
+type Column struct {
+	data  []int64
+	valid bitmap.Bitmap
+	loc   *time.Location
+}
+
+// New creates a new column from times. The location of the first time is used
+// as location for the column.
+func New(d []time.Time) Column {
+	data := make([]int64, len(d))
+	for i, t := range d {
+		data[i] = t.UnixNano()
+	}
+
+	loc := time.UTC
+	if len(d) > 0 {
+		loc = d[0].Location()
+	}
+
+	return NewNullable(data, nil, loc)
+}
+
+// NewNullable creates a new column from nanoseconds since the Unix epoch where values marked
+// as invalid in valid are null. valid may be nil if there are no null values.
+func NewNullable(d []int64, valid bitmap.Bitmap, loc *time.Location) Column {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return Column{data: d, valid: valid, loc: loc}
+}
+
+// Validity returns the validity bitmap of the column, nil if there are no null values.
+func (c Column) Validity() bitmap.Bitmap {
+	return c.valid
+}
+
+// Location returns the location used when presenting the times in the column.
+func (c Column) Location() *time.Location {
+	return c.loc
+}
+
+func (c Column) timeAt(i uint32) time.Time {
+	return time.Unix(0, c.data[i]).In(c.loc)
+}
+
+func (c Column) DataType() types.DataType {
+	return types.Time
+}
+
+func (c Column) FunctionType() types.FunctionType {
+	return types.FunctionTypeTime
+}
+
+func (c Column) StringAt(i uint32, naRep string) string {
+	if c.valid.IsNull(i) {
+		return naRep
+	}
+
+	return c.timeAt(i).Format(time.RFC3339Nano)
+}
+
+func (c Column) AppendByteStringAt(buf []byte, i uint32) []byte {
+	if c.valid.IsNull(i) {
+		return append(buf, "null"...)
+	}
+
+	buf = append(buf, '"')
+	buf = c.timeAt(i).AppendFormat(buf, time.RFC3339Nano)
+	return append(buf, '"')
+}
+
+func (c Column) ByteSize() int {
+	// Slice header + data + validity + location
+	return 2*8 + 8*cap(c.data) + 8*cap(c.valid) + 8
+}
+
+func (c Column) Len() int {
+	return len(c.data)
+}
+
+func (c Column) String() string {
+	strs := make([]string, len(c.data))
+	for i := range c.data {
+		strs[i] = c.StringAt(uint32(i), "null")
+	}
+
+	return "[" + strings.Join(strs, " ") + "]"
+}
+
+// Equals compares the points in time, the location of the columns is not considered.
+func (c Column) Equals(index index.Int, other column.Column, otherIndex index.Int) bool {
+	otherT, ok := other.(Column)
+	if !ok {
+		return false
+	}
+
+	for ix, x := range index {
+		oX := otherIndex[ix]
+		if c.valid.IsNull(x) || otherT.valid.IsNull(oX) {
+			if c.valid.IsNull(x) && otherT.valid.IsNull(oX) {
+				continue
+			}
+
+			return false
+		}
+
+		if c.data[x] != otherT.data[oX] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c Column) subset(index index.Int) Column {
+	data := make([]int64, len(index))
+	for i, ix := range index {
+		data[i] = c.data[ix]
+	}
+
+	return Column{data: data, valid: c.valid.Subset(index), loc: c.loc}
+}
+
+func (c Column) Subset(index index.Int) column.Column {
+	return c.subset(index)
+}
+
+func (c Column) Append(cols ...column.Column) (column.Column, error) {
+	newLen := c.Len()
+	timeCols := append(make([]Column, 0, len(cols)+1), c)
+	bitmaps, sizes := []bitmap.Bitmap{c.valid}, []int{c.Len()}
+	for _, col := range cols {
+		timeCol, ok := col.(Column)
+		if !ok {
+			return nil, qerrors.New("append time", "can only append time columns to time column")
+		}
+		newLen += timeCol.Len()
+		timeCols = append(timeCols, timeCol)
+		bitmaps, sizes = append(bitmaps, timeCol.valid), append(sizes, timeCol.Len())
+	}
+
+	newData := make([]int64, newLen)
+	offset := 0
+	for _, col := range timeCols {
+		offset += copy(newData[offset:], col.data)
+	}
+
+	return NewNullable(newData, bitmap.Concat(bitmaps, sizes), c.loc), nil
+}
+
+func (c Column) View(ix index.Int) View {
+	return View{column: c, index: ix}
+}
+
+func (c Column) Comparable(reverse, equalNull, nullLast bool) column.Comparable {
+	result := Comparable{data: c.data, valid: c.valid, ltValue: column.LessThan, gtValue: column.GreaterThan, nullLtValue: column.LessThan, nullGtValue: column.GreaterThan, equalNullValue: column.NotEqual}
+	if reverse {
+		result.ltValue, result.nullLtValue, result.gtValue, result.nullGtValue =
+			result.gtValue, result.nullGtValue, result.ltValue, result.nullLtValue
+	}
+
+	if nullLast {
+		result.nullLtValue, result.nullGtValue = result.nullGtValue, result.nullLtValue
+	}
+
+	if equalNull {
+		result.equalNullValue = column.Equal
+	}
+
+	return result
+}
+
+type Comparable struct {
+	data           []int64
+	valid          bitmap.Bitmap
+	ltValue        column.CompareResult
+	gtValue        column.CompareResult
+	nullLtValue    column.CompareResult
+	nullGtValue    column.CompareResult
+	equalNullValue column.CompareResult
+}
+
+func (c Comparable) Compare(i, j uint32) column.CompareResult {
+	xNull, yNull := c.valid.IsNull(i), c.valid.IsNull(j)
+	if xNull || yNull {
+		if !xNull {
+			return c.nullGtValue
+		}
+
+		if !yNull {
+			return c.nullLtValue
+		}
+
+		return c.equalNullValue
+	}
+
+	x, y := c.data[i], c.data[j]
+	if x < y {
+		return c.ltValue
+	}
+
+	if x > y {
+		return c.gtValue
+	}
+
+	return column.Equal
+}
+
+func (c Comparable) Hash(i uint32, seed uint64) uint64 {
+	if c.valid.IsNull(i) {
+		if c.equalNullValue == column.NotEqual {
+			// Use a random value here to avoid hash collisions when
+			// we don't consider null to equal null.
+			return rand.Uint64()
+		}
+
+		b := [1]byte{0}
+		return hash.HashBytes(b[:], seed)
+	}
+
+	x := &c.data[i]
+	b := (*[8]byte)(unsafe.Pointer(x))[:]
+	return hash.HashBytes(b, seed)
+}
+
+func (c Column) Aggregate(indices []index.Int, fn interface{}) (column.Column, error) {
+	var actualFn func([]time.Time) time.Time
+	switch t := fn.(type) {
+	case string:
+		builtIn, ok := aggregations[t]
+		if !ok {
+			return nil, qerrors.New("time aggregate", "aggregation function %s is not defined for time column", fn)
+		}
+		actualFn = builtIn
+	case func([]time.Time) time.Time:
+		actualFn = t
+	default:
+		return nil, qerrors.New("time aggregate", "invalid aggregation function type: %v", t)
+	}
+
+	// Null values are left out of the aggregation, groups with only null values produce null.
+	data := make([]int64, len(indices))
+	var valid bitmap.Bitmap
+	var buf []time.Time
+	for i, ix := range indices {
+		buf = buf[:0]
+		for _, j := range ix {
+			if !c.valid.IsNull(j) {
+				buf = append(buf, c.timeAt(j))
+			}
+		}
+
+		if len(buf) == 0 {
+			if valid == nil {
+				valid = bitmap.New(len(indices))
+			}
+			valid.SetNull(uint32(i))
+			continue
+		}
+
+		data[i] = actualFn(buf).UnixNano()
+	}
+
+	return NewNullable(data, valid, c.loc), nil
+}
+
+// Apply1 applies fn to all non null values in ix. Null values produce NaN for float
+// results, nil for string results and null for time results. For int and bool results
+// the validity of the column must be applied by the caller.
+func (c Column) Apply1(fn interface{}, ix index.Int) (interface{}, error) {
+	switch t := fn.(type) {
+	case func(time.Time) int:
+		result := make([]int, len(c.data))
+		for _, i := range ix {
+			if !c.valid.IsNull(i) {
+				result[i] = t(c.timeAt(i))
+			}
+		}
+		return result, nil
+	case func(time.Time) float64:
+		result := make([]float64, len(c.data))
+		for _, i := range ix {
+			if c.valid.IsNull(i) {
+				result[i] = math.NaN()
+			} else {
+				result[i] = t(c.timeAt(i))
+			}
+		}
+		return result, nil
+	case func(time.Time) bool:
+		result := make([]bool, len(c.data))
+		for _, i := range ix {
+			if !c.valid.IsNull(i) {
+				result[i] = t(c.timeAt(i))
+			}
+		}
+		return result, nil
+	case func(time.Time) *string:
+		result := make([]*string, len(c.data))
+		for _, i := range ix {
+			if !c.valid.IsNull(i) {
+				result[i] = t(c.timeAt(i))
+			}
+		}
+		return result, nil
+	case func(time.Time) time.Time:
+		result := make([]int64, len(c.data))
+		for _, i := range ix {
+			if !c.valid.IsNull(i) {
+				result[i] = t(c.timeAt(i)).UnixNano()
+			}
+		}
+		return NewNullable(result, c.valid, c.loc), nil
+	case string:
+		return nil, qerrors.New("time.apply1", "unknown built in function %v", t)
+	default:
+		return nil, qerrors.New("time.apply1", "cannot apply type %#v to column", fn)
+	}
+}
+
+func (c Column) Apply2(fn interface{}, s2 column.Column, ix index.Int) (interface{}, error) {
+	s2T, ok := s2.(Column)
+	if !ok {
+		return nil, qerrors.New("time.apply2", "invalid column type %v", reflect.TypeOf(s2))
+	}
+
+	switch t := fn.(type) {
+	case func(time.Time, time.Time) time.Time:
+		valid := c.valid.And(s2T.valid)
+		result := make([]int64, len(c.data))
+		for _, i := range ix {
+			if !valid.IsNull(i) {
+				result[i] = t(c.timeAt(i), s2T.timeAt(i)).UnixNano()
+			}
+		}
+		return NewNullable(result, valid, c.loc), nil
+	case func(time.Time, time.Time) bool:
+		valid := c.valid.And(s2T.valid)
+		result := make([]bool, len(c.data))
+		for _, i := range ix {
+			if !valid.IsNull(i) {
+				result[i] = t(c.timeAt(i), s2T.timeAt(i))
+			}
+		}
+		return result, nil
+	case string:
+		return nil, qerrors.New("time.apply2", "unknown built in function %s", t)
+	default:
+		return nil, qerrors.New("time.apply2", "cannot apply type %#v to column", fn)
+	}
+}
+
+func (c Column) filterBuiltIn(index index.Int, comparator string, comparatee interface{}, bIndex index.Bool) error {
+	switch t := comparatee.(type) {
+	case time.Time:
+		filterFn, ok := filterFuncs1[comparator]
+		if !ok {
+			return qerrors.New("filter time", "unknown filter operator %v for single value argument", comparator)
+		}
+		filterFn(index, c, t.UnixNano(), bIndex)
+	case []time.Time:
+		filterFn, ok := multiInputFilterFuncs[comparator]
+		if !ok {
+			return qerrors.New("filter time", "unknown filter operator %v for multi value argument", comparator)
+		}
+		filterFn(index, c, newTimeSet(t), bIndex)
+	case Column:
+		filterFn, ok := filterFuncs2[comparator]
+		if !ok {
+			return qerrors.New("filter time", "unknown filter operator %v for column - column comparison", comparator)
+		}
+		filterFn(index, c, t, bIndex)
+	case nil:
+		filterFn, ok := filterFuncs0[comparator]
+		if !ok {
+			return qerrors.New("filter time", "unknown filter operator %v for zero argument", comparator)
+		}
+		filterFn(index, c, bIndex)
+	default:
+		return qerrors.New("filter time", "invalid comparison value type %v", reflect.TypeOf(comparatee))
+	}
+
+	return nil
+}
+
+func (c Column) filterCustom1(index index.Int, fn func(time.Time) bool, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && fn(c.timeAt(pos))
+		}
+	}
+}
+
+func (c Column) filterCustom2(index index.Int, fn func(time.Time, time.Time) bool, comparatee interface{}, bIndex index.Bool) error {
+	otherC, ok := comparatee.(Column)
+	if !ok {
+		return qerrors.New("filter time", "expected comparatee to be time column, was %v", reflect.TypeOf(comparatee))
+	}
+
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && !otherC.valid.IsNull(pos) && fn(c.timeAt(pos), otherC.timeAt(pos))
+		}
+	}
+
+	return nil
+}
+
+// Filter applies the filter to the rows in index. Null values only match the isnull
+// and neq filters, same as for string columns.
+func (c Column) Filter(index index.Int, comparator interface{}, comparatee interface{}, bIndex index.Bool) error {
+	var err error
+	switch t := comparator.(type) {
+	case string:
+		err = c.filterBuiltIn(index, t, comparatee, bIndex)
+	case func(time.Time) bool:
+		c.filterCustom1(index, t, bIndex)
+	case func(time.Time, time.Time) bool:
+		err = c.filterCustom2(index, t, comparatee, bIndex)
+	default:
+		err = qerrors.New("filter time", "invalid filter type %v", reflect.TypeOf(comparator))
+	}
+	return err
+}
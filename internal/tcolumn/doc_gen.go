@@ -0,0 +1,21 @@
+package tcolumn
+
+// Code generated from template/... DO NOT EDIT
+
+func Doc() string {
+	return "\n Built in filters\n" +
+		"  !=\n" +
+		"  <\n" +
+		"  <=\n" +
+		"  =\n" +
+		"  >\n" +
+		"  >=\n" +
+		"  in\n" +
+		"  isnotnull\n" +
+		"  isnull\n" +
+
+		"\n Built in aggregations\n" +
+		"  max\n" +
+		"  min\n" +
+		"\n"
+}
@@ -0,0 +1,94 @@
+package tcolumn
+
+import (
+	"time"
+
+	"github.com/yistabraq/qframe/filter"
+	"github.com/yistabraq/qframe/internal/index"
+)
+
+var filterFuncs0 = map[string]func(index.Int, Column, index.Bool){
+	filter.IsNull:    isNull,
+	filter.IsNotNull: isNotNull,
+}
+
+var filterFuncs1 = map[string]func(index.Int, Column, int64, index.Bool){
+	filter.Gt:  gt,
+	filter.Gte: gte,
+	filter.Lt:  lt,
+	filter.Lte: lte,
+	filter.Eq:  eq,
+	filter.Neq: neq,
+}
+
+var multiInputFilterFuncs = map[string]func(index.Int, Column, timeSet, index.Bool){
+	filter.In: in,
+}
+
+var filterFuncs2 = map[string]func(index.Int, Column, Column, index.Bool){
+	filter.Gt:  gt2,
+	filter.Gte: gte2,
+	filter.Lt:  lt2,
+	filter.Lte: lte2,
+	filter.Eq:  eq2,
+	filter.Neq: neq2,
+}
+
+type timeSet map[int64]struct{}
+
+func newTimeSet(times []time.Time) timeSet {
+	result := make(timeSet, len(times))
+	for _, t := range times {
+		result[t.UnixNano()] = struct{}{}
+	}
+
+	return result
+}
+
+func (ts timeSet) Contains(x int64) bool {
+	_, ok := ts[x]
+	return ok
+}
+
+func neq(index index.Int, c Column, comparatee int64, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = c.valid.IsNull(pos) || c.data[pos] != comparatee
+		}
+	}
+}
+
+func in(index index.Int, c Column, comparatee timeSet, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && comparatee.Contains(c.data[pos])
+		}
+	}
+}
+
+func neq2(index index.Int, col, col2 Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = col.valid.IsNull(pos) || col2.valid.IsNull(pos) || col.data[pos] != col2.data[pos]
+		}
+	}
+}
+
+func isNull(index index.Int, c Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			bIndex[i] = c.valid.IsNull(index[i])
+		}
+	}
+}
+
+func isNotNull(index index.Int, c Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			bIndex[i] = !c.valid.IsNull(index[i])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package tcolumn
+
+import (
+	"github.com/yistabraq/qframe/internal/index"
+)
+
+// Code generated from template/... DO NOT EDIT
+
+func lt(index index.Int, c Column, comparatee int64, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && c.data[pos] < comparatee
+		}
+	}
+}
+
+func lte(index index.Int, c Column, comparatee int64, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && c.data[pos] <= comparatee
+		}
+	}
+}
+
+func gt(index index.Int, c Column, comparatee int64, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && c.data[pos] > comparatee
+		}
+	}
+}
+
+func gte(index index.Int, c Column, comparatee int64, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && c.data[pos] >= comparatee
+		}
+	}
+}
+
+func eq(index index.Int, c Column, comparatee int64, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !c.valid.IsNull(pos) && c.data[pos] == comparatee
+		}
+	}
+}
+
+func lt2(index index.Int, col, col2 Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !col.valid.IsNull(pos) && !col2.valid.IsNull(pos) && col.data[pos] < col2.data[pos]
+		}
+	}
+}
+
+func lte2(index index.Int, col, col2 Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !col.valid.IsNull(pos) && !col2.valid.IsNull(pos) && col.data[pos] <= col2.data[pos]
+		}
+	}
+}
+
+func gt2(index index.Int, col, col2 Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !col.valid.IsNull(pos) && !col2.valid.IsNull(pos) && col.data[pos] > col2.data[pos]
+		}
+	}
+}
+
+func gte2(index index.Int, col, col2 Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !col.valid.IsNull(pos) && !col2.valid.IsNull(pos) && col.data[pos] >= col2.data[pos]
+		}
+	}
+}
+
+func eq2(index index.Int, col, col2 Column, bIndex index.Bool) {
+	for i, x := range bIndex {
+		if !x {
+			pos := index[i]
+			bIndex[i] = !col.valid.IsNull(pos) && !col2.valid.IsNull(pos) && col.data[pos] == col2.data[pos]
+		}
+	}
+}
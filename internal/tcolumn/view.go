@@ -0,0 +1,40 @@
+package tcolumn
+
+import (
+	"time"
+
+	"github.com/yistabraq/qframe/internal/index"
+)
+
+type View struct {
+	column Column
+	index  index.Int
+}
+
+// ItemAt returns the time at position i. The zero time is returned for null values.
+func (v View) ItemAt(i int) time.Time {
+	if v.IsNull(i) {
+		return time.Time{}
+	}
+
+	return v.column.timeAt(v.index[i])
+}
+
+// IsNull returns true if the value at position i is null.
+func (v View) IsNull(i int) bool {
+	return v.column.valid.IsNull(v.index[i])
+}
+
+func (v View) Len() int {
+	return len(v.index)
+}
+
+// Slice returns the times in the view, null values are returned as the zero time.
+func (v View) Slice() []time.Time {
+	result := make([]time.Time, v.Len())
+	for i := range v.index {
+		result[i] = v.ItemAt(i)
+	}
+
+	return result
+}
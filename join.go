@@ -0,0 +1,311 @@
+package qframe
+
+import (
+	"math"
+
+	"github.com/yistabraq/qframe/config/join"
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/bitmap"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/grouper"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/ncolumn"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+// noMatch is used in the join indices to mark rows without a match on the other side.
+const noMatch = math.MaxUint32
+
+// Join joins the QFrame with other based on the values of the key columns.
+//
+// The resulting QFrame contains the columns of the QFrame followed by the non key columns
+// of other (except for semi and anti joins where only the columns of the QFrame are kept).
+// Non key columns that exist in both QFrames are suffixed to tell them apart.
+//
+// Rows without a match in the other QFrame are filled with null.
+//
+// The rows are ordered as the rows in the QFrame (or other for right joins). For full
+// joins rows only present in other are added last.
+//
+// Time complexity O(m * n) where m = number of columns, n = number of rows in the result.
+func (qf QFrame) Join(other QFrame, configFns ...join.ConfigFunc) QFrame {
+	if qf.Err != nil {
+		return qf
+	}
+
+	if other.Err != nil {
+		return qf.withErr(qerrors.Propagate("Join", other.Err))
+	}
+
+	conf, err := join.NewConfig(configFns)
+	if err != nil {
+		return qf.withErr(qerrors.Propagate("Join", err))
+	}
+
+	keys := conf.Columns
+	if len(keys) == 0 {
+		for _, col := range qf.columns {
+			if _, ok := other.columnsByName[col.name]; ok {
+				keys = append(keys, col.name)
+			}
+		}
+
+		if len(keys) == 0 {
+			return qf.withErr(qerrors.New("Join", "no common columns to join on"))
+		}
+	}
+
+	if err := qf.checkColumns("Join", keys); err != nil {
+		return qf.withErr(err)
+	}
+
+	if err := other.checkColumns("Join", keys); err != nil {
+		return qf.withErr(err)
+	}
+
+	// The key columns of both QFrames are appended to each other to be able to hash and
+	// compare keys from both sides with the same machinery as GroupBy. Rows from other
+	// are offset by the length of the columns in the QFrame.
+	leftLen, rightLen := qf.columnLen(), other.columnLen()
+	keyColumns := make(map[string]column.Column, len(keys))
+	comparables := make([]column.Comparable, len(keys))
+	for i, key := range keys {
+		leftCol, rightCol := qf.columnsByName[key].Column, other.columnsByName[key].Column
+		if leftCol.DataType() != rightCol.DataType() {
+			return qf.withErr(qerrors.New("Join", "key column %s has different types, %s and %s",
+				key, leftCol.DataType(), rightCol.DataType()))
+		}
+
+		keyCol, err := leftCol.Append(rightCol)
+		if err != nil {
+			return qf.withErr(qerrors.Propagate("Join", err))
+		}
+
+		keyColumns[key] = keyCol
+		comparables[i] = keyCol.Comparable(false, conf.JoinOnNull, false)
+	}
+
+	ix := make(index.Int, 0, qf.Len()+other.Len())
+	ix = append(ix, qf.index...)
+	for _, i := range other.index {
+		ix = append(ix, i+leftLen)
+	}
+
+	groups, _ := grouper.GroupBy(ix, comparables)
+	leftMatches, rightMatches := make([]index.Int, leftLen), make([]index.Int, rightLen)
+	for _, group := range groups {
+		// Positions within the group are in index order, left rows before right rows.
+		split := 0
+		for split < len(group) && group[split] < leftLen {
+			split++
+		}
+
+		lefts, rights := group[:split], group[split:]
+		for _, i := range lefts {
+			leftMatches[i] = rights
+		}
+
+		for _, i := range rights {
+			rightMatches[i-leftLen] = lefts
+		}
+	}
+
+	if conf.How == "semi" || conf.How == "anti" {
+		keep := conf.How == "semi"
+		newIx := make(index.Int, 0, qf.Len())
+		for _, i := range qf.index {
+			if (len(leftMatches[i]) > 0) == keep {
+				newIx = append(newIx, i)
+			}
+		}
+
+		return qf.withIndex(newIx)
+	}
+
+	// Build pairs of left and right row positions, right positions include the offset.
+	var leftIx, rightIx index.Int
+	if conf.How == "right" {
+		for _, i := range other.index {
+			if len(rightMatches[i]) == 0 {
+				leftIx, rightIx = append(leftIx, noMatch), append(rightIx, i+leftLen)
+				continue
+			}
+
+			for _, j := range rightMatches[i] {
+				leftIx, rightIx = append(leftIx, j), append(rightIx, i+leftLen)
+			}
+		}
+	} else {
+		for _, i := range qf.index {
+			if len(leftMatches[i]) == 0 {
+				if conf.How != "inner" {
+					leftIx, rightIx = append(leftIx, i), append(rightIx, noMatch)
+				}
+				continue
+			}
+
+			for _, j := range leftMatches[i] {
+				leftIx, rightIx = append(leftIx, i), append(rightIx, j)
+			}
+		}
+
+		if conf.How == "full" {
+			for _, i := range other.index {
+				if len(rightMatches[i]) == 0 {
+					leftIx, rightIx = append(leftIx, noMatch), append(rightIx, i+leftLen)
+				}
+			}
+		}
+	}
+
+	// Key columns take their value from whichever side has one
+	keyIx := make(index.Int, len(leftIx))
+	for i, j := range leftIx {
+		if j == noMatch {
+			keyIx[i] = rightIx[i]
+		} else {
+			keyIx[i] = j
+		}
+	}
+
+	for i, j := range rightIx {
+		if j != noMatch {
+			rightIx[i] = j - leftLen
+		}
+	}
+
+	keySet := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		keySet[key] = true
+	}
+
+	leftNames := make(map[string]bool, len(qf.columns))
+	for _, col := range qf.columns {
+		leftNames[col.name] = true
+	}
+
+	newColumns := make([]namedColumn, 0, len(qf.columns)+len(other.columns))
+	for _, col := range qf.columns {
+		name := col.name
+		var newCol column.Column
+		if keySet[name] {
+			if conf.How == "inner" || conf.How == "left" {
+				newCol = col.Subset(keyIx)
+			} else {
+				newCol = keyColumns[name].Subset(keyIx)
+			}
+		} else {
+			if _, ok := other.columnsByName[name]; ok {
+				name += conf.LeftSuffix
+			}
+
+			newCol, err = subsetWithNull(col.Column, leftIx, leftLen)
+			if err != nil {
+				return qf.withErr(qerrors.Propagate("Join", err))
+			}
+		}
+
+		newColumns = append(newColumns, namedColumn{Column: newCol, name: name, pos: len(newColumns)})
+	}
+
+	for _, col := range other.columns {
+		if keySet[col.name] {
+			continue
+		}
+
+		name := col.name
+		if leftNames[name] {
+			name += conf.RightSuffix
+		}
+
+		newCol, err := subsetWithNull(col.Column, rightIx, rightLen)
+		if err != nil {
+			return qf.withErr(qerrors.Propagate("Join", err))
+		}
+
+		newColumns = append(newColumns, namedColumn{Column: newCol, name: name, pos: len(newColumns)})
+	}
+
+	newColumnsByName := make(map[string]namedColumn, len(newColumns))
+	for _, col := range newColumns {
+		if _, ok := newColumnsByName[col.name]; ok {
+			return qf.withErr(qerrors.New("Join", "duplicate column name in result: %s", col.name))
+		}
+		newColumnsByName[col.name] = col
+	}
+
+	return QFrame{columns: newColumns, columnsByName: newColumnsByName, index: index.NewAscending(uint32(len(keyIx)))}
+}
+
+// columnLen returns the length of the underlying columns, which may differ from
+// the length of the index.
+func (qf QFrame) columnLen() uint32 {
+	if len(qf.columns) == 0 {
+		return 0
+	}
+
+	return uint32(qf.columns[0].Len())
+}
+
+// subsetWithNull is like Subset but any position set to noMatch in ix is filled with null.
+func subsetWithNull(c column.Column, ix index.Int, colLen uint32) (column.Column, error) {
+	hasNoMatch := false
+	for _, i := range ix {
+		if i == noMatch {
+			hasNoMatch = true
+			break
+		}
+	}
+
+	if !hasNoMatch {
+		return c.Subset(ix), nil
+	}
+
+	var nullCol column.Column
+	switch c.(type) {
+	case icolumn.Column:
+		valid := bitmap.New(1)
+		valid.SetNull(0)
+		nullCol = icolumn.NewNullable([]int{0}, valid)
+	case fcolumn.Column:
+		nullCol = fcolumn.New([]float64{math.NaN()})
+	case bcolumn.Column:
+		valid := bitmap.New(1)
+		valid.SetNull(0)
+		nullCol = bcolumn.NewNullable([]bool{false}, valid)
+	case scolumn.Column:
+		nullCol = scolumn.New([]*string{nil})
+	case ecolumn.Column:
+		nullCol, _ = ecolumn.New([]*string{nil}, nil)
+	case tcolumn.Column:
+		valid := bitmap.New(1)
+		valid.SetNull(0)
+		nullCol = tcolumn.NewNullable([]int64{0}, valid, nil)
+	case ncolumn.Column:
+		// Only found in QFrames without rows, all values are missing.
+		return scolumn.NewConst(nil, len(ix)), nil
+	default:
+		return nil, qerrors.New("subsetWithNull", "unknown column type: %s", c.DataType())
+	}
+
+	c, err := c.Append(nullCol)
+	if err != nil {
+		return nil, err
+	}
+
+	nullIx := make(index.Int, len(ix))
+	for i, j := range ix {
+		if j == noMatch {
+			nullIx[i] = colLen
+		} else {
+			nullIx[i] = j
+		}
+	}
+
+	return c.Subset(nullIx), nil
+}
@@ -0,0 +1,455 @@
+package qframe
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yistabraq/qframe/config/csv"
+	"github.com/yistabraq/qframe/config/groupby"
+	"github.com/yistabraq/qframe/types"
+)
+
+// LazyFrame records operations on a QFrame without executing them. The operations are
+// executed, in order, when Collect is called.
+//
+// Before executing the operations the plan is optimized:
+//   - Filters are moved before filters, applies, sorts and selects when they do not depend on their results.
+//   - Consecutive filters are merged into a single AndClause.
+//   - Columns that are not needed to produce the result are dropped as early as possible.
+//     Applies producing columns that are never used are not executed at all.
+//
+// Columns are only dropped if the result is given by a Select or GroupBy, otherwise all
+// columns are part of the result. When reading from a CSV using ScanCSV columns that are
+// dropped are never parsed.
+//
+// Errors are reported through the Err field of the QFrame returned by Collect.
+type LazyFrame struct {
+	source lazySource
+	steps  []lazyStep
+}
+
+// LazyGrouper is the lazy version of a Grouper, see LazyFrame.GroupBy.
+type LazyGrouper struct {
+	lf        LazyFrame
+	configFns []groupby.ConfigFunc
+}
+
+// Lazy returns a LazyFrame with the QFrame as source.
+func (qf QFrame) Lazy() LazyFrame {
+	return LazyFrame{source: frameSource{qf: qf}}
+}
+
+// ScanCSV returns a LazyFrame that reads its source data from reader, see ReadCSV. Only the
+// columns needed to produce the result are parsed. The reader is consumed by Collect which
+// hence can only be called once.
+func ScanCSV(reader io.Reader, confFuncs ...csv.ConfigFunc) LazyFrame {
+	return LazyFrame{source: csvSource{reader: reader, confFuncs: confFuncs}}
+}
+
+func (lf LazyFrame) withStep(step lazyStep) LazyFrame {
+	steps := make([]lazyStep, len(lf.steps), len(lf.steps)+1)
+	copy(steps, lf.steps)
+	return LazyFrame{source: lf.source, steps: append(steps, step)}
+}
+
+// Filter records a filter, see QFrame.Filter.
+func (lf LazyFrame) Filter(clause FilterClause) LazyFrame {
+	return lf.withStep(filterStep{clause: clause})
+}
+
+// Select records a selection of columns, see QFrame.Select.
+func (lf LazyFrame) Select(columns ...string) LazyFrame {
+	return lf.withStep(selectStep{columns: columns})
+}
+
+// Apply records instructions to apply, see QFrame.Apply.
+func (lf LazyFrame) Apply(instructions ...Instruction) LazyFrame {
+	for _, instruction := range instructions {
+		lf = lf.withStep(applyStep{instruction: instruction})
+	}
+	return lf
+}
+
+// Sort records a sort, see QFrame.Sort.
+func (lf LazyFrame) Sort(orders ...Order) LazyFrame {
+	return lf.withStep(sortStep{orders: orders})
+}
+
+// GroupBy records a grouping, see QFrame.GroupBy. The grouping must be followed by
+// an aggregation.
+func (lf LazyFrame) GroupBy(configFns ...groupby.ConfigFunc) LazyGrouper {
+	return LazyGrouper{lf: lf, configFns: configFns}
+}
+
+// Aggregate records an aggregation of the groups, see Grouper.Aggregate.
+func (g LazyGrouper) Aggregate(aggs ...Aggregation) LazyFrame {
+	return g.lf.withStep(groupByStep{configFns: g.configFns, aggs: aggs})
+}
+
+// Collect optimizes and executes the recorded operations and returns the result.
+func (lf LazyFrame) Collect() QFrame {
+	columns, steps := lf.optimize()
+	qf := lf.source.read(columns)
+	for _, step := range steps {
+		qf = step.execute(qf)
+	}
+
+	return qf
+}
+
+// Explain returns a description of the optimized plan, one operation per line.
+func (lf LazyFrame) Explain() string {
+	columns, steps := lf.optimize()
+	lines := []string{lf.source.String()}
+	if columns != nil {
+		lines[0] += fmt.Sprintf(", columns: %s", strings.Join(columns, ", "))
+	}
+
+	for _, step := range steps {
+		lines = append(lines, step.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// optimize returns the columns needed from the source, nil if all columns are needed,
+// and the optimized steps.
+func (lf LazyFrame) optimize() ([]string, []lazyStep) {
+	steps := mergeFilters(pushDownFilters(lf.steps))
+	return pruneColumns(steps)
+}
+
+// pushDownFilters moves every filter before the steps preceding it that it does not depend on.
+func pushDownFilters(steps []lazyStep) []lazyStep {
+	result := make([]lazyStep, 0, len(steps))
+	for _, step := range steps {
+		f, ok := step.(filterStep)
+		if !ok {
+			result = append(result, step)
+			continue
+		}
+
+		columns := clauseColumns(f.clause)
+		pos := len(result)
+		for pos > 0 && canPushFilter(result[pos-1], columns) {
+			pos--
+		}
+
+		result = append(result, nil)
+		copy(result[pos+1:], result[pos:])
+		result[pos] = f
+	}
+
+	return result
+}
+
+// canPushFilter returns true if a filter on columns can be executed before step.
+func canPushFilter(step lazyStep, columns []string) bool {
+	switch s := step.(type) {
+	case filterStep, sortStep:
+		return true
+	case applyStep:
+		return !contains(columns, s.instruction.DstCol)
+	case selectStep:
+		for _, c := range columns {
+			if !contains(s.columns, c) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// mergeFilters merges consecutive filters into one.
+func mergeFilters(steps []lazyStep) []lazyStep {
+	result := make([]lazyStep, 0, len(steps))
+	for _, step := range steps {
+		f, ok := step.(filterStep)
+		if !ok || len(result) == 0 {
+			result = append(result, step)
+			continue
+		}
+
+		prev, ok := result[len(result)-1].(filterStep)
+		if !ok {
+			result = append(result, step)
+			continue
+		}
+
+		result[len(result)-1] = filterStep{clause: And(append(andClauses(prev.clause), andClauses(f.clause)...)...)}
+	}
+
+	return result
+}
+
+func andClauses(clause FilterClause) []FilterClause {
+	if c, ok := clause.(AndClause); ok && c.Err() == nil {
+		return c.subClauses
+	}
+
+	return []FilterClause{clause}
+}
+
+// pruneColumns removes applies whose result is never used and returns the columns
+// needed from the source together with the remaining steps.
+func pruneColumns(steps []lazyStep) ([]string, []lazyStep) {
+	// The set of columns needed by the following steps, nil means all columns
+	var live map[string]bool
+	use := func(columns ...string) {
+		if live == nil {
+			return
+		}
+
+		for _, c := range columns {
+			if c != "" {
+				live[c] = true
+			}
+		}
+	}
+
+	result := make([]lazyStep, 0, len(steps))
+	for i := len(steps) - 1; i >= 0; i-- {
+		switch s := steps[i].(type) {
+		case selectStep:
+			live = map[string]bool{}
+			use(s.columns...)
+		case groupByStep:
+			live = map[string]bool{}
+			use(groupby.NewConfig(s.configFns).Columns...)
+			for _, agg := range s.aggs {
+				use(agg.Column)
+			}
+		case applyStep:
+			if live != nil {
+				if !live[s.instruction.DstCol] {
+					continue
+				}
+				delete(live, s.instruction.DstCol)
+			}
+			use(s.instruction.SrcCol1, s.instruction.SrcCol2)
+		case filterStep:
+			use(clauseColumns(s.clause)...)
+		case sortStep:
+			for _, o := range s.orders {
+				use(o.Column)
+			}
+		}
+
+		result = append(result, steps[i])
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	if live == nil {
+		return nil, result
+	}
+
+	columns := make([]string, 0, len(live))
+	for c := range live {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	return columns, result
+}
+
+// clauseColumns returns the names of all columns referenced by clause.
+func clauseColumns(clause FilterClause) []string {
+	switch c := clause.(type) {
+	case Filter:
+		if name, ok := c.Arg.(types.ColumnName); ok {
+			return []string{c.Column, string(name)}
+		}
+		return []string{c.Column}
+	case AndClause:
+		return subClauseColumns(c.subClauses)
+	case OrClause:
+		return subClauseColumns(c.subClauses)
+	case NotClause:
+		return clauseColumns(c.subClause)
+	}
+
+	return nil
+}
+
+func subClauseColumns(clauses []FilterClause) []string {
+	var result []string
+	for _, c := range clauses {
+		result = append(result, clauseColumns(c)...)
+	}
+	return result
+}
+
+func contains(s []string, x string) bool {
+	for _, e := range s {
+		if e == x {
+			return true
+		}
+	}
+	return false
+}
+
+type lazySource interface {
+	fmt.Stringer
+
+	// read returns the source data, only the given columns are needed, nil means all columns.
+	read(columns []string) QFrame
+}
+
+type frameSource struct {
+	qf QFrame
+}
+
+func (s frameSource) read(columns []string) QFrame {
+	if s.qf.Err != nil || columns == nil {
+		return s.qf
+	}
+
+	// Keep the original column order, unknown columns are reported by the step using them
+	keep := make([]string, 0, len(columns))
+	for _, c := range s.qf.ColumnNames() {
+		if contains(columns, c) {
+			keep = append(keep, c)
+		}
+	}
+
+	if len(keep) == 0 {
+		// Keep all columns to retain the number of rows
+		return s.qf
+	}
+
+	return s.qf.Select(keep...)
+}
+
+func (s frameSource) String() string {
+	return "Source: QFrame"
+}
+
+type csvSource struct {
+	reader    io.Reader
+	confFuncs []csv.ConfigFunc
+}
+
+func (s csvSource) read(columns []string) QFrame {
+	if len(columns) == 0 {
+		return ReadCSV(s.reader, s.confFuncs...)
+	}
+
+	confFuncs := make([]csv.ConfigFunc, len(s.confFuncs), len(s.confFuncs)+1)
+	copy(confFuncs, s.confFuncs)
+	return ReadCSV(s.reader, append(confFuncs, csv.Columns(columns))...)
+}
+
+func (s csvSource) String() string {
+	return "Source: CSV"
+}
+
+type lazyStep interface {
+	fmt.Stringer
+	execute(qf QFrame) QFrame
+}
+
+type filterStep struct {
+	clause FilterClause
+}
+
+func (s filterStep) execute(qf QFrame) QFrame {
+	return qf.Filter(s.clause)
+}
+
+func (s filterStep) String() string {
+	return "Filter: " + s.clause.String()
+}
+
+type selectStep struct {
+	columns []string
+}
+
+func (s selectStep) execute(qf QFrame) QFrame {
+	return qf.Select(s.columns...)
+}
+
+func (s selectStep) String() string {
+	return "Select: " + strings.Join(s.columns, ", ")
+}
+
+type applyStep struct {
+	instruction Instruction
+}
+
+func (s applyStep) execute(qf QFrame) QFrame {
+	return qf.Apply(s.instruction)
+}
+
+func (s applyStep) String() string {
+	fn := fmt.Sprintf("%v", s.instruction.Fn)
+	if reflect.ValueOf(s.instruction.Fn).Kind() == reflect.Func {
+		fn = fmt.Sprintf("%T", s.instruction.Fn)
+	}
+
+	args := make([]string, 0, 2)
+	for _, c := range []string{s.instruction.SrcCol1, s.instruction.SrcCol2} {
+		if c != "" {
+			args = append(args, c)
+		}
+	}
+
+	return fmt.Sprintf("Apply: %s = %s(%s)", s.instruction.DstCol, fn, strings.Join(args, ", "))
+}
+
+type sortStep struct {
+	orders []Order
+}
+
+func (s sortStep) execute(qf QFrame) QFrame {
+	return qf.Sort(s.orders...)
+}
+
+func (s sortStep) String() string {
+	orders := make([]string, len(s.orders))
+	for i, o := range s.orders {
+		orders[i] = o.Column
+		if o.Reverse {
+			orders[i] += " desc"
+		}
+		if o.NullLast {
+			orders[i] += " nulls last"
+		}
+	}
+
+	return "Sort: " + strings.Join(orders, ", ")
+}
+
+type groupByStep struct {
+	configFns []groupby.ConfigFunc
+	aggs      []Aggregation
+}
+
+func (s groupByStep) execute(qf QFrame) QFrame {
+	return qf.GroupBy(s.configFns...).Aggregate(s.aggs...)
+}
+
+func (s groupByStep) String() string {
+	aggs := make([]string, len(s.aggs))
+	for i, agg := range s.aggs {
+		fn := fmt.Sprintf("%v", agg.Fn)
+		if reflect.ValueOf(agg.Fn).Kind() == reflect.Func {
+			fn = fmt.Sprintf("%T", agg.Fn)
+		}
+
+		aggs[i] = fmt.Sprintf("%s(%s)", fn, agg.Column)
+		if agg.As != "" {
+			aggs[i] += " as " + agg.As
+		}
+	}
+
+	return fmt.Sprintf("GroupBy: %s, Aggregate: %s",
+		strings.Join(groupby.NewConfig(s.configFns).Columns, ", "), strings.Join(aggs, ", "))
+}
@@ -0,0 +1,181 @@
+//go:build ignore
+// +build ignore
+
+// Utility program for cross implementation tests of the parquet format.
+//
+// Writes the test files using the parquet implementation of the Go Arrow library.
+// Run from a module that requires github.com/apache/arrow/go/v12:
+// go run generate.go
+
+package main
+
+import (
+	"os"
+
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/compress"
+	"github.com/apache/arrow/go/v12/parquet/file"
+	"github.com/apache/arrow/go/v12/parquet/schema"
+)
+
+func node(name string, rep parquet.Repetition, typ parquet.Type) schema.Node {
+	var n *schema.PrimitiveNode
+	var err error
+	if typ == parquet.Types.ByteArray {
+		n, err = schema.NewPrimitiveNodeLogical(name, rep, schema.StringLogicalType{}, typ, -1, -1)
+	} else {
+		n, err = schema.NewPrimitiveNode(name, rep, typ, -1, -1)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func byteArrays(values ...string) []parquet.ByteArray {
+	result := make([]parquet.ByteArray, len(values))
+	for i, v := range values {
+		result[i] = parquet.ByteArray(v)
+	}
+	return result
+}
+
+// column holds the non null values of a column and the definition levels, nil for required columns.
+type column struct {
+	values interface{}
+	levels []int16
+}
+
+func write(fileName string, fields []schema.Node, columns []column, rowGroupLen int, props ...parquet.WriterProperty) {
+	root, err := schema.NewGroupNode("schema", parquet.Repetitions.Required, fields, -1)
+	if err != nil {
+		panic(err)
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	w := file.NewParquetWriter(f, root, file.WithWriterProps(parquet.NewWriterProperties(props...)))
+	defer w.Close()
+
+	rowCount := len(columns[0].levels)
+	if columns[0].levels == nil {
+		rowCount = len(columns[0].values.([]int64))
+	}
+
+	// Split data into row groups, keeping track of the position in the values of each column
+	valuePos := make([]int, len(columns))
+	for start := 0; start < rowCount; start += rowGroupLen {
+		end := start + rowGroupLen
+		if end > rowCount {
+			end = rowCount
+		}
+
+		rgw := w.AppendRowGroup()
+		for i, c := range columns {
+			var levels []int16
+			valueCount := end - start
+			if c.levels != nil {
+				levels = c.levels[start:end]
+				valueCount = 0
+				for _, l := range levels {
+					valueCount += int(l)
+				}
+			}
+
+			from, to := valuePos[i], valuePos[i]+valueCount
+			valuePos[i] = to
+			cw, err := rgw.NextColumn()
+			if err != nil {
+				panic(err)
+			}
+
+			switch v := c.values.(type) {
+			case []int32:
+				_, err = cw.(*file.Int32ColumnChunkWriter).WriteBatch(v[from:to], levels, nil)
+			case []int64:
+				_, err = cw.(*file.Int64ColumnChunkWriter).WriteBatch(v[from:to], levels, nil)
+			case []float32:
+				_, err = cw.(*file.Float32ColumnChunkWriter).WriteBatch(v[from:to], levels, nil)
+			case []float64:
+				_, err = cw.(*file.Float64ColumnChunkWriter).WriteBatch(v[from:to], levels, nil)
+			case []bool:
+				_, err = cw.(*file.BooleanColumnChunkWriter).WriteBatch(v[from:to], levels, nil)
+			case []parquet.ByteArray:
+				_, err = cw.(*file.ByteArrayColumnChunkWriter).WriteBatch(v[from:to], levels, nil)
+			}
+
+			if err != nil {
+				panic(err)
+			}
+
+			if err := cw.Close(); err != nil {
+				panic(err)
+			}
+		}
+
+		if err := rgw.Close(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func main() {
+	required, optional := parquet.Repetitions.Required, parquet.Repetitions.Optional
+
+	// Required columns of all supported types, plain encoded and not compressed
+	write("plain.parquet",
+		[]schema.Node{
+			node("i64", required, parquet.Types.Int64),
+			node("i32", required, parquet.Types.Int32),
+			node("f64", required, parquet.Types.Double),
+			node("f32", required, parquet.Types.Float),
+			node("bool", required, parquet.Types.Boolean),
+			node("string", required, parquet.Types.ByteArray)},
+		[]column{
+			{values: []int64{1, -2, 3}},
+			{values: []int32{4, 5, -6}},
+			{values: []float64{1.5, 2.5, -3.5}},
+			{values: []float32{0.5, 1.25, 2}},
+			{values: []bool{true, false, true}},
+			{values: byteArrays("foo", "bar", "")}},
+		3,
+		parquet.WithDictionaryDefault(false),
+		parquet.WithCompression(compress.Codecs.Uncompressed))
+
+	// Optional, dictionary encoded, columns split in two row groups with snappy compressed pages
+	fields := []schema.Node{
+		node("string", optional, parquet.Types.ByteArray),
+		node("i64", optional, parquet.Types.Int64),
+		node("f64", optional, parquet.Types.Double),
+		node("bool", optional, parquet.Types.Boolean)}
+	columns := []column{
+		{values: byteArrays("foo", "bar", "foo"), levels: []int16{1, 0, 1, 1}},
+		{values: []int64{1, 1, 2}, levels: []int16{1, 0, 1, 1}},
+		{values: []float64{1.5, 1.5, 2.5}, levels: []int16{1, 1, 0, 1}},
+		{values: []bool{true, false, true}, levels: []int16{1, 0, 1, 1}}}
+	write("dictionary.parquet", fields, columns, 2,
+		parquet.WithDictionaryDefault(true),
+		parquet.WithCompression(compress.Codecs.Snappy))
+
+	// Same data using version 2 data pages with gzip compression
+	write("v2.parquet", fields, columns, 2,
+		parquet.WithDictionaryDefault(true),
+		parquet.WithDataPageVersion(parquet.DataPageV2),
+		parquet.WithVersion(parquet.V2_LATEST),
+		parquet.WithCompression(compress.Codecs.Gzip))
+
+	// Dictionary encoding falls back to plain encoding when the dictionary grows too big
+	write("fallback.parquet",
+		[]schema.Node{node("string", optional, parquet.Types.ByteArray)},
+		[]column{{values: byteArrays("a", "b", "c", "d", "e", "f"), levels: []int16{1, 1, 1, 1, 1, 1}}},
+		6,
+		parquet.WithDictionaryDefault(true),
+		parquet.WithDictionaryPageSizeLimit(8),
+		parquet.WithBatchSize(2),
+		parquet.WithCompression(compress.Codecs.Snappy))
+}
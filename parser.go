@@ -0,0 +1,378 @@
+package qframe
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// ParseExpr parses a textual expression into an Expression that can be passed to Eval.
+//
+// The following constructs are supported:
+//
+//	Column names    - col, "quoted col name" (use "" to include a " in a quoted name)
+//	Literals        - 42, 1.5, 1e-3, 'string' (use '' to include a ' in a string), true, false, null
+//	Function calls  - abs(x), str(a), name(a, b, c), any function in the eval.Context or one
+//	                  of the conditional expressions described in Expr, eg. if(a > b, a, b)
+//	Infix operators - | & == != < <= > >= + - * / in increasing order of precedence
+//	Prefix operators - ! and - (evaluated as x * -1 unless applied to a numeric literal)
+//	Parentheses     - (a + b) * c
+//
+// All operators are left associative. Functions and operators are resolved by name, using the
+// type of their first argument, in the eval.Context when the expression is evaluated.
+// Unquoted column names must start with a letter or underscore followed by any number of
+// letters, digits and underscores. Columns named true, false or null must be quoted.
+//
+// Parse errors are reported through the Err method of the returned Expression and include
+// the position, in bytes starting from 1, where the error was found.
+//
+// Example:
+//
+//	ParseExpr("a * 2 + abs(b)") is equal to Expr("+", Expr("*", types.ColumnName("a"), 2), Expr("abs", types.ColumnName("b")))
+func ParseExpr(expr string) Expression {
+	p := &parser{lexer: lexer{input: expr}}
+	if err := p.next(); err != nil {
+		return errorExpr{err: err}
+	}
+
+	node, err := p.parseBinary(0)
+	if err != nil {
+		return errorExpr{err: err}
+	}
+
+	if p.tok.kind != tokEOF {
+		return errorExpr{err: p.unexpected()}
+	}
+
+	return Val(node)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokQuotedIdent
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func parseErr(pos int, reason string, params ...interface{}) error {
+	return qerrors.New("ParseExpr", reason+" at position %d", append(params, pos+1)...)
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) {
+		r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.pos += size
+	}
+
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	c := l.input[l.pos]
+	r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, value: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, value: ")", pos: start}, nil
+	case c == ',':
+		l.pos++
+		return token{kind: tokComma, value: ",", pos: start}, nil
+	case c == '\'' || c == '"':
+		s, err := l.quoted(c)
+		if err != nil {
+			return token{}, err
+		}
+
+		kind := tokString
+		if c == '"' {
+			kind = tokQuotedIdent
+		}
+		return token{kind: kind, value: s, pos: start}, nil
+	case c >= '0' && c <= '9' || c == '.':
+		for l.pos < len(l.input) && (isDigit(l.input[l.pos]) || l.input[l.pos] == '.') {
+			l.pos++
+		}
+
+		// Exponent
+		if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
+			l.pos++
+			if l.pos < len(l.input) && (l.input[l.pos] == '+' || l.input[l.pos] == '-') {
+				l.pos++
+			}
+			for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+				l.pos++
+			}
+		}
+		return token{kind: tokNumber, value: l.input[start:l.pos], pos: start}, nil
+	case r == '_' || unicode.IsLetter(r):
+		for l.pos < len(l.input) {
+			r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+			if r != '_' && !unicode.IsDigit(r) && !unicode.IsLetter(r) {
+				break
+			}
+			l.pos += size
+		}
+		return token{kind: tokIdent, value: l.input[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "&", "|", "!"} {
+		if strings.HasPrefix(l.input[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOperator, value: op, pos: start}, nil
+		}
+	}
+
+	return token{}, parseErr(start, "unexpected character '%s'", l.input[start:start+size])
+}
+
+// quoted reads a string delimited by quote, two consecutive quotes are read as one.
+func (l *lexer) quoted(quote byte) (string, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		l.pos++
+		if c != quote {
+			b.WriteByte(c)
+			continue
+		}
+
+		if l.pos < len(l.input) && l.input[l.pos] == quote {
+			b.WriteByte(quote)
+			l.pos++
+			continue
+		}
+
+		return b.String(), nil
+	}
+
+	return "", parseErr(start, "unterminated quote %c", quote)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Binary operators grouped by precedence, lowest first.
+var binaryOperators = [][]string{
+	{"|"},
+	{"&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+// parser is a recursive descent parser that produces the nested structures accepted by Expr.
+// Nodes are either constants, column names or Expressions.
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+func (p *parser) next() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return parseErr(p.tok.pos, "unexpected end of expression")
+	}
+
+	return parseErr(p.tok.pos, "unexpected '%s'", p.tok.value)
+}
+
+func (p *parser) isOperator(ops []string) bool {
+	if p.tok.kind != tokOperator {
+		return false
+	}
+
+	for _, op := range ops {
+		if p.tok.value == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) parseBinary(level int) (interface{}, error) {
+	if level == len(binaryOperators) {
+		return p.parseUnary()
+	}
+
+	lhs, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(binaryOperators[level]) {
+		op := p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		rhs, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		lhs = Expr(op, lhs, rhs)
+	}
+
+	return lhs, nil
+}
+
+func (p *parser) parseUnary() (interface{}, error) {
+	if !p.isOperator([]string{"!", "-"}) {
+		return p.parsePrimary()
+	}
+
+	op := p.tok.value
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "!" {
+		return Expr(op, operand), nil
+	}
+
+	switch t := operand.(type) {
+	case int:
+		return -t, nil
+	case float64:
+		return -t, nil
+	}
+
+	return Expr("*", operand, -1), nil
+}
+
+func (p *parser) parsePrimary() (interface{}, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokNumber:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if i, err := strconv.Atoi(tok.value); err == nil {
+			return i, nil
+		}
+
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, parseErr(tok.pos, "invalid number '%s'", tok.value)
+		}
+		return f, nil
+	case tokString:
+		return tok.value, p.next()
+	case tokQuotedIdent:
+		return types.ColumnName(tok.value), p.next()
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		node, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind != tokRParen {
+			return nil, p.unexpected()
+		}
+		return node, p.next()
+	case tokIdent:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind == tokLParen {
+			return p.parseCall(tok)
+		}
+
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return types.ColumnName(tok.value), nil
+	}
+
+	return nil, p.unexpected()
+}
+
+func (p *parser) parseCall(name token) (interface{}, error) {
+	// Skip the opening parenthesis
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	var args []interface{}
+	for p.tok.kind != tokRParen {
+		if len(args) > 0 {
+			if p.tok.kind != tokComma {
+				return nil, p.unexpected()
+			}
+
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+
+		arg, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	if len(args) == 0 {
+		return nil, parseErr(name.pos, "function '%s' requires at least one argument", name.value)
+	}
+
+	return Expr(name.value, args...), p.next()
+}
@@ -0,0 +1,122 @@
+package qframe
+
+import (
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// Columns of different types used together in two argument functions are implicitly
+// converted to a common type according to the following rules:
+//
+//	int and float   - float
+//	bool and int    - int, true => 1 and false => 0
+//	bool and float  - float, true => 1 and false => 0
+//	enum and string - string
+//
+// Null values remain null. Any other combination of types is an error.
+
+type typePair struct {
+	t1, t2 types.DataType
+}
+
+var promotions = map[typePair]types.DataType{
+	{types.Int, types.Float}:   types.Float,
+	{types.Bool, types.Int}:    types.Int,
+	{types.Bool, types.Float}:  types.Float,
+	{types.Enum, types.String}: types.String,
+}
+
+// promotedType returns the type that columns of type t1 and t2 are converted to when used together.
+func promotedType(t1, t2 types.DataType) (types.DataType, error) {
+	if t1 == t2 {
+		return t1, nil
+	}
+
+	if t, ok := promotions[typePair{t1, t2}]; ok {
+		return t, nil
+	}
+
+	if t, ok := promotions[typePair{t2, t1}]; ok {
+		return t, nil
+	}
+
+	return types.None, qerrors.New("promotedType", "cannot combine columns of type %s and %s", t1, t2)
+}
+
+// promoteColumns converts c1 and c2, for the rows in ix, to a common type.
+func promoteColumns(c1, c2 column.Column, ix index.Int) (column.Column, column.Column, error) {
+	if c1.DataType() == c2.DataType() || c1.DataType() == types.Undefined || c2.DataType() == types.Undefined {
+		return c1, c2, nil
+	}
+
+	typ, err := promotedType(c1.DataType(), c2.DataType())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c1, err = convertColumn(c1, typ, ix); err != nil {
+		return nil, nil, err
+	}
+
+	if c2, err = convertColumn(c2, typ, ix); err != nil {
+		return nil, nil, err
+	}
+
+	return c1, c2, nil
+}
+
+// convertColumn converts the values of c in the rows in ix to typ.
+func convertColumn(c column.Column, typ types.DataType, ix index.Int) (column.Column, error) {
+	if c.DataType() == typ {
+		return c, nil
+	}
+
+	var fn interface{}
+	switch {
+	case c.DataType() == types.Int && typ == types.Float:
+		fn = func(x int) float64 { return float64(x) }
+	case c.DataType() == types.Bool && typ == types.Int:
+		fn = func(x bool) int {
+			if x {
+				return 1
+			}
+			return 0
+		}
+	case c.DataType() == types.Bool && typ == types.Float:
+		fn = func(x bool) float64 {
+			if x {
+				return 1
+			}
+			return 0
+		}
+	case c.DataType() == types.Enum && typ == types.String:
+		fn = func(x *string) *string { return x }
+	default:
+		return nil, qerrors.New("convertColumn", "cannot convert %s to %s", c.DataType(), typ)
+	}
+
+	result, err := c.Apply1(fn, ix)
+	if err != nil {
+		return nil, qerrors.Propagate("convertColumn", err)
+	}
+
+	switch t := result.(type) {
+	case []float64:
+		// Nulls are converted to NaN by Apply1
+		return fcolumn.New(t), nil
+	case []int:
+		return icolumn.NewNullable(t, c.(bcolumn.Column).Validity()), nil
+	case []*string:
+		return scolumn.New(t), nil
+	case column.Column:
+		return t, nil
+	}
+
+	return nil, qerrors.New("convertColumn", "unexpected conversion result %T", result)
+}
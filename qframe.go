@@ -1,6 +1,7 @@
 package qframe
 
 import (
+	"context"
 	"database/sql"
 	stdcsv "encoding/csv"
 	"fmt"
@@ -8,16 +9,18 @@ import (
 	"reflect"
 	"sort"
 	"strings"
-
-	"github.com/yistabraq/qframe/config/rolling"
+	"time"
 
 	"github.com/yistabraq/qframe/config/csv"
 	"github.com/yistabraq/qframe/config/eval"
 	"github.com/yistabraq/qframe/config/groupby"
+	"github.com/yistabraq/qframe/config/json"
 	"github.com/yistabraq/qframe/config/newqf"
+	"github.com/yistabraq/qframe/config/parquet"
 	qsql "github.com/yistabraq/qframe/config/sql"
 	"github.com/yistabraq/qframe/filter"
 	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/bitmap"
 	"github.com/yistabraq/qframe/internal/column"
 	"github.com/yistabraq/qframe/internal/ecolumn"
 	"github.com/yistabraq/qframe/internal/fcolumn"
@@ -25,11 +28,14 @@ import (
 	"github.com/yistabraq/qframe/internal/icolumn"
 	"github.com/yistabraq/qframe/internal/index"
 	qfio "github.com/yistabraq/qframe/internal/io"
+	qfarrowio "github.com/yistabraq/qframe/internal/io/arrow"
+	qfparquetio "github.com/yistabraq/qframe/internal/io/parquet"
 	qfsqlio "github.com/yistabraq/qframe/internal/io/sql"
 	"github.com/yistabraq/qframe/internal/math/integer"
 	"github.com/yistabraq/qframe/internal/scolumn"
 	qfsort "github.com/yistabraq/qframe/internal/sort"
 	qfstrings "github.com/yistabraq/qframe/internal/strings"
+	"github.com/yistabraq/qframe/internal/tcolumn"
 	"github.com/yistabraq/qframe/qerrors"
 	"github.com/yistabraq/qframe/types"
 
@@ -127,6 +133,8 @@ func createColumn(name string, data interface{}, config *newqf.Config) (column.C
 			}
 			// Book keeping
 			delete(config.EnumColumns, name)
+		} else if c, ok := autoEnum(t, config); ok {
+			localS = c
 		} else {
 			localS = scolumn.New(t)
 		}
@@ -142,10 +150,57 @@ func createColumn(name string, data interface{}, config *newqf.Config) (column.C
 			localS = scolumn.NewConst(t.Val, t.Count)
 		}
 
+	case []*int:
+		data, valid := make([]int, len(t)), bitmap.Bitmap(nil)
+		for i, p := range t {
+			if p != nil {
+				data[i] = *p
+				continue
+			}
+
+			if valid == nil {
+				valid = bitmap.New(len(t))
+			}
+			valid.SetNull(uint32(i))
+		}
+		localS = icolumn.NewNullable(data, valid)
 	case []bool:
 		localS = bcolumn.New(t)
+	case []*bool:
+		data, valid := make([]bool, len(t)), bitmap.Bitmap(nil)
+		for i, p := range t {
+			if p != nil {
+				data[i] = *p
+				continue
+			}
+
+			if valid == nil {
+				valid = bitmap.New(len(t))
+			}
+			valid.SetNull(uint32(i))
+		}
+		localS = bcolumn.NewNullable(data, valid)
 	case ConstBool:
 		localS = bcolumn.NewConst(t.Val, t.Count)
+	case []time.Time:
+		localS = tcolumn.New(t)
+	case []*time.Time:
+		data, valid, loc := make([]int64, len(t)), bitmap.Bitmap(nil), (*time.Location)(nil)
+		for i, p := range t {
+			if p != nil {
+				data[i] = p.UnixNano()
+				if loc == nil {
+					loc = p.Location()
+				}
+				continue
+			}
+
+			if valid == nil {
+				valid = bitmap.New(len(t))
+			}
+			valid.SetNull(uint32(i))
+		}
+		localS = tcolumn.NewNullable(data, valid, loc)
 	case ecolumn.Column:
 		localS = t
 	case qfstrings.StringBlob:
@@ -158,6 +213,16 @@ func createColumn(name string, data interface{}, config *newqf.Config) (column.C
 	return localS, nil
 }
 
+// autoEnum returns data as an enum column if automatic enums are enabled and
+// data has few enough distinct values.
+func autoEnum(data []*string, config *newqf.Config) (ecolumn.Column, bool) {
+	if config.AutoEnumMaxCardinality <= 0 {
+		return ecolumn.Column{}, false
+	}
+
+	return ecolumn.NewAuto(data, config.AutoEnumMaxCardinality, config.AutoEnumMaxRatio)
+}
+
 // New creates a new QFrame with column content from data.
 //
 // Time complexity O(m * n) where m = number of columns, n = number of rows.
@@ -572,7 +637,8 @@ func (qf QFrame) GroupBy(configFns ...groupby.ConfigFunc) Grouper {
 		return Grouper{Err: err}
 	}
 
-	g := Grouper{columns: qf.columns, columnsByName: qf.columnsByName, groupedColumns: config.Columns}
+	g := Grouper{columns: qf.columns, columnsByName: qf.columnsByName, groupedColumns: config.Columns, index: qf.index,
+		parallelism: config.Parallelism}
 	if qf.Len() == 0 {
 		return g
 	}
@@ -584,36 +650,12 @@ func (qf QFrame) GroupBy(configFns ...groupby.ConfigFunc) Grouper {
 
 	orders := qf.orders(config.Columns)
 	comparables := qf.comparables(config.Columns, orders, config.GroupByNull)
-	indices, stats := grouper.GroupBy(qf.index, comparables)
+	indices, stats := grouper.GroupByParallel(qf.index, comparables, config.Parallelism)
 	g.indices = indices
 	g.Stats = GroupStats(stats)
 	return g
 }
 
-func (qf QFrame) Rolling(fn types.SliceFuncOrBuiltInId, dstCol, srcCol string, configFns ...rolling.ConfigFunc) QFrame {
-	if qf.Err != nil {
-		return qf
-	}
-
-	conf, err := rolling.NewConfig(configFns)
-	if err != nil {
-		return qf.withErr(err)
-	}
-
-	namedColumn, ok := qf.columnsByName[srcCol]
-	if !ok {
-		return qf.withErr(qerrors.New("Rolling", unknownCol(srcCol)))
-	}
-
-	srcColumn := namedColumn.Column
-	resultColumn, err := srcColumn.Rolling(fn, qf.index, conf)
-	if err != nil {
-		return qf.withErr(qerrors.Propagate("Rolling", err))
-	}
-
-	return qf.setColumn(dstCol, resultColumn)
-}
-
 func fixLengthString(s string, pad string, desiredLen int) string {
 	// NB: Assumes desiredLen to be >= 3
 	if len(s) > desiredLen {
@@ -831,14 +873,25 @@ func (qf QFrame) apply1(fn types.DataFuncOrBuiltInId, dstCol, srcCol string) QFr
 		return qf.withErr(qerrors.Propagate("apply1", err))
 	}
 
+	// Null values in the source column remain null in int and bool results
+	var valid bitmap.Bitmap
+	switch c := srcColumn.(type) {
+	case icolumn.Column:
+		valid = c.Validity()
+	case bcolumn.Column:
+		valid = c.Validity()
+	case tcolumn.Column:
+		valid = c.Validity()
+	}
+
 	var resultColumn column.Column
 	switch t := sliceResult.(type) {
 	case []int:
-		resultColumn = icolumn.New(t)
+		resultColumn = icolumn.NewNullable(t, valid)
 	case []float64:
 		resultColumn = fcolumn.New(t)
 	case []bool:
-		resultColumn = bcolumn.New(t)
+		resultColumn = bcolumn.NewNullable(t, valid)
 	case []*string:
 		resultColumn = scolumn.New(t)
 	case column.Column:
@@ -868,11 +921,38 @@ func (qf QFrame) apply2(fn types.DataFuncOrBuiltInId, dstCol, srcCol1, srcCol2 s
 	}
 	srcColumn2 := namedSrcColumn2.Column
 
-	resultColumn, err := srcColumn1.Apply2(fn, srcColumn2, qf.index)
+	srcColumn1, srcColumn2, err := promoteColumns(srcColumn1, srcColumn2, qf.index)
 	if err != nil {
 		return qf.withErr(qerrors.Propagate("apply2", err))
 	}
 
+	result, err := srcColumn1.Apply2(fn, srcColumn2, qf.index)
+	if err != nil {
+		return qf.withErr(qerrors.Propagate("apply2", err))
+	}
+
+	var resultColumn column.Column
+	switch t := result.(type) {
+	case []bool:
+		// Boolean results, eg. from comparisons, are null if any of the arguments is null
+		comp1 := srcColumn1.Comparable(false, false, false)
+		comp2 := srcColumn2.Comparable(false, false, false)
+		var valid bitmap.Bitmap
+		for _, i := range qf.index {
+			if isNull(comp1, i) || isNull(comp2, i) {
+				if valid == nil {
+					valid = bitmap.New(len(t))
+				}
+				valid.SetNull(i)
+			}
+		}
+		resultColumn = bcolumn.NewNullable(t, valid)
+	case column.Column:
+		resultColumn = t
+	default:
+		return qf.withErr(qerrors.New("apply2", "unexpected type of new columns %#v", t))
+	}
+
 	return qf.setColumn(dstCol, resultColumn)
 }
 
@@ -894,6 +974,8 @@ type Instruction struct {
 
 	// SrcCol2 is the second column to take arguments to Fn from.
 	// This field is optional and must only be set if Fn takes two arguments.
+	// If SrcCol1 and SrcCol2 are of different types they are converted to a common
+	// type, eg. int and float columns are both converted to float, before Fn is applied.
 	SrcCol2 string
 }
 
@@ -998,8 +1080,8 @@ func (qf QFrame) Append(qff ...QFrame) QFrame {
 	// TODO: Check error status on all involved QFrames
 	// TODO: Check that all columns have the same length? This should always be true.
 	result := qf
-	appendCols := make([]column.Column, 0, len(qff))
 	for _, col := range qf.columns {
+		appendCols := make([]column.Column, 0, len(qff))
 		for _, otherQf := range qff {
 			// TODO: Verify that column exists
 			appendCols = append(appendCols, otherQf.columnsByName[col.name].Column)
@@ -1053,7 +1135,65 @@ func ReadCSV(reader io.Reader, confFuncs ...csv.ConfigFunc) QFrame {
 	return New(data, newqf.ColumnOrder(columns...))
 }
 
+// CSVChunkReader reads CSV data as a sequence of QFrames, see NewCSVChunkReader.
+type CSVChunkReader struct {
+	reader *qfio.CSVChunkReader
+	err    error
+}
+
+// NewCSVChunkReader returns a reader that reads CSV data from reader in chunks of at most
+// chunkRows rows. This makes it possible to process data that does not fit in memory.
+//
+// It accepts the same configuration as ReadCSV. All chunks have the same columns and
+// column types. Types not given using csv.Types are detected from the first chunk.
+// If a later chunk contains values that cannot be represented by the detected type an
+// error is returned, use csv.Types to avoid this. Enum columns keep the same values,
+// in the same order, in all chunks. Values not seen before are added as they are found.
+//
+// The header, if any, is read when the reader is created. Any error is returned from Read.
+func NewCSVChunkReader(reader io.Reader, chunkRows int, confFuncs ...csv.ConfigFunc) *CSVChunkReader {
+	if chunkRows <= 0 {
+		return &CSVChunkReader{err: qerrors.New("NewCSVChunkReader", "chunk size must be positive, was %d", chunkRows)}
+	}
+
+	conf := csv.NewConfig(confFuncs)
+	r, err := qfio.NewCSVChunkReader(reader, chunkRows, qfio.CSVConfig(conf))
+	return &CSVChunkReader{reader: r, err: err}
+}
+
+// Read returns the next chunk. The first chunk is always returned, even if the CSV contains
+// no rows. Following chunks contain at least one row. When there are no more rows io.EOF
+// is returned.
+//
+// Time complexity O(m * n) where m = number of columns, n = number of rows in the chunk.
+func (r *CSVChunkReader) Read() (QFrame, error) {
+	if r.err != nil {
+		return QFrame{Err: r.err}, r.err
+	}
+
+	data, columns, err := r.reader.Read()
+	if err == io.EOF {
+		return QFrame{}, err
+	}
+
+	if err != nil {
+		r.err = err
+		return QFrame{Err: err}, err
+	}
+
+	qf := New(data, newqf.ColumnOrder(columns...))
+	if qf.Err != nil {
+		r.err = qf.Err
+		return qf, qf.Err
+	}
+
+	return qf, nil
+}
+
 // ReadJSON returns a QFrame with data, in JSON format, taken from reader.
+// The data is either an array of records or an object with one array of values
+// per column. Keys missing from a record become null values.
+// Use newqf.AutoEnum to store low cardinality string columns as enums.
 //
 // Time complexity O(m * n) where m = number of columns, n = number of rows.
 func ReadJSON(reader io.Reader, confFuncs ...newqf.ConfigFunc) QFrame {
@@ -1065,6 +1205,61 @@ func ReadJSON(reader io.Reader, confFuncs ...newqf.ConfigFunc) QFrame {
 	return New(data, confFuncs...)
 }
 
+// ReadNDJSON returns a QFrame with data, in newline delimited JSON format, taken from reader.
+// Each line holds one record as a JSON object. Records are decoded one at a time and the
+// column types are inferred from all records. Keys missing from a record become null values.
+// Columns are ordered by first appearance. Use newqf.AutoEnum to store low cardinality string
+// columns as enums.
+//
+// Time complexity O(m * n) where m = number of columns, n = number of rows.
+func ReadNDJSON(reader io.Reader, confFuncs ...newqf.ConfigFunc) QFrame {
+	data, columns, err := qfio.ReadNDJSON(reader)
+	if err != nil {
+		return QFrame{Err: err}
+	}
+
+	return New(data, append([]newqf.ConfigFunc{newqf.ColumnOrder(columns...)}, confFuncs...)...)
+}
+
+// ReadArrow returns a QFrame with data, in Arrow IPC stream format, taken from reader.
+//
+// Int, floating point, bool, utf8 and timestamp arrays are supported. Dictionary encoded
+// utf8 arrays become enum columns if the number of values fit in an enum, string
+// columns otherwise. Timestamps become time columns in the timezone of the array,
+// UTC if none is given.
+//
+// Time complexity O(m * n) where m = number of columns, n = number of rows.
+func ReadArrow(reader io.Reader) QFrame {
+	data, columns, err := qfarrowio.ReadArrow(reader)
+	if err != nil {
+		return QFrame{Err: err}
+	}
+
+	return New(data, newqf.ColumnOrder(columns...))
+}
+
+// ReadParquet returns a QFrame with data, from a Parquet file of the given size, taken from reader.
+//
+// Flat schemas with boolean, int32, int64, float, double and byte array columns are supported.
+// Ints become int columns, floats and doubles float columns and byte arrays string columns.
+// Int64 columns with a timestamp logical, or converted, type become time columns in UTC.
+// String columns where all pages are dictionary encoded become enum columns, with the values
+// in dictionary order, if the number of values fit in an enum. Plain and dictionary encoded
+// pages compressed with snappy or gzip, or not compressed at all, can be read.
+//
+// Config functions may be used to only read a subset of the columns, see config/parquet.
+//
+// Time complexity O(m * n) where m = number of columns, n = number of rows.
+func ReadParquet(reader io.ReaderAt, size int64, confFuncs ...parquet.ConfigFunc) QFrame {
+	conf := parquet.NewConfig(confFuncs)
+	data, columns, err := qfparquetio.ReadParquet(reader, size, qfparquetio.Config(conf))
+	if err != nil {
+		return QFrame{Err: err}
+	}
+
+	return New(data, newqf.ColumnOrder(columns...))
+}
+
 // ReadSQL returns a QFrame by reading the results of a SQL query.
 func ReadSQL(tx *sql.Tx, confFuncs ...qsql.ConfigFunc) QFrame {
 	return ReadSQLWithArgs(tx, []interface{}{}, confFuncs...)
@@ -1072,25 +1267,136 @@ func ReadSQL(tx *sql.Tx, confFuncs ...qsql.ConfigFunc) QFrame {
 
 // ReadSQLWithArgs returns a QFrame by reading the results of a SQL query with arguments
 func ReadSQLWithArgs(tx *sql.Tx, queryArgs []interface{}, confFuncs ...qsql.ConfigFunc) QFrame {
+	// Arguments given using qsql.Args in confFuncs take precedence
+	argConfFuncs := append([]qsql.ConfigFunc{qsql.Args(queryArgs...)}, confFuncs...)
+	return ReadSQLContext(context.Background(), tx, argConfFuncs...)
+}
+
+// SQLQueryer is used to run queries when reading SQL data.
+// It is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type SQLQueryer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ReadSQLContext returns a QFrame by reading the results of a SQL query, see qsql.Query and qsql.Args.
+// The query is canceled if ctx is done before all rows have been read.
+//
+// Column types are taken from the column types reported by the driver, this makes columns
+// containing only NULL values, or values of different Go types, get the type of the database
+// column. Columns for which the driver does not report a known type get their type from the
+// first non NULL value.
+//
+// Time complexity O(m * n) where m = number of columns, n = number of rows.
+func ReadSQLContext(ctx context.Context, queryer SQLQueryer, confFuncs ...qsql.ConfigFunc) QFrame {
+	r := NewSQLChunkReader(ctx, queryer, 0, confFuncs...)
+	defer r.Close()
+	qf, err := r.Read()
+	if err != nil {
+		return QFrame{Err: err}
+	}
+	return qf
+}
+
+// SQLChunkReader reads the results of a SQL query as a sequence of QFrames, see NewSQLChunkReader.
+type SQLChunkReader struct {
+	stmt      *sql.Stmt
+	rows      *sql.Rows
+	reader    *qfsqlio.Reader
+	conf      qsql.Config
+	chunkRows int
+	enums     map[string][]string
+	err       error
+}
+
+// NewSQLChunkReader runs a SQL query and returns a reader that reads the results in chunks
+// of at most chunkRows rows, 0 means all rows in one chunk. This makes it possible to process
+// results that do not fit in memory.
+//
+// It accepts the same configuration as ReadSQLContext. All chunks have the same columns and
+// column types. Columns containing only NULL values in the first chunk, that have no type
+// reported by the driver, are string columns. Columns that are stored as enums in the first
+// chunk, using qsql.AutoEnum, are enums in all chunks. The reader must be closed when done.
+func NewSQLChunkReader(ctx context.Context, queryer SQLQueryer, chunkRows int, confFuncs ...qsql.ConfigFunc) *SQLChunkReader {
+	if chunkRows < 0 {
+		return &SQLChunkReader{err: qerrors.New("NewSQLChunkReader", "chunk size must not be negative, was %d", chunkRows)}
+	}
+
 	conf := qsql.NewConfig(confFuncs)
 	// The MySQL can only use prepared
 	// statements to return "native" types, otherwise
 	// everything is returned as text.
 	// see https://github.com/go-sql-driver/mysql/issues/407
-	stmt, err := tx.Prepare(conf.Query)
+	stmt, err := queryer.PrepareContext(ctx, conf.Query)
 	if err != nil {
-		return QFrame{Err: err}
+		return &SQLChunkReader{err: err}
 	}
-	defer stmt.Close()
-	rows, err := stmt.Query(queryArgs...)
+	r := &SQLChunkReader{stmt: stmt, conf: conf, chunkRows: chunkRows}
+	r.rows, err = stmt.QueryContext(ctx, conf.QueryArgs...)
 	if err != nil {
-		return QFrame{Err: err}
+		r.err = err
+		return r
+	}
+	r.reader, r.err = qfsqlio.NewReader(r.rows, qfsqlio.SQLConfig(conf))
+	return r
+}
+
+// Read returns the next chunk. The first chunk is always returned, even if the query returns
+// no rows. Following chunks contain at least one row. When there are no more rows io.EOF
+// is returned.
+//
+// Time complexity O(m * n) where m = number of columns, n = number of rows in the chunk.
+func (r *SQLChunkReader) Read() (QFrame, error) {
+	if r.err != nil {
+		return QFrame{Err: r.err}, r.err
 	}
-	data, columns, err := qfsqlio.ReadSQL(rows, qfsqlio.SQLConfig(conf))
+
+	data, columns, err := r.reader.Read(r.chunkRows)
+	if err == io.EOF {
+		return QFrame{}, err
+	}
+
 	if err != nil {
-		return QFrame{Err: err}
+		r.err = err
+		return QFrame{Err: err}, err
 	}
-	return New(data, newqf.ColumnOrder(columns...))
+
+	confFuncs := []newqf.ConfigFunc{newqf.ColumnOrder(columns...)}
+	if r.enums == nil {
+		confFuncs = append(confFuncs, newqf.AutoEnum(r.conf.AutoEnumMaxCardinality), newqf.AutoEnumRatio(r.conf.AutoEnumMaxRatio))
+	} else {
+		confFuncs = append(confFuncs, newqf.Enums(r.enums))
+	}
+
+	qf := New(data, confFuncs...)
+	if qf.Err != nil {
+		r.err = qf.Err
+		return qf, qf.Err
+	}
+
+	if r.enums == nil {
+		r.enums = map[string][]string{}
+		for name, typ := range qf.ColumnTypeMap() {
+			if typ == types.Enum {
+				r.enums[name] = nil
+			}
+		}
+	}
+
+	return qf, nil
+}
+
+// Close closes the result set and the statement of the query.
+func (r *SQLChunkReader) Close() error {
+	var err error
+	if r.rows != nil {
+		err = r.rows.Close()
+	}
+	if r.stmt != nil {
+		if stmtErr := r.stmt.Close(); err == nil {
+			err = stmtErr
+		}
+	}
+	return err
 }
 
 // ToCSV writes the data in the QFrame, in CSV format, to writer.
@@ -1139,28 +1445,43 @@ func (qf QFrame) ToCSV(writer io.Writer, confFuncs ...csv.ToConfigFunc) error {
 }
 
 // ToJSON writes the data in the QFrame, in JSON format one record per row, to writer.
+// Use json.Format to write newline delimited JSON or one array per column instead.
 //
 // Time complexity O(m * n) where m = number of rows, n = number of columns.
-func (qf QFrame) ToJSON(writer io.Writer) error {
+func (qf QFrame) ToJSON(writer io.Writer, confFuncs ...json.ConfigFunc) error {
 	if qf.Err != nil {
 		return qerrors.Propagate("ToJSON", qf.Err)
 	}
 
+	conf := json.NewConfig(confFuncs)
 	colByteNames := make([][]byte, len(qf.columns))
 	for i, col := range qf.columns {
 		colByteNames[i] = qfstrings.QuotedBytes(col.name)
 	}
 
+	switch conf.Format {
+	case json.Records, json.NDJSON:
+		return qf.toJSONRecords(writer, colByteNames, conf.Format == json.NDJSON)
+	case json.Columns:
+		return qf.toJSONColumns(writer, colByteNames)
+	}
+
+	return qerrors.New("ToJSON", "unknown format: %s", conf.Format)
+}
+
+func (qf QFrame) toJSONRecords(writer io.Writer, colByteNames [][]byte, ndjson bool) error {
 	// Custom JSON generator for records due to performance reasons
 	jsonBuf := []byte{'['}
-	_, err := writer.Write(jsonBuf)
-	if err != nil {
-		return err
+	if !ndjson {
+		_, err := writer.Write(jsonBuf)
+		if err != nil {
+			return err
+		}
 	}
 
 	for i, ix := range qf.index {
 		jsonBuf = jsonBuf[:0]
-		if i > 0 {
+		if i > 0 && !ndjson {
 			jsonBuf = append(jsonBuf, byte(','))
 		}
 
@@ -1178,18 +1499,99 @@ func (qf QFrame) ToJSON(writer io.Writer) error {
 		}
 
 		jsonBuf = append(jsonBuf, byte('}'))
+		if ndjson {
+			jsonBuf = append(jsonBuf, byte('\n'))
+		}
 
-		_, err = writer.Write(jsonBuf)
+		_, err := writer.Write(jsonBuf)
 		if err != nil {
 			return err
 		}
 	}
 
-	_, err = writer.Write([]byte{']'})
+	if ndjson {
+		return nil
+	}
+
+	_, err := writer.Write([]byte{']'})
+	return err
+}
+
+func (qf QFrame) toJSONColumns(writer io.Writer, colByteNames [][]byte) error {
+	jsonBuf := []byte{'{'}
+	for j, col := range qf.columns {
+		if j > 0 {
+			jsonBuf = append(jsonBuf, byte(','))
+		}
+
+		jsonBuf = append(jsonBuf, colByteNames[j]...)
+		jsonBuf = append(jsonBuf, byte(':'), byte('['))
+		for i, ix := range qf.index {
+			if i > 0 {
+				jsonBuf = append(jsonBuf, byte(','))
+			}
+			jsonBuf = col.AppendByteStringAt(jsonBuf, ix)
+		}
+		jsonBuf = append(jsonBuf, byte(']'))
+
+		_, err := writer.Write(jsonBuf)
+		if err != nil {
+			return err
+		}
+		jsonBuf = jsonBuf[:0]
+	}
+
+	_, err := writer.Write(append(jsonBuf, byte('}')))
 	return err
 }
 
+// ToArrow writes the data in the QFrame, in Arrow IPC stream format, to writer.
+// All data is written as one record batch. Enum columns are written as dictionary
+// encoded arrays, time columns as nanosecond timestamps with the location of the
+// column as timezone.
+//
+// Time complexity O(m * n) where m = number of rows, n = number of columns.
+func (qf QFrame) ToArrow(writer io.Writer) error {
+	if qf.Err != nil {
+		return qerrors.Propagate("ToArrow", qf.Err)
+	}
+
+	columns := make([]column.Column, len(qf.columns))
+	for i, col := range qf.columns {
+		columns[i] = col.Column
+	}
+
+	return qfarrowio.WriteArrow(writer, qf.ColumnNames(), columns, qf.index)
+}
+
+// ToParquet writes the data in the QFrame, as a Parquet file, to writer.
+// All data is written as one row group, all columns as optional. Enum columns are written
+// dictionary encoded, other columns plain encoded. Time columns are written as nanosecond
+// timestamps adjusted to UTC, the location of the column is not kept. Pages are compressed
+// using snappy unless another codec is configured, see config/parquet.
+//
+// Time complexity O(m * n) where m = number of rows, n = number of columns.
+func (qf QFrame) ToParquet(writer io.Writer, confFuncs ...parquet.ConfigFunc) error {
+	if qf.Err != nil {
+		return qerrors.Propagate("ToParquet", qf.Err)
+	}
+
+	columns := make([]column.Column, len(qf.columns))
+	for i, col := range qf.columns {
+		columns[i] = col.Column
+	}
+
+	conf := parquet.NewConfig(confFuncs)
+	return qfparquetio.WriteParquet(writer, qf.ColumnNames(), columns, qf.index, qfparquetio.Config(conf))
+}
+
 // ToSQL writes a QFrame into a SQL database.
+//
+// By default one INSERT statement is executed per row. Use qsql.BatchSize
+// to insert many rows per statement or qsql.BulkLoad to use a database
+// specific bulk loading mechanism such as COPY FROM STDIN in PostgreSQL.
+// The table can be created before writing using qsql.CreateTable. Rows that
+// already exist can be updated, or left as they are, using qsql.Upsert.
 func (qf QFrame) ToSQL(tx *sql.Tx, confFuncs ...qsql.ConfigFunc) error {
 	if qf.Err != nil {
 		return qerrors.Propagate("ToSQL", qf.Err)
@@ -1202,15 +1604,23 @@ func (qf QFrame) ToSQL(tx *sql.Tx, confFuncs ...qsql.ConfigFunc) error {
 			return qerrors.New("ToSQL", err.Error())
 		}
 	}
-	for i := range qf.index {
-		args := make([]interface{}, len(qf.columns))
-		for j, b := range builders {
-			args[j] = b(qf.index, i)
+	conf := qfsqlio.SQLConfig(qsql.NewConfig(confFuncs))
+	if conf.CreateTable {
+		columns := make([]column.Column, len(qf.columns))
+		for i, col := range qf.columns {
+			columns[i] = col.Column
 		}
-		_, err = tx.Exec(qfsqlio.Insert(qf.ColumnNames(), qfsqlio.SQLConfig(qsql.NewConfig(confFuncs))), args...)
+		query, err := qfsqlio.CreateTable(qf.ColumnNames(), columns, conf)
 		if err != nil {
-			return qerrors.New("ToSQL", err.Error())
+			return qerrors.Propagate("ToSQL", err)
 		}
+		if _, err := tx.Exec(query); err != nil {
+			return qerrors.Propagate("ToSQL", err)
+		}
+	}
+	err = qfsqlio.WriteSQL(tx, qf.ColumnNames(), builders, qf.index, conf)
+	if err != nil {
+		return qerrors.Propagate("ToSQL", err)
 	}
 	return nil
 }
@@ -1248,7 +1658,8 @@ func Doc() string {
 		types.Enum:   ecolumn.Doc(),
 		types.Float:  fcolumn.Doc(),
 		types.Int:    icolumn.Doc(),
-		types.String: scolumn.Doc()} {
+		types.String: scolumn.Doc(),
+		types.Time:   tcolumn.Doc()} {
 		result += fmt.Sprintf("%s\n%s\n%s\n", strings.Title(string(typeName)), strings.Repeat("-", len(typeName)), docString)
 	}
 
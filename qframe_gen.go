@@ -6,6 +6,7 @@ import (
 	"github.com/yistabraq/qframe/internal/fcolumn"
 	"github.com/yistabraq/qframe/internal/icolumn"
 	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
 	"github.com/yistabraq/qframe/qerrors"
 )
 
@@ -215,3 +216,44 @@ func (qf QFrame) MustEnumView(colName string) EnumView {
 	}
 	return view
 }
+
+// TimeView provides a "view" into an time column and can be used for access to individual elements.
+type TimeView struct {
+	tcolumn.View
+}
+
+// TimeView returns a view into an time column identified by name.
+//
+// colName - Name of the column.
+//
+// Returns an error if the column is missing or of wrong type.
+// Time complexity O(1).
+func (qf QFrame) TimeView(colName string) (TimeView, error) {
+	namedColumn, ok := qf.columnsByName[colName]
+	if !ok {
+		return TimeView{}, qerrors.New("TimeView", "unknown column: %s", colName)
+	}
+
+	col, ok := namedColumn.Column.(tcolumn.Column)
+	if !ok {
+		return TimeView{}, qerrors.New(
+			"TimeView",
+			"invalid column type, expected: %s, was: %s", "time", namedColumn.DataType())
+	}
+
+	return TimeView{View: col.View(qf.index)}, nil
+}
+
+// MustTimeView returns a view into an time column identified by name.
+//
+// colName - Name of the column.
+//
+// Panics if the column is missing or of wrong type.
+// Time complexity O(1).
+func (qf QFrame) MustTimeView(colName string) TimeView {
+	view, err := qf.TimeView(colName)
+	if err != nil {
+		panic(qerrors.Propagate("MustTimeView", err))
+	}
+	return view
+}
@@ -1,13 +1,21 @@
 package qframe_test
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
 	"io"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/yistabraq/qframe"
+	"github.com/yistabraq/qframe/config/newqf"
 	qsql "github.com/yistabraq/qframe/config/sql"
+	"github.com/yistabraq/qframe/types"
 )
 
 // MockDriver implements a fake SQL driver for testing.
@@ -15,6 +23,9 @@ type MockDriver struct {
 	t *testing.T
 	// expected SQL query
 	query string
+	// expected SQL queries, in order, for tests
+	// preparing more than one statement
+	queries []string
 	// results holds values that are
 	// returned from a database query
 	results struct {
@@ -46,9 +57,10 @@ func (m MockDriver) Open(name string) (driver.Conn, error) {
 		mockQuery: m.mockQuery,
 	}
 	return &MockConn{
-		t:     m.t,
-		stmt:  stmt,
-		query: m.query,
+		t:       m.t,
+		stmt:    stmt,
+		query:   m.query,
+		queries: m.queries,
 	}, nil
 }
 
@@ -74,6 +86,17 @@ func (m MockRows) Close() error { return nil }
 
 func (m MockRows) Columns() []string { return m.columns }
 
+// TypedMockRows are MockRows that also report the types of the columns.
+type TypedMockRows struct {
+	*MockRows
+	scanTypes []reflect.Type
+	dbTypes   []string
+}
+
+func (m TypedMockRows) ColumnTypeScanType(index int) reflect.Type { return m.scanTypes[index] }
+
+func (m TypedMockRows) ColumnTypeDatabaseTypeName(index int) string { return m.dbTypes[index] }
+
 type MockTx struct{}
 
 func (m MockTx) Commit() error { return nil }
@@ -91,8 +114,8 @@ type MockStmt struct {
 func (s MockStmt) Close() error { return nil }
 
 func (s MockStmt) NumInput() int {
-	if len(s.values) > 0 {
-		return len(s.values[0])
+	if s.idx < len(s.values) {
+		return len(s.values[s.idx])
 	}
 	return 0
 }
@@ -118,12 +141,21 @@ func (s MockStmt) Query(args []driver.Value) (driver.Rows, error) {
 type MockQuery func(args []driver.Value) (driver.Rows, error)
 
 type MockConn struct {
-	t     *testing.T
-	query string
-	stmt  *MockStmt
+	t        *testing.T
+	query    string
+	queries  []string
+	queryIdx int
+	stmt     *MockStmt
 }
 
-func (m MockConn) Prepare(query string) (driver.Stmt, error) {
+func (m *MockConn) Prepare(query string) (driver.Stmt, error) {
+	if len(m.queries) > 0 {
+		if m.queryIdx >= len(m.queries) || query != m.queries[m.queryIdx] {
+			m.t.Errorf("unexpected query %d: %s", m.queryIdx, query)
+		}
+		m.queryIdx++
+		return m.stmt, nil
+	}
 	if query != m.query {
 		m.t.Errorf("invalid query: %s != %s", query, m.query)
 	}
@@ -138,9 +170,12 @@ func (c MockConn) Begin() (driver.Tx, error) {
 var (
 	_ driver.Conn = (*MockConn)(nil)
 	_ driver.Rows = (*MockRows)(nil)
-	_ driver.Tx   = (*MockTx)(nil)
-	_ driver.Stmt = (*MockStmt)(nil)
-	_ driver.Conn = (*MockConn)(nil)
+
+	_ driver.RowsColumnTypeScanType         = TypedMockRows{}
+	_ driver.RowsColumnTypeDatabaseTypeName = TypedMockRows{}
+	_ driver.Tx                             = (*MockTx)(nil)
+	_ driver.Stmt                           = (*MockStmt)(nil)
+	_ driver.Conn                           = (*MockConn)(nil)
 )
 
 func TestQFrame_ToSQL(t *testing.T) {
@@ -163,6 +198,111 @@ func TestQFrame_ToSQL(t *testing.T) {
 	assertNotErr(t, qf.ToSQL(tx, qsql.Table("test")))
 }
 
+func TestQFrame_ToSQLBatchSize(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.queries = []string{
+		"INSERT INTO test (COL1,COL2) VALUES (?,?),(?,?);",
+		"INSERT INTO test (COL1,COL2) VALUES (?,?);",
+	}
+	dvr.args.values = [][]driver.Value{
+		{int64(1), "one", int64(2), "two"},
+		{int64(3), "three", int64(4), "four"},
+		{int64(5), "five"},
+	}
+	sql.Register("TestToSQLBatchSize", dvr)
+	db, _ := sql.Open("TestToSQLBatchSize", "")
+	tx, _ := db.Begin()
+	qf := qframe.New(map[string]interface{}{
+		"COL1": []int{1, 2, 3, 4, 5},
+		"COL2": []string{"one", "two", "three", "four", "five"},
+	})
+	assertNotErr(t, qf.ToSQL(tx, qsql.Table("test"), qsql.BatchSize(2)))
+}
+
+func TestQFrame_ToSQLBatchSizeIncrementing(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.query = `INSERT INTO "test" ("COL1","COL2") VALUES ($1,$2),($3,$4),($5,$6);`
+	dvr.args.values = [][]driver.Value{
+		{int64(1), nil, nil, true, int64(3), false},
+	}
+	sql.Register("TestToSQLBatchSizeIncrementing", dvr)
+	db, _ := sql.Open("TestToSQLBatchSizeIncrementing", "")
+	tx, _ := db.Begin()
+	one, three, tr, f := 1, 3, true, false
+	qf := qframe.New(map[string]interface{}{
+		"COL1": []*int{&one, nil, &three},
+		"COL2": []*bool{nil, &tr, &f},
+	})
+	assertNotErr(t, qf.ToSQL(tx, qsql.Table("test"), qsql.Postgres(), qsql.BatchSize(1000)))
+}
+
+func TestQFrame_ToSQLBulkLoad(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.query = "COPY test (COL1,COL2) FROM STDIN"
+	dvr.args.values = [][]driver.Value{
+		{int64(1), "one"},
+		{int64(2), "two"},
+		// Flush
+		{},
+	}
+	sql.Register("TestToSQLBulkLoad", dvr)
+	db, _ := sql.Open("TestToSQLBulkLoad", "")
+	tx, _ := db.Begin()
+	qf := qframe.New(map[string]interface{}{
+		"COL1": []int{1, 2},
+		"COL2": []string{"one", "two"},
+	})
+	copyIn := func(table string, columns ...string) string {
+		return fmt.Sprintf("COPY %s (%s) FROM STDIN", table, strings.Join(columns, ","))
+	}
+	assertNotErr(t, qf.ToSQL(tx, qsql.Table("test"), qsql.BulkLoad(qsql.CopyIn(copyIn))))
+}
+
+func TestQFrame_ToSQLCreateTable(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.queries = []string{
+		`CREATE TABLE IF NOT EXISTS "test" ("COL1" BIGINT,"COL2" VARCHAR(10),PRIMARY KEY ("COL1"));`,
+		`INSERT INTO "test" ("COL1","COL2") VALUES ($1,$2);`,
+	}
+	dvr.args.values = [][]driver.Value{
+		{},
+		{int64(1), "one"},
+		{int64(2), "two"},
+	}
+	sql.Register("TestToSQLCreateTable", dvr)
+	db, _ := sql.Open("TestToSQLCreateTable", "")
+	tx, _ := db.Begin()
+	qf := qframe.New(map[string]interface{}{
+		"COL1": []int{1, 2},
+		"COL2": []string{"one", "two"},
+	})
+	assertNotErr(t, qf.ToSQL(tx,
+		qsql.Table("test"),
+		qsql.Postgres(),
+		qsql.CreateTable(true),
+		qsql.ColumnType("COL2", "VARCHAR(10)"),
+		qsql.PrimaryKey("COL1")))
+}
+
+func TestQFrame_ToSQLUpsert(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.query = `INSERT INTO "test" ("ID","COL1") VALUES ($1,$2),($3,$4) ON CONFLICT ("ID") DO UPDATE SET "COL1"=excluded."COL1";`
+	dvr.args.values = [][]driver.Value{
+		{int64(1), "one", int64(2), "two"},
+	}
+	sql.Register("TestToSQLUpsert", dvr)
+	db, _ := sql.Open("TestToSQLUpsert", "")
+	tx, _ := db.Begin()
+	qf := qframe.New(map[string]interface{}{
+		"ID":   []int{1, 2},
+		"COL1": []string{"one", "two"},
+	}, newqf.ColumnOrder("ID", "COL1"))
+	assertNotErr(t, qf.ToSQL(tx, qsql.Table("test"), qsql.Postgres(), qsql.Upsert("ID"), qsql.BatchSize(2)))
+
+	err := qf.ToSQL(tx, qsql.Table("test"), qsql.Postgres(), qsql.Upsert("FOO"))
+	assertErr(t, err, "unknown upsert key column")
+}
+
 func TestQFrame_ReadSQL(t *testing.T) {
 	dvr := MockDriver{t: t}
 	dvr.results.columns = []string{"COL1", "COL2", "COL3", "COL4"}
@@ -185,6 +325,81 @@ func TestQFrame_ReadSQL(t *testing.T) {
 	assertEquals(t, expected, qf)
 }
 
+func TestQFrame_ReadSQLAutoEnum(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.results.columns = []string{"COL1", "COL2"}
+	dvr.results.values = [][]driver.Value{
+		{"a", "one"},
+		{"b", "two"},
+		{"a", "three"},
+	}
+	sql.Register("TestReadSQLAutoEnum", dvr)
+	db, _ := sql.Open("TestReadSQLAutoEnum", "")
+	tx, _ := db.Begin()
+	qf := qframe.ReadSQL(tx, qsql.AutoEnum(2))
+	assertNotErr(t, qf.Err)
+	assertTrue(t, qf.ColumnTypeMap()["COL1"] == types.Enum)
+	assertTrue(t, qf.ColumnTypeMap()["COL2"] == types.String)
+	expected := qframe.New(map[string]interface{}{
+		"COL1": []string{"a", "b", "a"},
+		"COL2": []string{"one", "two", "three"},
+	}, newqf.Enums(map[string][]string{"COL1": nil}))
+	assertEquals(t, expected, qf)
+}
+
+func TestQFrame_ToSQLNullable(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.query = "INSERT INTO test (COL1,COL2) VALUES (?,?);"
+	dvr.args.values = [][]driver.Value{
+		{int64(1), nil},
+		{nil, true},
+	}
+	sql.Register("TestToSQLNullable", dvr)
+	db, _ := sql.Open("TestToSQLNullable", "")
+	tx, _ := db.Begin()
+	one, tr := 1, true
+	qf := qframe.New(map[string]interface{}{
+		"COL1": []*int{&one, nil},
+		"COL2": []*bool{nil, &tr},
+	})
+	assertNotErr(t, qf.ToSQL(tx, qsql.Table("test")))
+}
+
+func TestQFrame_ToSQLTime(t *testing.T) {
+	t1 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	dvr := MockDriver{t: t}
+	dvr.query = "INSERT INTO test (COL1) VALUES (?);"
+	dvr.args.values = [][]driver.Value{{t1}, {nil}}
+	sql.Register("TestToSQLTime", dvr)
+	db, _ := sql.Open("TestToSQLTime", "")
+	tx, _ := db.Begin()
+	qf := qframe.New(map[string]interface{}{
+		"COL1": []*time.Time{&t1, nil},
+	})
+	assertNotErr(t, qf.ToSQL(tx, qsql.Table("test")))
+}
+
+func TestQFrame_ReadSQLNullable(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.results.columns = []string{"COL1", "COL2"}
+	dvr.results.values = [][]driver.Value{
+		{nil, true},
+		{int64(2), nil},
+		{nil, false},
+	}
+	sql.Register("TestReadSQLNullable", dvr)
+	db, _ := sql.Open("TestReadSQLNullable", "")
+	tx, _ := db.Begin()
+	qf := qframe.ReadSQL(tx)
+	assertNotErr(t, qf.Err)
+	two, tr, fa := 2, true, false
+	expected := qframe.New(map[string]interface{}{
+		"COL1": []*int{nil, &two, nil},
+		"COL2": []*bool{&tr, nil, &fa},
+	})
+	assertEquals(t, expected, qf)
+}
+
 func TestQFrame_ReadSQLCoercion(t *testing.T) {
 	dvr := MockDriver{t: t}
 	dvr.results.columns = []string{"COL1", "COL2"}
@@ -251,4 +466,169 @@ func TestQFrame_ReadWithArgs(t *testing.T) {
 		"COL4": []bool{true},
 	})
 	assertEquals(t, expected, qf)
+
+	// Arguments given as options are not overridden
+	qf = qframe.ReadSQL(tx, qsql.Query(stmt), qsql.Args("two"))
+	assertNotErr(t, qf.Err)
+	expected = qframe.New(map[string]interface{}{
+		"COL1": []int{2},
+		"COL2": []float64{1.2},
+		"COL3": []string{"two"},
+		"COL4": []bool{false},
+	})
+	assertEquals(t, expected, qf)
+
+	// The options of the caller are left untouched
+	confFuncs := make([]qsql.ConfigFunc, 1, 2)
+	confFuncs[0] = qsql.Query(stmt)
+	qf = qframe.ReadSQLWithArgs(tx, []interface{}{"three"}, confFuncs...)
+	assertNotErr(t, qf.Err)
+	assertTrue(t, confFuncs[:2][1] == nil)
+}
+
+func TestQFrame_ReadSQLContext(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.query = "SELECT * FROM test WHERE COL1 > ?"
+	dvr.args.values = [][]driver.Value{{int64(0)}}
+	interfaceType := reflect.TypeOf((*interface{})(nil)).Elem()
+	dvr.mockQuery = func(args []driver.Value) (driver.Rows, error) {
+		if len(args) != 1 || args[0] != int64(0) {
+			t.Errorf("unexpected query args: %v", args)
+		}
+		return TypedMockRows{
+			MockRows: &MockRows{
+				t:       t,
+				columns: []string{"COL1", "COL2", "COL3", "COL4", "COL5"},
+				values: [][]driver.Value{
+					{int64(1), nil, nil, []byte("1.5"), nil},
+					{int64(2), nil, nil, int64(2), nil},
+				},
+			},
+			scanTypes: []reflect.Type{
+				reflect.TypeOf(int64(0)),
+				reflect.TypeOf(sql.NullFloat64{}),
+				interfaceType,
+				interfaceType,
+				interfaceType,
+			},
+			dbTypes: []string{"BIGINT", "DOUBLE", "TIMESTAMP", "NUMERIC(10, 2)", "UNKNOWN"},
+		}, nil
+	}
+	sql.Register("TestReadSQLContext", dvr)
+	db, _ := sql.Open("TestReadSQLContext", "")
+
+	// Columns containing only NULL values, or values of different types,
+	// get their types from the column types reported by the driver.
+	qf := qframe.ReadSQLContext(context.Background(), db, qsql.Query(dvr.query), qsql.Args(0))
+	assertNotErr(t, qf.Err)
+	expected := qframe.New(map[string]interface{}{
+		"COL1": []int{1, 2},
+		"COL2": []float64{math.NaN(), math.NaN()},
+		"COL3": []*time.Time{nil, nil},
+		"COL4": []float64{1.5, 2},
+		"COL5": []*string{nil, nil},
+	}, newqf.ColumnOrder("COL1", "COL2", "COL3", "COL4", "COL5"))
+	assertEquals(t, expected, qf)
+}
+
+func TestQFrame_ReadSQLContextCanceled(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.results.columns = []string{"COL1"}
+	dvr.results.values = [][]driver.Value{{int64(1)}}
+	sql.Register("TestReadSQLContextCanceled", dvr)
+	db, _ := sql.Open("TestReadSQLContextCanceled", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	qf := qframe.ReadSQLContext(ctx, db)
+	assertErr(t, qf.Err, "context canceled")
+}
+
+func TestQFrame_SQLChunkReader(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.results.columns = []string{"COL1", "COL2"}
+	dvr.results.values = [][]driver.Value{
+		{int64(1), "a"},
+		{int64(2), "b"},
+		{int64(3), "a"},
+		{int64(4), "c"},
+		{int64(5), "b"},
+	}
+	sql.Register("TestSQLChunkReader", dvr)
+	db, _ := sql.Open("TestSQLChunkReader", "")
+	conn, err := db.Conn(context.Background())
+	assertNotErr(t, err)
+	defer conn.Close()
+
+	r := qframe.NewSQLChunkReader(context.Background(), conn, 2, qsql.AutoEnum(2))
+	defer r.Close()
+	expected := []map[string]interface{}{
+		{"COL1": []int{1, 2}, "COL2": []string{"a", "b"}},
+		{"COL1": []int{3, 4}, "COL2": []string{"a", "c"}},
+		{"COL1": []int{5}, "COL2": []string{"b"}},
+	}
+	for _, data := range expected {
+		qf, err := r.Read()
+		assertNotErr(t, err)
+		assertEquals(t, qframe.New(data, newqf.Enums(map[string][]string{"COL2": nil})), qf)
+	}
+
+	_, err = r.Read()
+	if err != io.EOF {
+		t.Errorf("expected io.EOF, was: %v", err)
+	}
+	assertNotErr(t, r.Close())
+}
+
+func TestQFrame_SQLChunkReaderNullFirstChunk(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.results.columns = []string{"COL1"}
+	dvr.results.values = [][]driver.Value{{nil}, {nil}, {int64(3)}, {nil}}
+	sql.Register("TestSQLChunkReaderNullFirstChunk", dvr)
+	db, _ := sql.Open("TestSQLChunkReaderNullFirstChunk", "")
+	r := qframe.NewSQLChunkReader(context.Background(), db, 2)
+	defer r.Close()
+
+	// The type of the column is decided by the first chunk
+	three := "3"
+	expected := []map[string]interface{}{
+		{"COL1": []*string{nil, nil}},
+		{"COL1": []*string{&three, nil}},
+	}
+	for _, data := range expected {
+		qf, err := r.Read()
+		assertNotErr(t, err)
+		assertEquals(t, qframe.New(data), qf)
+	}
+
+	_, err := r.Read()
+	if err != io.EOF {
+		t.Errorf("expected io.EOF, was: %v", err)
+	}
+}
+
+func TestQFrame_SQLChunkReaderNoRows(t *testing.T) {
+	dvr := MockDriver{t: t}
+	dvr.results.columns = []string{"COL1"}
+	sql.Register("TestSQLChunkReaderNoRows", dvr)
+	db, _ := sql.Open("TestSQLChunkReaderNoRows", "")
+	r := qframe.NewSQLChunkReader(context.Background(), db, 10)
+	defer r.Close()
+
+	// The first chunk is always returned
+	qf, err := r.Read()
+	assertNotErr(t, err)
+	if qf.Len() != 0 || !reflect.DeepEqual(qf.ColumnNames(), []string{"COL1"}) {
+		t.Errorf("unexpected chunk: %s", qf)
+	}
+
+	_, err = r.Read()
+	if err != io.EOF {
+		t.Errorf("expected io.EOF, was: %v", err)
+	}
+}
+
+func TestQFrame_SQLChunkReaderNegativeSize(t *testing.T) {
+	r := qframe.NewSQLChunkReader(context.Background(), nil, -1)
+	_, err := r.Read()
+	assertErr(t, err, "chunk size")
 }
@@ -2,13 +2,18 @@ package qframe_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/yistabraq/qframe/config/rolling"
 
@@ -20,7 +25,11 @@ import (
 	"github.com/yistabraq/qframe/config/csv"
 	"github.com/yistabraq/qframe/config/eval"
 	"github.com/yistabraq/qframe/config/groupby"
+	"github.com/yistabraq/qframe/config/join"
+	"github.com/yistabraq/qframe/config/json"
 	"github.com/yistabraq/qframe/config/newqf"
+	"github.com/yistabraq/qframe/config/parquet"
+	"github.com/yistabraq/qframe/function"
 	"github.com/yistabraq/qframe/types"
 )
 
@@ -854,6 +863,7 @@ func TestQFrame_GroupByAggregateFloats(t *testing.T) {
 }
 
 func TestQFrame_RollingWindow(t *testing.T) {
+	three, five, six, seven, nine := 3, 5, 6, 7, 9
 	sum := func(col []int) int {
 		result := 0
 		for _, x := range col {
@@ -875,19 +885,108 @@ func TestQFrame_RollingWindow(t *testing.T) {
 			expected: map[string]interface{}{"destination": []int{1, 2, 3}},
 			fn:       sum,
 		},
+		{
+			name:     "centered window",
+			input:    map[string]interface{}{"source": []int{1, 2, 3, 4}},
+			expected: map[string]interface{}{"destination": []*int{nil, &six, &nine, nil}},
+			fn:       sum,
+			configs:  []rolling.ConfigFunc{rolling.WindowSize(3)},
+		},
+		{
+			name:     "even centered window",
+			input:    map[string]interface{}{"source": []int{1, 2, 3, 4}},
+			expected: map[string]interface{}{"destination": []*int{nil, &three, &five, &seven}},
+			fn:       sum,
+			configs:  []rolling.ConfigFunc{rolling.WindowSize(2)},
+		},
+		{
+			name:     "start window with pad value",
+			input:    map[string]interface{}{"source": []int{1, 2, 3, 4}},
+			expected: map[string]interface{}{"destination": []int{3, 5, 7, -1}},
+			fn:       sum,
+			configs:  []rolling.ConfigFunc{rolling.WindowSize(2), rolling.Position("start"), rolling.PadValue(-1)},
+		},
+		{
+			name:     "end window with built in function",
+			input:    map[string]interface{}{"source": []float64{1, 2, 3, 5}},
+			expected: map[string]interface{}{"destination": []float64{math.NaN(), 1.5, 2.5, 4}},
+			fn:       "median",
+			configs:  []rolling.ConfigFunc{rolling.WindowSize(2), rolling.Position("end")},
+		},
+		{
+			name:     "string window",
+			input:    map[string]interface{}{"source": []string{"a", "b", "c"}},
+			expected: map[string]interface{}{"destination": []string{"a", "a", "b"}},
+			fn:       "first",
+			configs:  []rolling.ConfigFunc{rolling.WindowSize(2), rolling.Position("end"), rolling.PadValue("a")},
+		},
+		{
+			name:     "interval function",
+			input:    map[string]interface{}{"source": []int{1, 2, 3, 4, 5}, "ts": []int{0, 10, 30, 40, 80}},
+			expected: map[string]interface{}{"destination": []int{3, 2, 7, 4, 5}},
+			fn:       sum,
+			configs: []rolling.ConfigFunc{rolling.IntervalFunction("ts", func(start, end int) bool {
+				return end < start+20
+			})},
+		},
 	}
 
 	for _, tc := range table {
 		t.Run(fmt.Sprintf("Rolling %s", tc.name), func(t *testing.T) {
 			in := qframe.New(tc.input)
 
-			out := in.Rolling(tc.fn, "destination", "source")
+			out := in.Rolling(tc.fn, "destination", "source", tc.configs...)
 
 			assertEquals(t, qframe.New(tc.expected), out.Select("destination"))
 		})
 	}
 }
 
+func TestQFrame_RollingErrors(t *testing.T) {
+	in := qframe.New(map[string]interface{}{"source": []int{1, 2, 3}, "ts": []float64{1, 2, 3}})
+	out := in.Rolling("sum", "destination", "source", rolling.IntervalFunction("ts", func(a, b int) bool { return true }))
+	assertErr(t, out.Err, "invalid interval function")
+
+	out = in.Rolling("sum", "destination", "source", rolling.WindowSize(2), rolling.PadValue("a"))
+	assertErr(t, out.Err, "append")
+}
+
+func TestGrouper_Rolling(t *testing.T) {
+	in := qframe.New(map[string]interface{}{
+		"sensor": []string{"a", "b", "a", "b", "a", "b"},
+		"value":  []float64{1, 10, 3, 20, 5, 40},
+	})
+
+	out := in.GroupBy(groupby.Columns("sensor")).Rolling("avg", "avg", "value",
+		rolling.WindowSize(2), rolling.Position("end"))
+	expected := qframe.New(map[string]interface{}{
+		"sensor": []string{"a", "b", "a", "b", "a", "b"},
+		"value":  []float64{1, 10, 3, 20, 5, 40},
+		"avg":    []float64{math.NaN(), math.NaN(), 2, 15, 4, 30},
+	}, newqf.ColumnOrder("sensor", "value", "avg"))
+	assertEquals(t, expected, out)
+}
+
+func TestGrouper_Transform(t *testing.T) {
+	in := qframe.New(map[string]interface{}{
+		"group": []string{"a", "b", "a", "b", "a"},
+		"value": []int{1, 10, 3, 30, 4},
+	})
+
+	out := in.Sort(qframe.Order{Column: "value", Reverse: true}).GroupBy(groupby.Columns("group")).Transform(
+		qframe.Aggregation{Fn: "sum", Column: "value", As: "total"},
+		qframe.Aggregation{Fn: "count", Column: "value", As: "size"})
+	out = out.Eval("share", qframe.Expr("/", qframe.Expr("float", types.ColumnName("value")), qframe.Expr("float", types.ColumnName("total"))))
+	expected := qframe.New(map[string]interface{}{
+		"group": []string{"b", "b", "a", "a", "a"},
+		"value": []int{30, 10, 4, 3, 1},
+		"total": []int{40, 40, 8, 8, 8},
+		"size":  []int{2, 2, 3, 3, 3},
+		"share": []float64{0.75, 0.25, 0.5, 0.375, 0.125},
+	}, newqf.ColumnOrder("group", "value", "total", "size", "share"))
+	assertEquals(t, expected, out)
+}
+
 func colNamesToOrders(colNames ...string) []qframe.Order {
 	result := make([]qframe.Order, len(colNames))
 	for i, name := range colNames {
@@ -997,6 +1096,7 @@ func TestQFrame_ReadCSV(t *testing.T) {
 		1  aaa  3.25  7.0  NaN
 	*/
 	a, b, c, empty := "a", "b", "c", ""
+	one, three, tr := 1, 3, true
 	table := []struct {
 		name             string
 		inputHeaders     []string
@@ -1135,6 +1235,35 @@ func TestQFrame_ReadCSV(t *testing.T) {
 			emptyNull:    true,
 			expected:     map[string]interface{}{"foo": []*string{&a, nil, &c}},
 		},
+		{
+			name:         "Int with null value",
+			inputHeaders: []string{"foo"},
+			inputData:    "1\n\n3",
+			emptyNull:    true,
+			expected:     map[string]interface{}{"foo": []*int{&one, nil, &three}},
+		},
+		{
+			name:         "Bool with null value",
+			inputHeaders: []string{"foo"},
+			inputData:    "true\n\n",
+			emptyNull:    true,
+			expected:     map[string]interface{}{"foo": []*bool{&tr, nil}},
+		},
+		{
+			name:         "Only null values",
+			inputHeaders: []string{"foo"},
+			inputData:    "\n\n",
+			emptyNull:    true,
+			expected:     map[string]interface{}{"foo": []float64{math.NaN(), math.NaN()}},
+		},
+		{
+			name:         "Only null values with int type",
+			inputHeaders: []string{"foo"},
+			inputData:    "\n\n",
+			emptyNull:    true,
+			types:        map[string]string{"foo": "int"},
+			expected:     map[string]interface{}{"foo": []*int{nil, nil}},
+		},
 		{
 			name:         "CRLF",
 			rowDelimiter: "\r\n",
@@ -1309,17 +1438,45 @@ foo
 		assertErr(t, out.Err, "unknown enum value")
 	})
 
-	t.Run("Fails with too high cardinality column", func(t *testing.T) {
-		input := make([]string, 0)
-		for i := 0; i < 256; i++ {
-			input = append(input, strconv.Itoa(i))
+	t.Run("High cardinality columns", func(t *testing.T) {
+		// Cover all code widths, 8, 16 and 32 bits
+		for _, cardinality := range []int{254, 255, 70000} {
+			input := make([]*string, 0, cardinality+1)
+			for i := cardinality - 1; i >= 0; i-- {
+				input = append(input, strP(strconv.Itoa(i)))
+			}
+			input = append(input, nil)
+
+			f := qframe.New(map[string]interface{}{"foo": input}, newqf.Enums(map[string][]string{"foo": nil}))
+			assertNotErr(t, f.Err)
+			assertTrue(t, f.ColumnTypeMap()["foo"] == types.Enum)
+			assertEquals(t, qframe.New(map[string]interface{}{"foo": input}), f.Apply(qframe.Instruction{Fn: function.StrS, SrcCol1: "foo", DstCol: "foo"}))
+
+			// Enum order is the order the values were first seen in
+			last := strconv.Itoa(0)
+			sorted := f.Sort(qframe.Order{Column: "foo", Reverse: true})
+			assertTrue(t, *sorted.MustEnumView("foo").ItemAt(0) == last)
+			assertTrue(t, sorted.MustEnumView("foo").ItemAt(cardinality) == nil)
+
+			filtered := f.Filter(qframe.Or(
+				qframe.Filter{Column: "foo", Comparator: "=", Arg: last},
+				qframe.Filter{Column: "foo", Comparator: "in", Arg: []string{"1", "2"}},
+				qframe.Filter{Column: "foo", Comparator: "like", Arg: "1."}))
+			assertTrue(t, filtered.Len() == 13)
+
+			grouped := f.Append(f).GroupBy(groupby.Columns("foo")).Aggregate(qframe.Aggregation{Fn: "count", Column: "foo", As: "count"})
+			assertNotErr(t, grouped.Err)
+			assertTrue(t, grouped.Len() == cardinality+2)
+			assertTrue(t, grouped.Filter(qframe.Filter{Column: "count", Comparator: "=", Arg: 2}).Len() == cardinality)
+
+			// Appending values that do not fit in the current code width widens the codes
+			small := qframe.New(map[string]interface{}{"foo": []string{"a", "b"}}, newqf.Enums(map[string][]string{"foo": nil}))
+			appended := small.Append(f)
+			assertNotErr(t, appended.Err)
+			assertTrue(t, appended.Len() == cardinality+3)
+			assertTrue(t, *appended.MustEnumView("foo").ItemAt(cardinality + 1) == last)
+			assertTrue(t, appended.Filter(qframe.Filter{Column: "foo", Comparator: ">", Arg: "b"}).Len() == cardinality)
 		}
-
-		out := qframe.New(
-			map[string]interface{}{"foo": input},
-			newqf.Enums(map[string][]string{"foo": nil}))
-
-		assertErr(t, out.Err, "max cardinality")
 	})
 
 	t.Run("Fails when enum values specified for non enum column", func(t *testing.T) {
@@ -1411,6 +1568,167 @@ a,1.5`
 	assertEquals(t, expected, out)
 }
 
+func TestQFrame_ReadCSVColumns(t *testing.T) {
+	input := `A,B,C,D
+1,x,1.5,notanint
+2,y,2.5,3`
+
+	out := qframe.ReadCSV(strings.NewReader(input), csv.Columns([]string{"C", "A"}), csv.Types(map[string]string{"D": "int"}),
+		csv.EnumValues(map[string][]string{"B": {"x", "y"}}))
+	expected := qframe.New(map[string]interface{}{"A": []int{1, 2}, "C": []float64{1.5, 2.5}}, newqf.ColumnOrder("A", "C"))
+	assertNotErr(t, out.Err)
+	assertEquals(t, expected, out)
+
+	out = qframe.ReadCSV(strings.NewReader(input), csv.Columns([]string{"A", "E"}))
+	assertErr(t, out.Err, `unknown column: "E"`)
+}
+
+func TestQFrame_ReadCSVAutoEnum(t *testing.T) {
+	input := `LOW,HIGH,INT,EXPLICIT,EMPTY
+a,1x,1,a,
+b,2x,2,b,x
+a,3x,3,a,
+b,4x,4,b,x
+`
+	table := []struct {
+		name      string
+		configs   []csv.ConfigFunc
+		emptyNull bool
+		expected  map[string]types.DataType
+	}{
+		{
+			name:     "disabled by default",
+			expected: map[string]types.DataType{"LOW": types.String, "HIGH": types.String, "INT": types.Int, "EXPLICIT": types.String, "EMPTY": types.String}},
+		{
+			name:     "max cardinality",
+			configs:  []csv.ConfigFunc{csv.AutoEnum(2)},
+			expected: map[string]types.DataType{"LOW": types.Enum, "HIGH": types.String, "INT": types.Int, "EXPLICIT": types.String, "EMPTY": types.Enum}},
+		{
+			name:     "max ratio",
+			configs:  []csv.ConfigFunc{csv.AutoEnum(10), csv.AutoEnumRatio(0.5)},
+			expected: map[string]types.DataType{"LOW": types.Enum, "HIGH": types.String, "INT": types.Int, "EXPLICIT": types.String, "EMPTY": types.Enum}},
+		{
+			name:      "max ratio, nulls are not distinct values",
+			configs:   []csv.ConfigFunc{csv.AutoEnum(10), csv.AutoEnumRatio(0.25)},
+			emptyNull: true,
+			expected:  map[string]types.DataType{"LOW": types.String, "HIGH": types.String, "INT": types.Int, "EXPLICIT": types.String, "EMPTY": types.Enum}},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			confs := append(tc.configs, csv.Types(map[string]string{"EXPLICIT": "string"}), csv.EmptyNull(tc.emptyNull))
+			out := qframe.ReadCSV(strings.NewReader(input), confs...)
+			assertNotErr(t, out.Err)
+			if !reflect.DeepEqual(tc.expected, out.ColumnTypeMap()) {
+				t.Errorf("Unexpected types: %v", out.ColumnTypeMap())
+			}
+
+			// Same content, regardless of type
+			for _, col := range []string{"LOW", "HIGH", "EMPTY"} {
+				assertEquals(t,
+					qframe.ReadCSV(strings.NewReader(input), csv.EmptyNull(tc.emptyNull)).Select(col),
+					out.Select(col).Apply(qframe.Instruction{Fn: function.StrS, SrcCol1: col, DstCol: col}))
+			}
+		})
+	}
+
+	t.Run("reduces byte size", func(t *testing.T) {
+		buf := bytes.NewBufferString("A\n")
+		for i := 0; i < 10000; i++ {
+			fmt.Fprintf(buf, "a somewhat longer value that is repeated %d\n", i%10)
+		}
+		input := buf.String()
+		strSize := qframe.ReadCSV(strings.NewReader(input)).ByteSize()
+		enumSize := qframe.ReadCSV(strings.NewReader(input), csv.AutoEnum(100)).ByteSize()
+		assertTrue(t, 10*enumSize < strSize)
+	})
+}
+
+func TestQFrame_ReadCSVParallel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("INT,FLOAT,STRING,ENUM,BOOL\n")
+	for i := 0; i < 3000; i++ {
+		str := fmt.Sprintf("s%d", i)
+		switch i % 7 {
+		case 0:
+			str = fmt.Sprintf("\"multi\nline, \"\"quoted\"\"\n%d\"", i)
+		case 3:
+			str = ""
+		}
+
+		lineEnd := "\n"
+		if i%5 == 0 {
+			lineEnd = "\r\n"
+		}
+
+		fmt.Fprintf(buf, "%d,%d.5,%s,e%d,%t%s", i, i, str, i%3, i%2 == 0, lineEnd)
+	}
+	input := buf.String()
+
+	for _, p := range []int{2, 3, 8} {
+		t.Run(fmt.Sprintf("parallelism %d", p), func(t *testing.T) {
+			confs := []csv.ConfigFunc{csv.Types(map[string]string{"ENUM": "enum"})}
+			expected := qframe.ReadCSV(strings.NewReader(input), confs...)
+			assertNotErr(t, expected.Err)
+
+			out := qframe.ReadCSV(strings.NewReader(input), append(confs, csv.Parallelism(p))...)
+			assertNotErr(t, out.Err)
+			assertEquals(t, expected, out)
+
+			out = qframe.ReadCSV(strings.NewReader(input), csv.Parallelism(p), csv.Columns([]string{"STRING", "INT"}))
+			assertNotErr(t, out.Err)
+			assertEquals(t, expected.Select("INT", "STRING"), out)
+		})
+	}
+
+	t.Run("headers", func(t *testing.T) {
+		input := "1,a\n2,\"b\nc\"\n3,d"
+		expected := qframe.New(map[string]interface{}{"A": []int{1, 2, 3}, "B": []string{"a", "b\nc", "d"}}, newqf.ColumnOrder("A", "B"))
+		out := qframe.ReadCSV(strings.NewReader(input), csv.Headers([]string{"A", "B"}), csv.Parallelism(4))
+		assertNotErr(t, out.Err)
+		assertEquals(t, expected, out)
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		out := qframe.ReadCSV(strings.NewReader("A,B\n"), csv.Parallelism(4))
+		assertNotErr(t, out.Err)
+		if out.Len() != 0 || !reflect.DeepEqual(out.ColumnNames(), []string{"A", "B"}) {
+			t.Errorf("Unexpected result: %s", out)
+		}
+	})
+
+	t.Run("wrong number of columns", func(t *testing.T) {
+		lines := make([]string, 0, 101)
+		lines = append(lines, "A,B")
+		for i := 0; i < 100; i++ {
+			lines = append(lines, "1,2")
+		}
+		lines[80] = "1"
+		out := qframe.ReadCSV(strings.NewReader(strings.Join(lines, "\n")), csv.Parallelism(4))
+		assertErr(t, out.Err, "Wrong number of columns on line 81")
+	})
+}
+
+func TestQFrame_ReadJSONAutoEnum(t *testing.T) {
+	input := `[{"A": "x", "B": "1"}, {"A": "y", "B": "2"}, {"A": null, "B": "3"}, {"A": "x", "B": "4"}]`
+	out := qframe.ReadJSON(strings.NewReader(input), newqf.AutoEnum(3))
+	assertNotErr(t, out.Err)
+	assertTrue(t, out.ColumnTypeMap()["A"] == types.Enum)
+	assertTrue(t, out.ColumnTypeMap()["B"] == types.String)
+	assertTrue(t, out.MustEnumView("A").ItemAt(2) == nil)
+
+	// Explicit enums take precedence
+	out = qframe.ReadJSON(strings.NewReader(input), newqf.AutoEnum(3), newqf.Enums(map[string][]string{"A": {"y", "x"}}))
+	assertNotErr(t, out.Err)
+	assertTrue(t, len(out.Sort(qframe.Order{Column: "A"}).MustEnumView("A").Slice()) == 4)
+	assertTrue(t, *out.Sort(qframe.Order{Column: "A"}).MustEnumView("A").ItemAt(1) == "y")
+
+	out = qframe.ReadJSON(strings.NewReader(input), newqf.AutoEnum(3), newqf.AutoEnumRatio(0.5))
+	assertNotErr(t, out.Err)
+	assertTrue(t, out.ColumnTypeMap()["A"] == types.Enum)
+	assertTrue(t, out.ColumnTypeMap()["B"] == types.String)
+}
+
 func TestQFrame_ReadJSON(t *testing.T) {
 	/*
 		>>> pd.DataFrame.from_records([dict(a=1.5), dict(a=None)])
@@ -1467,6 +1785,151 @@ func TestQFrame_ReadJSON(t *testing.T) {
 	}
 }
 
+func TestQFrame_ReadJSONMissingKeys(t *testing.T) {
+	input := `[{"A": 1.5, "B": "x", "C": true}, {"B": "y"}, {"A": 2.5, "C": false, "D": "z"}]`
+	out := qframe.ReadJSON(strings.NewReader(input))
+	assertNotErr(t, out.Err)
+	x, y, z, tr, f := "x", "y", "z", true, false
+	expected := qframe.New(map[string]interface{}{
+		"A": []float64{1.5, math.NaN(), 2.5},
+		"B": []*string{&x, &y, nil},
+		"C": []*bool{&tr, nil, &f},
+		"D": []*string{nil, nil, &z},
+	})
+	assertEquals(t, expected, out)
+}
+
+func TestQFrame_ReadJSONColumns(t *testing.T) {
+	input := `{"A": [1, null], "B": ["x", null], "C": [null, null]}`
+	out := qframe.ReadJSON(strings.NewReader(input))
+	assertNotErr(t, out.Err)
+	x := "x"
+	expected := qframe.New(map[string]interface{}{
+		"A": []float64{1, math.NaN()},
+		"B": []*string{&x, nil},
+		"C": []*string{nil, nil},
+	})
+	assertEquals(t, expected, out)
+
+	out = qframe.ReadJSON(strings.NewReader(`{"A": [1, 2], "B": [1]}`))
+	assertErr(t, out.Err, "length")
+}
+
+func TestQFrame_ReadNDJSON(t *testing.T) {
+	input := `{"S": "a", "I": 1, "F": 1, "B": true}
+{"S": null, "I": null, "F": 2.5, "B": false, "N": null}
+
+{"I": 3, "F": null, "T": "t"}
+`
+	out := qframe.ReadNDJSON(strings.NewReader(input))
+	assertNotErr(t, out.Err)
+	a, tt, one, three, tr, f := "a", "t", 1, 3, true, false
+	expected := qframe.New(map[string]interface{}{
+		"S": []*string{&a, nil, nil},
+		"I": []*int{&one, nil, &three},
+		"F": []float64{1, 2.5, math.NaN()},
+		"B": []*bool{&tr, &f, nil},
+		"N": []*string{nil, nil, nil},
+		"T": []*string{nil, nil, &tt},
+	}, newqf.ColumnOrder("S", "I", "F", "B", "N", "T"))
+	assertEquals(t, expected, out)
+}
+
+func TestQFrame_ReadNDJSONAutoEnum(t *testing.T) {
+	input := "{\"A\": \"x\"}\n{\"A\": \"y\"}\n{\"A\": \"x\"}\n"
+	out := qframe.ReadNDJSON(strings.NewReader(input), newqf.AutoEnum(2))
+	assertNotErr(t, out.Err)
+	assertTrue(t, out.ColumnTypeMap()["A"] == types.Enum)
+}
+
+func TestQFrame_ReadNDJSONEmpty(t *testing.T) {
+	out := qframe.ReadNDJSON(strings.NewReader(""))
+	assertNotErr(t, out.Err)
+	assertTrue(t, out.Len() == 0 && len(out.ColumnNames()) == 0)
+}
+
+func TestQFrame_ReadNDJSONErrors(t *testing.T) {
+	table := []struct {
+		input string
+		err   string
+	}{
+		{input: `{"A": 1}` + "\n" + `{"A": "x"}`, err: "wrong type, expected int, was string"},
+		{input: `{"A": "x"}` + "\n" + `{"A": true}`, err: "column A, record 1"},
+		{input: `{"A": [1, 2]}`, err: "nested values are not supported"},
+		{input: `[{"A": 1}]`, err: "expected JSON object for record 0"},
+		{input: `{"A": 1, "A": 2}`, err: "duplicate key A"},
+		{input: `{"A": 1`, err: "ReadNDJSON"},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.input, func(t *testing.T) {
+			out := qframe.ReadNDJSON(strings.NewReader(tc.input))
+			assertErr(t, out.Err, tc.err)
+		})
+	}
+}
+
+func TestQFrame_ToJSONFormats(t *testing.T) {
+	a := "a"
+	in := qframe.New(map[string]interface{}{
+		"I": []int{1, 2},
+		"S": []*string{&a, nil},
+		"F": []float64{1.5, math.NaN()},
+	}, newqf.ColumnOrder("I", "S", "F"))
+
+	table := []struct {
+		format   string
+		expected string
+	}{
+		{format: json.Records, expected: `[{"I":1,"S":"a","F":1.5},{"I":2,"S":null,"F":null}]`},
+		{format: json.NDJSON, expected: "{\"I\":1,\"S\":\"a\",\"F\":1.5}\n{\"I\":2,\"S\":null,\"F\":null}\n"},
+		{format: json.Columns, expected: `{"I":[1,2],"S":["a",null],"F":[1.5,null]}`},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.format, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			assertNotErr(t, in.ToJSON(buf, json.Format(tc.format)))
+			if buf.String() != tc.expected {
+				t.Errorf("Unexpected JSON: %s, expected: %s", buf.String(), tc.expected)
+			}
+		})
+	}
+
+	assertErr(t, in.ToJSON(new(bytes.Buffer), json.Format("foo")), "unknown format")
+
+	// Empty QFrames
+	buf := new(bytes.Buffer)
+	assertNotErr(t, qframe.New(map[string]interface{}{"I": []int{}}).ToJSON(buf, json.Format(json.Columns)))
+	assertTrue(t, buf.String() == `{"I":[]}`)
+	buf.Reset()
+	assertNotErr(t, qframe.New(map[string]interface{}{"I": []int{}}).ToJSON(buf, json.Format(json.NDJSON)))
+	assertTrue(t, buf.String() == "")
+}
+
+func TestQFrame_ToFromNDJSON(t *testing.T) {
+	a, b := "a", "b"
+	one, tr := 1, true
+	in := qframe.New(map[string]interface{}{
+		"I": []*int{&one, nil},
+		"F": []float64{1.5, 2},
+		"B": []*bool{nil, &tr},
+		"S": []*string{&a, &b},
+	}, newqf.ColumnOrder("I", "F", "B", "S"))
+
+	buf := new(bytes.Buffer)
+	assertNotErr(t, in.ToJSON(buf, json.Format(json.NDJSON)))
+	out := qframe.ReadNDJSON(buf)
+	assertNotErr(t, out.Err)
+	assertEquals(t, in, out)
+
+	buf.Reset()
+	assertNotErr(t, in.ToJSON(buf, json.Format(json.Columns)))
+	out = qframe.ReadJSON(buf)
+	assertNotErr(t, out.Err)
+	assertEquals(t, in.Select("F", "S"), out.Select("F", "S"))
+}
+
 func TestQFrame_ToCSV(t *testing.T) {
 	table := []struct {
 		input    map[string]interface{}
@@ -1568,90 +2031,421 @@ func TestQFrame_ToJSONInt(t *testing.T) {
 	}
 }
 
-func TestQFrame_FilterEnum(t *testing.T) {
-	a, b, c, d, e := "a", "b", "c", "d", "e"
-	enums := newqf.Enums(map[string][]string{"COL1": {"a", "b", "c", "d", "e"}})
-	in := qframe.New(map[string]interface{}{
-		"COL1": []*string{&b, &c, &a, nil, &e, &d, nil}}, enums)
-
+func TestQFrame_ReadArrow(t *testing.T) {
+	foo, bar := "foo", "bar"
 	table := []struct {
-		clause   qframe.FilterClause
+		file     string
 		expected map[string]interface{}
 	}{
-		{
-			qframe.Filter{Column: "COL1", Comparator: ">", Arg: "b"},
-			map[string]interface{}{"COL1": []*string{&c, &e, &d}},
-		},
-		{
-			qframe.Filter{Column: "COL1", Comparator: "in", Arg: []string{"a", "b"}},
-			map[string]interface{}{"COL1": []*string{&b, &a}},
-		},
+		{file: "bool.bin", expected: map[string]interface{}{"f0": []bool{true, false, true}}},
+		{file: "float.bin", expected: map[string]interface{}{"f0": []float64{1.5, 2.5, math.NaN()}}},
+		{file: "int.bin", expected: map[string]interface{}{"f0": []int{1, 2, 3}}},
+		{file: "string.bin", expected: map[string]interface{}{"f0": []*string{&foo, &bar, nil}}},
+		{file: "mixed.bin", expected: map[string]interface{}{
+			"f0": []int{1, 2, 3},
+			"f1": []float64{1.5, 2.5, math.NaN()},
+			"f2": []bool{true, false, true},
+			"f3": []*string{&foo, &bar, nil}}},
 	}
 
-	for i, tc := range table {
-		t.Run(fmt.Sprintf("Filter enum %d", i), func(t *testing.T) {
-			expected := qframe.New(tc.expected, enums)
-			out := in.Filter(tc.clause)
-			assertEquals(t, expected, out)
+	for _, tc := range table {
+		t.Run(fmt.Sprintf("ReadArrow %s", tc.file), func(t *testing.T) {
+			f, err := os.Open(filepath.Join("arrow", tc.file))
+			assertNotErr(t, err)
+			defer f.Close()
+
+			out := qframe.ReadArrow(f)
+			assertNotErr(t, out.Err)
+			assertEquals(t, qframe.New(tc.expected), out)
 		})
 	}
 }
 
-func TestQFrame_FilterString(t *testing.T) {
-	a, b, c, d, e := "a", "b", "c", "d", "e"
-	withNil := map[string]interface{}{"COL1": []*string{&b, &c, &a, nil, &e, &d, nil}}
+func TestQFrame_ToFromArrow(t *testing.T) {
+	a, b := "a", "b"
+	config := []newqf.ConfigFunc{
+		newqf.Enums(map[string][]string{"ENUM": {"b", "a"}}),
+		newqf.ColumnOrder("STRING", "INT", "FLOAT", "BOOL", "ENUM")}
+	original := qframe.New(map[string]interface{}{
+		"STRING": []*string{&a, nil, &b},
+		"INT":    []int{3, -1, 2},
+		"FLOAT":  []float64{1.5, math.NaN(), 2.5},
+		"BOOL":   []bool{true, false, true},
+		"ENUM":   []*string{&a, nil, &b}}, config...)
+	assertNotErr(t, original.Err)
+
+	// Sort to make sure that the current index order is what gets written
+	original = original.Sort(qframe.Order{Column: "INT"})
 
-	table := []struct {
-		input    map[string]interface{}
-		clause   qframe.FilterClause
-		expected map[string]interface{}
-	}{
-		{
-			withNil,
-			qframe.Filter{Column: "COL1", Comparator: ">", Arg: "b"},
-			map[string]interface{}{"COL1": []*string{&c, &e, &d}},
-		},
-		{
-			withNil,
-			qframe.Filter{Column: "COL1", Comparator: "<", Arg: "b"},
-			map[string]interface{}{"COL1": []*string{&a}},
-		},
-		{
-			withNil,
-			qframe.Filter{Column: "COL1", Comparator: "!=", Arg: "a"},
-			map[string]interface{}{"COL1": []*string{&b, &c, nil, &e, &d, nil}},
-		},
-		{
-			withNil,
-			qframe.Filter{Column: "COL1", Comparator: "like", Arg: "b"},
-			map[string]interface{}{"COL1": []*string{&b}},
-		},
-		{
-			withNil,
-			qframe.Filter{Column: "COL1", Comparator: "in", Arg: []string{"a", "b"}},
-			map[string]interface{}{"COL1": []*string{&b, &a}},
-		},
-	}
+	buf := new(bytes.Buffer)
+	assertNotErr(t, original.ToArrow(buf))
 
-	for i, tc := range table {
-		t.Run(fmt.Sprintf("Filter string %d", i), func(t *testing.T) {
-			in := qframe.New(tc.input)
-			expected := qframe.New(tc.expected)
-			out := in.Filter(tc.clause)
-			assertEquals(t, expected, out)
-		})
+	out := qframe.ReadArrow(buf)
+	assertNotErr(t, out.Err)
+	assertEquals(t, original, out)
+	if !reflect.DeepEqual(out.ColumnTypes(), original.ColumnTypes()) {
+		t.Errorf("Unexpected column types: %v", out.ColumnTypes())
 	}
+
+	// Enum values and their order should be retained
+	out = out.Sort(qframe.Order{Column: "ENUM", NullLast: true})
+	assertEquals(t, qframe.New(map[string]interface{}{"ENUM": []*string{&b, &a, nil}}, config[0]), out.Select("ENUM"))
 }
 
-func TestQFrame_LikeFilterString(t *testing.T) {
-	col1 := []string{"ABC", "AbC", "DEF", "ABCDEF", "abcdef", "FFF", "abc$def", "défåäöΦ"}
+func TestQFrame_ToFromArrowTime(t *testing.T) {
+	input := "T\n2020-01-02T03:04:05.123456789+02:00\n\n2020-01-03T03:04:05+02:00\n"
+	original := qframe.ReadCSV(strings.NewReader(input), csv.Types(map[string]string{"T": types.Time}))
+	assertNotErr(t, original.Err)
 
-	// Add a couple of fields to be able to verify functionality for high cardinality enums
-	for i := 0; i < 200; i++ {
-		col1 = append(col1, fmt.Sprintf("foo%dbar", i))
-	}
+	buf := new(bytes.Buffer)
+	assertNotErr(t, original.ToArrow(buf))
 
-	data := map[string]interface{}{"COL1": col1}
+	out := qframe.ReadArrow(buf)
+	assertNotErr(t, out.Err)
+	assertEquals(t, original, out)
+
+	// The location is kept as timezone of the timestamps
+	expected, actual := original.MustTimeView("T").ItemAt(0), out.MustTimeView("T").ItemAt(0)
+	if expected.String() != actual.String() {
+		t.Errorf("Unexpected time: %s, expected: %s", actual, expected)
+	}
+
+	named := qframe.New(map[string]interface{}{"T": []time.Time{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}})
+	buf.Reset()
+	assertNotErr(t, named.ToArrow(buf))
+	out = qframe.ReadArrow(buf)
+	assertNotErr(t, out.Err)
+	assertTrue(t, out.MustTimeView("T").ItemAt(0).Location().String() == "UTC")
+}
+
+func TestQFrame_ReadArrowErrors(t *testing.T) {
+	out := qframe.ReadArrow(strings.NewReader(""))
+	assertErr(t, out.Err, "expected stream to start with a schema")
+
+	out = qframe.ReadArrow(bytes.NewReader([]byte{0xFF, 0xFF, 0xFF, 0xFF, 8, 0, 0, 0, 200, 0, 0, 0, 0, 0, 0, 0}))
+	assertErr(t, out.Err, "malformed input")
+
+	// Message length beyond the end of the stream
+	out = qframe.ReadArrow(bytes.NewReader([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F, 0}))
+	assertErr(t, out.Err, "malformed input")
+}
+
+// corruptedCopies calls fn with copies of data where a few random bytes have been changed.
+func corruptedCopies(data []byte, count int, fn func(corrupted []byte)) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < count; i++ {
+		corrupted := append([]byte(nil), data...)
+		for j := rnd.Intn(4); j >= 0; j-- {
+			corrupted[rnd.Intn(len(corrupted))] = byte(rnd.Intn(256))
+		}
+		fn(corrupted)
+	}
+}
+
+func corruptionTestFrame() qframe.QFrame {
+	a, b := "a", "b"
+	one, tr := 1, true
+	size := 100
+	ints, floats, strs, enums := make([]*int, size), make([]float64, size), make([]*string, size), make([]*string, size)
+	bools := make([]*bool, size)
+	for i := 0; i < size; i++ {
+		if i%3 != 0 {
+			ints[i], bools[i], strs[i] = &one, &tr, &a
+		}
+		floats[i] = float64(i)
+		enums[i] = &b
+	}
+
+	return qframe.New(map[string]interface{}{
+		"INT": ints, "FLOAT": floats, "BOOL": bools, "STRING": strs, "ENUM": enums},
+		newqf.Enums(map[string][]string{"ENUM": {"a", "b"}}))
+}
+
+func TestQFrame_ReadArrowCorrupted(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assertNotErr(t, corruptionTestFrame().ToArrow(buf))
+
+	// Reading corrupted input must result in either an error or a QFrame, never a panic
+	// or huge allocations.
+	corruptedCopies(buf.Bytes(), 3000, func(corrupted []byte) {
+		qframe.ReadArrow(bytes.NewReader(corrupted))
+	})
+}
+
+func TestQFrame_ReadParquet(t *testing.T) {
+	foo, bar, empty := "foo", "bar", ""
+	one, two, t1, f1 := 1, 2, true, false
+	optional := map[string]interface{}{
+		"string": []*string{&foo, nil, &bar, &foo},
+		"i64":    []*int{&one, nil, &one, &two},
+		"f64":    []float64{1.5, 1.5, math.NaN(), 2.5},
+		"bool":   []*bool{&t1, nil, &f1, &t1}}
+	optionalConfig := []newqf.ConfigFunc{
+		newqf.Enums(map[string][]string{"string": {"foo", "bar"}}),
+		newqf.ColumnOrder("string", "i64", "f64", "bool")}
+	table := []struct {
+		file     string
+		expected map[string]interface{}
+		config   []newqf.ConfigFunc
+	}{
+		{file: "plain.parquet", expected: map[string]interface{}{
+			"i64":    []int{1, -2, 3},
+			"i32":    []int{4, 5, -6},
+			"f64":    []float64{1.5, 2.5, -3.5},
+			"f32":    []float64{0.5, 1.25, 2},
+			"bool":   []bool{true, false, true},
+			"string": []*string{&foo, &bar, &empty}},
+			config: []newqf.ConfigFunc{newqf.ColumnOrder("i64", "i32", "f64", "f32", "bool", "string")}},
+		{file: "dictionary.parquet", expected: optional, config: optionalConfig},
+		{file: "v2.parquet", expected: optional, config: optionalConfig},
+		{file: "fallback.parquet", expected: map[string]interface{}{"string": []string{"a", "b", "c", "d", "e", "f"}}},
+	}
+
+	for _, tc := range table {
+		t.Run(fmt.Sprintf("ReadParquet %s", tc.file), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("parquet", tc.file))
+			assertNotErr(t, err)
+
+			out := qframe.ReadParquet(bytes.NewReader(data), int64(len(data)))
+			assertNotErr(t, out.Err)
+			expected := qframe.New(tc.expected, tc.config...)
+			assertEquals(t, expected, out)
+			if !reflect.DeepEqual(out.ColumnTypes(), expected.ColumnTypes()) {
+				t.Errorf("Unexpected column types: %v", out.ColumnTypes())
+			}
+		})
+	}
+}
+
+func TestQFrame_ReadParquetColumns(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("parquet", "plain.parquet"))
+	assertNotErr(t, err)
+
+	// The order of the columns in the file is kept
+	out := qframe.ReadParquet(bytes.NewReader(data), int64(len(data)), parquet.Columns("bool", "i64"))
+	assertNotErr(t, out.Err)
+	assertEquals(t, qframe.New(map[string]interface{}{
+		"i64":  []int{1, -2, 3},
+		"bool": []bool{true, false, true}}, newqf.ColumnOrder("i64", "bool")), out)
+
+	out = qframe.ReadParquet(bytes.NewReader(data), int64(len(data)), parquet.Columns("i64", "foo"))
+	assertErr(t, out.Err, "unknown column")
+}
+
+func TestQFrame_ToFromParquet(t *testing.T) {
+	a, b := "a", "b"
+	three, minusOne, f := 3, -1, false
+	config := []newqf.ConfigFunc{
+		newqf.Enums(map[string][]string{"ENUM": {"b", "a"}}),
+		newqf.ColumnOrder("STRING", "INT", "FLOAT", "BOOL", "ENUM")}
+	original := qframe.New(map[string]interface{}{
+		"STRING": []*string{&a, nil, &b},
+		"INT":    []*int{&three, &minusOne, nil},
+		"FLOAT":  []float64{1.5, math.NaN(), 2.5},
+		"BOOL":   []*bool{nil, &f, &f},
+		"ENUM":   []*string{&a, nil, &b}}, config...)
+	assertNotErr(t, original.Err)
+
+	// Sort to make sure that the current index order is what gets written
+	original = original.Sort(qframe.Order{Column: "FLOAT"})
+
+	for _, codec := range []string{parquet.Uncompressed, parquet.Snappy, parquet.Gzip} {
+		t.Run(codec, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			assertNotErr(t, original.ToParquet(buf, parquet.Compression(codec)))
+
+			out := qframe.ReadParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assertNotErr(t, out.Err)
+			assertEquals(t, original, out)
+			if !reflect.DeepEqual(out.ColumnTypes(), original.ColumnTypes()) {
+				t.Errorf("Unexpected column types: %v", out.ColumnTypes())
+			}
+
+			// Enum values and their order should be retained
+			out = out.Sort(qframe.Order{Column: "ENUM", NullLast: true})
+			assertEquals(t, qframe.New(map[string]interface{}{"ENUM": []*string{&b, &a, nil}}, config[0]), out.Select("ENUM"))
+		})
+	}
+}
+
+func TestQFrame_ToFromParquetTime(t *testing.T) {
+	input := "T\n2020-01-02T03:04:05.123456789+02:00\n\n2020-01-03T03:04:05+02:00\n"
+	original := qframe.ReadCSV(strings.NewReader(input), csv.Types(map[string]string{"T": types.Time}))
+	assertNotErr(t, original.Err)
+
+	buf := new(bytes.Buffer)
+	assertNotErr(t, original.ToParquet(buf))
+
+	// Times are read back in UTC
+	out := qframe.ReadParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assertNotErr(t, out.Err)
+	assertEquals(t, original, out)
+	assertTrue(t, out.MustTimeView("T").ItemAt(0).Equal(original.MustTimeView("T").ItemAt(0)))
+	assertTrue(t, out.MustTimeView("T").ItemAt(0).Location() == time.UTC)
+	assertTrue(t, out.MustTimeView("T").IsNull(1))
+}
+
+func TestQFrame_ToFromParquetManyPages(t *testing.T) {
+	size := 150000
+	ints, strs, enums := make([]int, size), make([]*string, size), make([]*string, size)
+	values := []string{"x", "y", "z"}
+	for i := range ints {
+		ints[i] = i
+		if i%3 != 0 {
+			s := strconv.Itoa(i)
+			strs[i] = &s
+		}
+
+		if i%100 < 50 {
+			// Long runs of the same value
+			enums[i] = &values[(i/100)%3]
+		}
+	}
+
+	original := qframe.New(map[string]interface{}{"INT": ints, "STRING": strs, "ENUM": enums},
+		newqf.Enums(map[string][]string{"ENUM": values}))
+	buf := new(bytes.Buffer)
+	assertNotErr(t, original.ToParquet(buf))
+
+	out := qframe.ReadParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assertNotErr(t, out.Err)
+	assertEquals(t, original, out)
+}
+
+func TestQFrame_ToFromParquetEmpty(t *testing.T) {
+	original := qframe.New(map[string]interface{}{"INT": []int{}, "STRING": []string{}})
+	buf := new(bytes.Buffer)
+	assertNotErr(t, original.ToParquet(buf))
+
+	out := qframe.ReadParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assertNotErr(t, out.Err)
+	assertEquals(t, original, out)
+
+	// Columns of undefined type are written using the null type
+	original = qframe.ReadCSV(strings.NewReader("A,B\n"))
+	buf.Reset()
+	assertNotErr(t, original.ToParquet(buf))
+
+	out = qframe.ReadParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assertNotErr(t, out.Err)
+	if out.Len() != 0 || !reflect.DeepEqual(out.ColumnTypes(), original.ColumnTypes()) {
+		t.Errorf("Unexpected column types: %v", out.ColumnTypes())
+	}
+}
+
+func TestQFrame_ReadParquetCorrupted(t *testing.T) {
+	for _, codec := range []string{parquet.Uncompressed, parquet.Snappy, parquet.Gzip} {
+		t.Run(codec, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			assertNotErr(t, corruptionTestFrame().ToParquet(buf, parquet.Compression(codec)))
+
+			// Reading corrupted input must result in either an error or a QFrame, never a panic
+			// or huge allocations.
+			corruptedCopies(buf.Bytes(), 3000, func(corrupted []byte) {
+				qframe.ReadParquet(bytes.NewReader(corrupted), int64(len(corrupted)))
+			})
+		})
+	}
+}
+
+func TestQFrame_ParquetErrors(t *testing.T) {
+	out := qframe.ReadParquet(strings.NewReader(""), 0)
+	assertErr(t, out.Err, "file too small")
+
+	out = qframe.ReadParquet(strings.NewReader("PAR1\x00\x00\x00\x00PAR2"), 12)
+	assertErr(t, out.Err, "not a Parquet file")
+
+	out = qframe.ReadParquet(strings.NewReader("PAR1\xFF\xFF\x02\x00\x00\x00PAR1"), 14)
+	assertErr(t, out.Err, "malformed input")
+
+	qf := qframe.New(map[string]interface{}{"INT": []int{1}})
+	assertErr(t, qf.ToParquet(new(bytes.Buffer), parquet.Compression("lz4")), "unknown compression codec")
+}
+
+func TestQFrame_FilterEnum(t *testing.T) {
+	a, b, c, d, e := "a", "b", "c", "d", "e"
+	enums := newqf.Enums(map[string][]string{"COL1": {"a", "b", "c", "d", "e"}})
+	in := qframe.New(map[string]interface{}{
+		"COL1": []*string{&b, &c, &a, nil, &e, &d, nil}}, enums)
+
+	table := []struct {
+		clause   qframe.FilterClause
+		expected map[string]interface{}
+	}{
+		{
+			qframe.Filter{Column: "COL1", Comparator: ">", Arg: "b"},
+			map[string]interface{}{"COL1": []*string{&c, &e, &d}},
+		},
+		{
+			qframe.Filter{Column: "COL1", Comparator: "in", Arg: []string{"a", "b"}},
+			map[string]interface{}{"COL1": []*string{&b, &a}},
+		},
+	}
+
+	for i, tc := range table {
+		t.Run(fmt.Sprintf("Filter enum %d", i), func(t *testing.T) {
+			expected := qframe.New(tc.expected, enums)
+			out := in.Filter(tc.clause)
+			assertEquals(t, expected, out)
+		})
+	}
+}
+
+func TestQFrame_FilterString(t *testing.T) {
+	a, b, c, d, e := "a", "b", "c", "d", "e"
+	withNil := map[string]interface{}{"COL1": []*string{&b, &c, &a, nil, &e, &d, nil}}
+
+	table := []struct {
+		input    map[string]interface{}
+		clause   qframe.FilterClause
+		expected map[string]interface{}
+	}{
+		{
+			withNil,
+			qframe.Filter{Column: "COL1", Comparator: ">", Arg: "b"},
+			map[string]interface{}{"COL1": []*string{&c, &e, &d}},
+		},
+		{
+			withNil,
+			qframe.Filter{Column: "COL1", Comparator: "<", Arg: "b"},
+			map[string]interface{}{"COL1": []*string{&a}},
+		},
+		{
+			withNil,
+			qframe.Filter{Column: "COL1", Comparator: "!=", Arg: "a"},
+			map[string]interface{}{"COL1": []*string{&b, &c, nil, &e, &d, nil}},
+		},
+		{
+			withNil,
+			qframe.Filter{Column: "COL1", Comparator: "like", Arg: "b"},
+			map[string]interface{}{"COL1": []*string{&b}},
+		},
+		{
+			withNil,
+			qframe.Filter{Column: "COL1", Comparator: "in", Arg: []string{"a", "b"}},
+			map[string]interface{}{"COL1": []*string{&b, &a}},
+		},
+	}
+
+	for i, tc := range table {
+		t.Run(fmt.Sprintf("Filter string %d", i), func(t *testing.T) {
+			in := qframe.New(tc.input)
+			expected := qframe.New(tc.expected)
+			out := in.Filter(tc.clause)
+			assertEquals(t, expected, out)
+		})
+	}
+}
+
+func TestQFrame_LikeFilterString(t *testing.T) {
+	col1 := []string{"ABC", "AbC", "DEF", "ABCDEF", "abcdef", "FFF", "abc$def", "défåäöΦ"}
+
+	// Add a couple of fields to be able to verify functionality for high cardinality enums
+	for i := 0; i < 200; i++ {
+		col1 = append(col1, fmt.Sprintf("foo%dbar", i))
+	}
+
+	data := map[string]interface{}{"COL1": col1}
 	for _, enums := range []map[string][]string{{}, {"COL1": nil}} {
 		table := []struct {
 			comparator string
@@ -2005,66 +2799,345 @@ func TestQFrame_AggregateGroupByNull(t *testing.T) {
 	}
 }
 
-func TestQFrame_NewWithConstantVal(t *testing.T) {
-	a := "a"
+func TestQFrame_GroupByParallel(t *testing.T) {
+	size := 20000
+	a, b, c := make([]int, size), make([]*string, size), make([]float64, size)
+	for i := 0; i < size; i++ {
+		a[i] = (i * 7919) % 1013
+		if i%5 != 0 {
+			b[i] = strP(strconv.Itoa(i % 3))
+		}
+		c[i] = float64(i)
+	}
+	in := qframe.New(map[string]interface{}{"A": a, "B": b, "C": c})
+	aggs := []qframe.Aggregation{
+		{Fn: "sum", Column: "C"},
+		{Fn: "count", Column: "C", As: "COUNT"},
+		{Fn: aggregation.StrJoin(","), Column: "B", As: "STRINGS"},
+		{Fn: "median", Column: "A", As: "MEDIAN"},
+	}
+
+	for _, groupNull := range []bool{true, false} {
+		t.Run(fmt.Sprintf("null %v", groupNull), func(t *testing.T) {
+			expected := in.GroupBy(groupby.Columns("A", "B"), groupby.Null(groupNull)).Aggregate(aggs...)
+			out := in.GroupBy(groupby.Columns("A", "B"), groupby.Null(groupNull), groupby.Parallelism(8)).Aggregate(aggs...)
+			assertNotErr(t, out.Err)
+			if groupNull {
+				// The order of the groups is the same as for sequential execution
+				assertEquals(t, expected, out)
+			} else {
+				// Null groups are placed randomly
+				orders := []qframe.Order{{Column: "A"}, {Column: "B"}, {Column: "C"}}
+				assertEquals(t, expected.Sort(orders...), out.Sort(orders...))
+			}
+		})
+	}
+
+	out := in.GroupBy(groupby.Columns("A"), groupby.Parallelism(4)).Aggregate(aggs[0], qframe.Aggregation{Fn: "sum", Column: "B"})
+	assertErr(t, out.Err, "sum")
+}
+
+func TestQFrame_AggregateBuiltIn(t *testing.T) {
+	a, b, c := "a", "b", "c"
+	one, two, three := 1, 2, 3
+	nan := math.NaN()
 	table := []struct {
 		name     string
 		input    interface{}
-		expected interface{}
 		enums    map[string][]string
+		fn       string
+		expected interface{}
 	}{
-		{
-			name:     "int",
-			input:    qframe.ConstInt{Val: 33, Count: 2},
-			expected: []int{33, 33}},
-		{
-			name:     "float",
-			input:    qframe.ConstFloat{Val: 33.5, Count: 2},
-			expected: []float64{33.5, 33.5}},
-		{
-			name:     "bool",
-			input:    qframe.ConstBool{Val: true, Count: 2},
-			expected: []bool{true, true}},
-		{
-			name:     "string",
-			input:    qframe.ConstString{Val: &a, Count: 2},
-			expected: []string{"a", "a"}},
-		{
-			name:     "string null",
-			input:    qframe.ConstString{Val: nil, Count: 2},
-			expected: []*string{nil, nil}},
-		{
-			name:     "enum",
-			input:    qframe.ConstString{Val: &a, Count: 2},
-			expected: []string{"a", "a"},
-			enums:    map[string][]string{"COL1": nil}},
-		{
-			name:     "enum null",
-			input:    qframe.ConstString{Val: nil, Count: 2},
-			expected: []*string{nil, nil},
-			enums:    map[string][]string{"COL1": nil}},
+		{name: "int median", input: []int{1, 2, 3, 4, 10, 20, 30}, fn: "median", expected: []float64{2.5, 20}},
+		{name: "int quantile", input: []int{1, 2, 3, 4, 10, 20, 30}, fn: aggregation.Quantile(0.25), expected: []float64{1.75, 15}},
+		{name: "int var", input: []int{1, 2, 3, 4, 10, 20, 30}, fn: "var", expected: []float64{5.0 / 3, 100}},
+		{name: "int std", input: []int{1, 2, 3, 4, 10, 20, 30}, fn: "std", expected: []float64{math.Sqrt(5.0 / 3), 10}},
+		{name: "int null median", input: []*int{&one, nil, &three, nil, nil, nil, nil}, fn: "median", expected: []float64{2, nan}},
+		{name: "float median NaN", input: []float64{1, nan, 3, 5, 10, nan, 30}, fn: "median", expected: []float64{3, 20}},
+		{name: "float quantile max", input: []float64{1, nan, 3, 5, 10, nan, 30}, fn: "quantile(1)", expected: []float64{5, 30}},
+		{name: "float var single value", input: []float64{1, 2, 3, 4, nan, nan, 30}, fn: "var", expected: []float64{5.0 / 3, nan}},
+		{name: "int first", input: []*int{nil, &two, &three, &one, nil, nil, nil}, fn: "first", expected: []*int{&two, nil}},
+		{name: "int last", input: []*int{nil, &two, &three, &one, &one, &two, nil}, fn: "last", expected: []*int{&one, &two}},
+		{name: "float last NaN", input: []float64{1, 2, 3, nan, 10, 20, nan}, fn: "last", expected: []float64{3, 20}},
+		{name: "string first", input: []*string{nil, &b, &a, &c, nil, &c, nil}, fn: "first", expected: []*string{&b, &c}},
+		{name: "string mode", input: []*string{&b, &a, &b, &a, nil, nil, nil}, fn: "mode", expected: []*string{&a, nil}},
+		{name: "string nunique", input: []*string{&b, &a, &b, nil, nil, nil, nil}, fn: "nunique", expected: []int{2, 0}},
+		{name: "enum mode", input: []*string{&c, &a, &c, nil, &b, &b, &a},
+			enums: map[string][]string{"COL2": {"c", "b", "a"}}, fn: "mode", expected: []*string{&c, &b}},
+		{name: "enum nunique", input: []*string{&c, &a, &c, nil, &b, &b, &a},
+			enums: map[string][]string{"COL2": nil}, fn: "nunique", expected: []int{2, 2}},
+		{name: "float nunique NaN", input: []float64{1, 1, nan, nan, 2, 3, 2}, fn: "nunique", expected: []int{1, 2}},
+		{name: "float sum NaN", input: []float64{1, nan, 3, nan, nan, nan, nan}, fn: "sum", expected: []float64{4, nan}},
+		{name: "float avg NaN", input: []float64{1, nan, 3, nan, nan, nan, nan}, fn: "avg", expected: []float64{2, nan}},
+		{name: "float max NaN", input: []float64{nan, 1, 3, nan, nan, -1, nan}, fn: "max", expected: []float64{3, -1}},
+		{name: "float min NaN", input: []float64{nan, 1, 3, nan, nan, nan, nan}, fn: "min", expected: []float64{1, nan}},
+		{name: "bool mode", input: []bool{true, false, false, true, true, false, true}, fn: "mode", expected: []bool{false, true}},
 	}
 
 	for _, tc := range table {
 		t.Run(tc.name, func(t *testing.T) {
-			in := qframe.New(map[string]interface{}{"COL1": tc.input}, newqf.Enums(tc.enums))
-			expected := qframe.New(map[string]interface{}{"COL1": tc.expected}, newqf.Enums(tc.enums))
-			assertEquals(t, expected, in)
+			var enums map[string][]string
+			expectedEnums := map[string][]string{}
+			if tc.enums != nil {
+				enums = tc.enums
+				if _, ok := tc.expected.([]*string); ok {
+					expectedEnums = tc.enums
+				}
+			}
+
+			input := qframe.New(map[string]interface{}{
+				"COL1": []int{1, 1, 1, 1, 2, 2, 2},
+				"COL2": tc.input,
+			}, newqf.Enums(enums))
+			expected := qframe.New(map[string]interface{}{
+				"COL1": []int{1, 2},
+				"COL2": tc.expected,
+			}, newqf.Enums(expectedEnums), newqf.ColumnOrder("COL1", "COL2"))
+
+			out := input.GroupBy(groupby.Columns("COL1")).Aggregate(qframe.Aggregation{Fn: tc.fn, Column: "COL2"})
+			assertEquals(t, expected, out.Sort(qframe.Order{Column: "COL1"}))
 		})
 	}
 }
 
-func TestQFrame_NewErrors(t *testing.T) {
-	longCol := make([]string, 256)
-	for i := range longCol {
-		longCol[i] = fmt.Sprintf("%d", i)
+func TestQFrame_AggregateBuiltInErrors(t *testing.T) {
+	table := []struct {
+		input interface{}
+		fn    string
+		err   string
+	}{
+		{input: []string{"a", "b"}, fn: "median", err: "only defined for int and float"},
+		{input: []bool{true, false}, fn: "std", err: "only defined for int and float"},
+		{input: []int{1, 2}, fn: "quantile(1.5)", err: "invalid quantile"},
+		{input: []int{1, 2}, fn: "quantile(x)", err: "invalid quantile"},
 	}
 
+	for _, tc := range table {
+		t.Run(tc.fn, func(t *testing.T) {
+			input := qframe.New(map[string]interface{}{"COL1": []int{1, 2}, "COL2": tc.input})
+			out := input.GroupBy(groupby.Columns("COL1")).Aggregate(qframe.Aggregation{Fn: tc.fn, Column: "COL2"})
+			assertErr(t, out.Err, tc.err)
+		})
+	}
+}
+
+func TestQFrame_Describe(t *testing.T) {
+	a, bb, ccc := "a", "bb", "ccc"
+	one, three := 1, 3
+	input := qframe.New(map[string]interface{}{
+		"INT":   []*int{&one, nil, &three, &three},
+		"FLOAT": []float64{1, 2, math.NaN(), 5},
+		"STR":   []*string{&bb, &a, nil, &bb},
+		"ENUM":  []*string{&ccc, &ccc, &ccc, &ccc},
+		"BOOL":  []bool{true, false, true, true},
+	}, newqf.ColumnOrder("INT", "FLOAT", "STR", "ENUM", "BOOL"), newqf.Enums(map[string][]string{"ENUM": nil}))
+
+	nan := math.NaN()
+	two, four := 2, 4
+	expected := qframe.New(map[string]interface{}{
+		"column":     []string{"INT", "FLOAT", "STR", "ENUM", "BOOL"},
+		"type":       []string{"int", "float", "string", "enum", "bool"},
+		"count":      []int{3, 3, 3, 4, 4},
+		"null_count": []int{1, 1, 1, 0, 0},
+		"distinct":   []int{2, 3, 2, 1, 2},
+		"min":        []float64{1, 1, nan, nan, nan},
+		"max":        []float64{3, 5, nan, nan, nan},
+		"mean":       []float64{7.0 / 3, 8.0 / 3, nan, nan, nan},
+		"std":        []float64{math.Sqrt(4.0 / 3), math.Sqrt(13.0 / 3), nan, nan, nan},
+		"p25":        []float64{2, 1.5, nan, nan, nan},
+		"p50":        []float64{3, 2, nan, nan, nan},
+		"p75":        []float64{3, 3.5, nan, nan, nan},
+		"top":        []*string{nil, nil, &bb, &ccc, nil},
+		"top_count":  []*int{nil, nil, &two, &four, nil},
+		"min_length": []*int{nil, nil, &one, &three, nil},
+		"max_length": []*int{nil, nil, &two, &three, nil},
+	}, newqf.ColumnOrder("column", "type", "count", "null_count", "distinct", "min", "max", "mean", "std",
+		"p25", "p50", "p75", "top", "top_count", "min_length", "max_length"))
+
+	out := input.Describe()
+	assertNotErr(t, out.Err)
+	// Compare floats with some tolerance
+	assertEquals(t, expected.Drop("std"), out.Drop("std"))
+	std := out.MustFloatView("std")
+	for i, v := range expected.MustFloatView("std").Slice() {
+		if math.IsNaN(v) != math.IsNaN(std.ItemAt(i)) || math.Abs(v-std.ItemAt(i)) > 1e-9 {
+			t.Errorf("Unexpected std at %d: %f != %f", i, v, std.ItemAt(i))
+		}
+	}
+}
+
+func TestQFrame_SeriesOperations(t *testing.T) {
+	one, two, three, five := 1, 2, 3, 5
+	nan := math.NaN()
+	a, b, c, d := "a", "b", "c", "d"
+	six := 6
 	table := []struct {
-		input   map[string]interface{}
-		configs []newqf.ConfigFunc
-		err     string
-	}{
+		name     string
+		input    interface{}
+		fn       func(f qframe.QFrame) qframe.QFrame
+		expected interface{}
+	}{
+		{name: "shift lag", input: []int{1, 2, 3, 5},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.Shift("DST", "COL", 1) },
+			expected: []*int{nil, &one, &two, &three}},
+		{name: "shift lead", input: []string{"a", "b", "c", "d"},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.Shift("DST", "COL", -2) },
+			expected: []*string{&c, &d, nil, nil}},
+		{name: "diff int", input: []*int{&one, &two, nil, &five},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.Diff("DST", "COL", 1) },
+			expected: []*int{nil, &one, nil, nil}},
+		{name: "diff float", input: []float64{1, 2, 4, 8},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.Diff("DST", "COL", 2) },
+			expected: []float64{nan, nan, 3, 6}},
+		{name: "pct change", input: []int{1, 2, 3, 6},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.PctChange("DST", "COL", 1) },
+			expected: []float64{nan, 1, 0.5, 1}},
+		{name: "cum sum int", input: []*int{&one, nil, &two, &three},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.CumSum("DST", "COL") },
+			expected: []*int{&one, nil, &three, &six}},
+		{name: "cum sum float", input: []float64{1.5, nan, 2, 3},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.CumSum("DST", "COL") },
+			expected: []float64{1.5, nan, 3.5, 6.5}},
+		{name: "cum max", input: []float64{2, 1, nan, 3},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.CumMax("DST", "COL") },
+			expected: []float64{2, 2, nan, 3}},
+		{name: "cum min string", input: []*string{&b, &c, nil, &a},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.CumMin("DST", "COL") },
+			expected: []*string{&b, &b, nil, &a}},
+		{name: "cum count", input: []int{5, 6, 7, 8},
+			fn:       func(f qframe.QFrame) qframe.QFrame { return f.CumCount("DST") },
+			expected: []int{0, 1, 2, 3}},
+		{name: "respects sort order", input: []int{3, 1, 2, 5},
+			fn: func(f qframe.QFrame) qframe.QFrame {
+				return f.Sort(qframe.Order{Column: "COL", Reverse: true}).CumSum("DST", "COL").Sort(qframe.Order{Column: "COL"})
+			},
+			expected: []int{11, 10, 8, 5}},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			input := qframe.New(map[string]interface{}{"COL": tc.input})
+			expected := qframe.New(map[string]interface{}{"DST": tc.expected})
+			out := tc.fn(input)
+			assertNotErr(t, out.Err)
+			assertEquals(t, expected, out.Select("DST"))
+		})
+	}
+}
+
+func TestQFrame_SeriesOperationsNoNulls(t *testing.T) {
+	// Int results without null values have no validity bitmap
+	out := qframe.New(map[string]interface{}{"COL": []int{1, 2, 3}}).CumSum("DST", "COL").Select("DST")
+	assertNotErr(t, out.Err)
+	expected := qframe.New(map[string]interface{}{"DST": []int{1, 3, 6}})
+	assertEquals(t, expected, out)
+	if out.ByteSize() != expected.ByteSize() {
+		t.Errorf("Unexpected byte size %d, expected %d", out.ByteSize(), expected.ByteSize())
+	}
+}
+
+func TestQFrame_SeriesOperationsGrouped(t *testing.T) {
+	input := qframe.New(map[string]interface{}{
+		"KEY": []string{"a", "b", "a", "b", "a"},
+		"COL": []int{1, 10, 2, 20, 4},
+	})
+
+	g := input.GroupBy(groupby.Columns("KEY"))
+	one, two, ten := 1, 2, 10
+	table := []struct {
+		name     string
+		out      qframe.QFrame
+		expected interface{}
+	}{
+		{name: "shift", out: g.Shift("DST", "COL", 1), expected: []*int{nil, nil, &one, &ten, &two}},
+		{name: "diff", out: g.Diff("DST", "COL", 1), expected: []*int{nil, nil, &one, &ten, &two}},
+		{name: "pct change", out: g.PctChange("DST", "COL", 1), expected: []float64{math.NaN(), math.NaN(), 1, 1, 1}},
+		{name: "cum sum", out: g.CumSum("DST", "COL"), expected: []int{1, 10, 3, 30, 7}},
+		{name: "cum max", out: g.CumMax("DST", "COL"), expected: []int{1, 10, 2, 20, 4}},
+		{name: "cum min", out: g.CumMin("DST", "COL"), expected: []int{1, 10, 1, 10, 1}},
+		{name: "cum count", out: g.CumCount("DST"), expected: []int{0, 0, 1, 1, 2}},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := qframe.New(map[string]interface{}{
+				"KEY": []string{"a", "b", "a", "b", "a"},
+				"COL": []int{1, 10, 2, 20, 4},
+				"DST": tc.expected,
+			}, newqf.ColumnOrder("COL", "KEY", "DST"))
+			assertEquals(t, expected, tc.out)
+		})
+	}
+}
+
+func TestQFrame_SeriesOperationsErrors(t *testing.T) {
+	input := qframe.New(map[string]interface{}{"COL": []string{"a", "b"}})
+	assertErr(t, input.Diff("DST", "COL", 1).Err, "only defined for int and float")
+	assertErr(t, input.CumSum("DST", "COL").Err, "only defined for int and float")
+	assertErr(t, input.Shift("DST", "FOO", 1).Err, "unknown column")
+	assertErr(t, input.GroupBy(groupby.Columns("FOO")).CumSum("DST", "COL").Err, "unknown column")
+}
+
+func TestQFrame_NewWithConstantVal(t *testing.T) {
+	a := "a"
+	table := []struct {
+		name     string
+		input    interface{}
+		expected interface{}
+		enums    map[string][]string
+	}{
+		{
+			name:     "int",
+			input:    qframe.ConstInt{Val: 33, Count: 2},
+			expected: []int{33, 33}},
+		{
+			name:     "float",
+			input:    qframe.ConstFloat{Val: 33.5, Count: 2},
+			expected: []float64{33.5, 33.5}},
+		{
+			name:     "bool",
+			input:    qframe.ConstBool{Val: true, Count: 2},
+			expected: []bool{true, true}},
+		{
+			name:     "string",
+			input:    qframe.ConstString{Val: &a, Count: 2},
+			expected: []string{"a", "a"}},
+		{
+			name:     "string null",
+			input:    qframe.ConstString{Val: nil, Count: 2},
+			expected: []*string{nil, nil}},
+		{
+			name:     "enum",
+			input:    qframe.ConstString{Val: &a, Count: 2},
+			expected: []string{"a", "a"},
+			enums:    map[string][]string{"COL1": nil}},
+		{
+			name:     "enum null",
+			input:    qframe.ConstString{Val: nil, Count: 2},
+			expected: []*string{nil, nil},
+			enums:    map[string][]string{"COL1": nil}},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			in := qframe.New(map[string]interface{}{"COL1": tc.input}, newqf.Enums(tc.enums))
+			expected := qframe.New(map[string]interface{}{"COL1": tc.expected}, newqf.Enums(tc.enums))
+			assertEquals(t, expected, in)
+		})
+	}
+}
+
+func TestQFrame_NewErrors(t *testing.T) {
+	longCol := make([]string, 256)
+	for i := range longCol {
+		longCol[i] = fmt.Sprintf("%d", i)
+	}
+
+	table := []struct {
+		input   map[string]interface{}
+		configs []newqf.ConfigFunc
+		err     string
+	}{
 		{
 			input: map[string]interface{}{"": []int{1}},
 			err:   "must not be empty"},
@@ -2077,14 +3150,6 @@ func TestQFrame_NewErrors(t *testing.T) {
 		{
 			input: map[string]interface{}{"$foo": []int{1}},
 			err:   "must not start with $"},
-		{
-			input:   map[string]interface{}{"COL1": longCol},
-			configs: []newqf.ConfigFunc{newqf.Enums(map[string][]string{"COL1": nil})},
-			err:     "enum max cardinality"},
-		{
-			input:   map[string]interface{}{"COL1": longCol},
-			configs: []newqf.ConfigFunc{newqf.Enums(map[string][]string{"COL1": nil})},
-			err:     "enum max cardinality"},
 		{
 			input:   map[string]interface{}{"COL1": longCol},
 			configs: []newqf.ConfigFunc{newqf.Enums(map[string][]string{"COL2": nil})},
@@ -2411,6 +3476,18 @@ func TestQFrame_EnumView(t *testing.T) {
 	assertTrue(t, (*v.ItemAt(2) == *s[2]) && (*s[2] == *expected[2]))
 }
 
+func intP(x int) *int {
+	return &x
+}
+
+func boolP(x bool) *bool {
+	return &x
+}
+
+func strP(x string) *string {
+	return &x
+}
+
 func col(c string) types.ColumnName {
 	return types.ColumnName(c)
 }
@@ -2499,6 +3576,211 @@ func TestQFrame_EvalSuccess(t *testing.T) {
 			input:    map[string]interface{}{"COL1": []float64{18}, "COL2": []float64{2}, "COL3": []float64{3}},
 			dstCol:   "COL4",
 			expected: []float64{3}},
+		{
+			name:     "int col greater than col",
+			expr:     qframe.Expr(">", col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []int{1, 3}, "COL2": []int{2, 2}},
+			expected: []bool{false, true}},
+		{
+			name:     "float col less than or equal to constant",
+			expr:     qframe.Expr("<=", col("COL1"), 2.0),
+			input:    map[string]interface{}{"COL1": []float64{1, 3, math.NaN()}},
+			expected: []*bool{boolP(true), boolP(false), nil}},
+		{
+			name:     "string col equals constant",
+			expr:     qframe.Expr("==", col("COL1"), "b"),
+			input:    map[string]interface{}{"COL1": []*string{strP("a"), strP("b"), nil}},
+			expected: []*bool{boolP(false), boolP(true), nil}},
+		{
+			name:     "enum col not equal to col",
+			expr:     qframe.Expr("!=", col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []string{"a", "b"}, "COL2": []string{"a", "c"}},
+			expected: []bool{false, true},
+			enums:    map[string][]string{"COL1": nil, "COL2": nil}},
+		{
+			name:     "bool col equals col",
+			expr:     qframe.Expr("==", col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []bool{true, false}, "COL2": []bool{true, true}},
+			expected: []bool{true, false}},
+		{
+			name: "time col before col",
+			expr: qframe.Expr("<", col("COL1"), col("COL2")),
+			input: map[string]interface{}{
+				"COL1": []time.Time{time.Unix(1, 0), time.Unix(3, 0)},
+				"COL2": []time.Time{time.Unix(2, 0), time.Unix(2, 0)}},
+			expected: []bool{true, false}},
+		{
+			name:     "if",
+			expr:     qframe.Expr("if", qframe.Expr(">", col("COL1"), col("COL2")), col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []*int{intP(1), intP(3), nil}, "COL2": []int{2, 2, 5}},
+			expected: []int{2, 3, 5}},
+		{
+			name:     "coalesce",
+			expr:     qframe.Expr("coalesce", col("COL1"), col("COL2"), "c"),
+			input:    map[string]interface{}{"COL1": []*string{strP("a"), nil, nil}, "COL2": []*string{nil, strP("b"), nil}},
+			expected: []string{"a", "b", "c"}},
+		{
+			name: "case",
+			expr: qframe.Expr("case",
+				qframe.Expr("<", col("COL1"), 0.0), "negative",
+				qframe.Expr("==", col("COL1"), 0.0), "zero"),
+			input:    map[string]interface{}{"COL1": []float64{-1, 0, 1, math.NaN()}},
+			expected: []*string{strP("negative"), strP("zero"), nil, nil}},
+		{
+			name:     "case with default",
+			expr:     qframe.Expr("case", qframe.Expr("<", col("COL1"), 0.0), "negative", "positive"),
+			input:    map[string]interface{}{"COL1": []float64{-1, 1}},
+			expected: []string{"negative", "positive"}},
+		{
+			name:     "parsed comparison and condition",
+			expr:     qframe.ParseExpr("if(revenue > cost, 'profit', 'loss')"),
+			input:    map[string]interface{}{"revenue": []int{5, 1}, "cost": []int{3, 2}},
+			dstCol:   "z",
+			expected: []string{"profit", "loss"}},
+		{
+			name:     "int col plus float col promoted to float",
+			expr:     qframe.Expr("+", col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []*int{intP(1), nil}, "COL2": []float64{1.5, 2.5}},
+			expected: []float64{2.5, math.NaN()}},
+		{
+			name:     "float col times int constant promoted to float",
+			expr:     qframe.ParseExpr("-COL1 * 2"),
+			input:    map[string]interface{}{"COL1": []float64{1.5, 2.5}},
+			expected: []float64{-3, -5}},
+		{
+			name:     "bool col plus int col promoted to int",
+			expr:     qframe.Expr("+", col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []bool{true, false}, "COL2": []int{1, 2}},
+			expected: []int{2, 2}},
+		{
+			name:     "int col compared to float col",
+			expr:     qframe.Expr(">", col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []int{1, 3}, "COL2": []float64{1.5, 2.5}},
+			expected: []bool{false, true}},
+		{
+			name:     "enum col plus string col promoted to string",
+			expr:     qframe.Expr("+", col("COL1"), col("COL2")),
+			input:    map[string]interface{}{"COL1": []string{"a", "b"}, "COL2": []string{"A", "B"}},
+			expected: []string{"aA", "bB"},
+			enums:    map[string][]string{"COL1": nil}},
+		{
+			name:     "if with int and float values",
+			expr:     qframe.Expr("if", col("COL1"), 1, 2.5),
+			input:    map[string]interface{}{"COL1": []bool{true, false}},
+			expected: []float64{1, 2.5}},
+		{
+			name:     "float math functions",
+			expr:     qframe.ParseExpr("sqrt(COL1) + log(exp(COL1)) + floor(COL1) + ceil(COL1)"),
+			input:    map[string]interface{}{"COL1": []float64{4, 2.25, math.NaN()}},
+			expected: []float64{14, 8.75, math.NaN()}},
+		{
+			name:     "float pow",
+			expr:     qframe.Expr("pow", col("COL1"), 2),
+			input:    map[string]interface{}{"COL1": []float64{3, -1.5}},
+			expected: []float64{9, 2.25}},
+		{
+			name:     "float round",
+			expr:     qframe.Expr("round", col("COL1")),
+			input:    map[string]interface{}{"COL1": []float64{1.5, -2.5, 1.2345}},
+			expected: []float64{2, -3, 1}},
+		{
+			name:     "float round with decimals",
+			expr:     qframe.Expr("round", col("COL1"), 2),
+			input:    map[string]interface{}{"COL1": []float64{1.005, -2.555, 1.2345, math.NaN()}},
+			expected: []float64{1, -2.56, 1.23, math.NaN()}},
+		{
+			name:     "float clip",
+			expr:     qframe.ParseExpr("clip(COL1 * 2, 0, 1.5)"),
+			input:    map[string]interface{}{"COL1": []float64{-1, 0.5, 1, math.NaN()}},
+			expected: []float64{0, 1, 1.5, math.NaN()}},
+		{
+			name:     "int math functions promoted to float",
+			expr:     qframe.ParseExpr("sqrt(COL1) + floor(COL1)"),
+			input:    map[string]interface{}{"COL1": []int{4, 9}},
+			expected: []float64{6, 12}},
+		{
+			name:     "int pow promoted to float",
+			expr:     qframe.Expr("pow", col("COL1"), 2),
+			input:    map[string]interface{}{"COL1": []int{3, -2}},
+			expected: []float64{9, 4}},
+		{
+			name:     "int round with decimals promoted to float",
+			expr:     qframe.Expr("round", col("COL1"), 1),
+			input:    map[string]interface{}{"COL1": []*int{intP(7), nil}},
+			expected: []float64{7, math.NaN()}},
+		{
+			name:     "int clip promoted to float",
+			expr:     qframe.Expr("clip", col("COL1"), 0, 2),
+			input:    map[string]interface{}{"COL1": []int{-1, 1, 5}},
+			expected: []float64{0, 1, 2}},
+		{
+			name:     "int clip of expression promoted to float",
+			expr:     qframe.ParseExpr("clip(COL1 + 1, 0, 2.5)"),
+			input:    map[string]interface{}{"COL1": []int{-3, 1, 5}},
+			expected: []float64{0, 2, 2.5}},
+		{
+			name:     "float format",
+			expr:     qframe.Expr("format", col("COL1"), "%.1f%%"),
+			input:    map[string]interface{}{"COL1": []float64{12.34, math.NaN()}},
+			expected: []*string{strP("12.3%"), nil}},
+		{
+			name:     "int format",
+			expr:     qframe.Expr("format", col("COL1"), "%03d"),
+			input:    map[string]interface{}{"COL1": []*int{intP(7), nil}},
+			expected: []*string{strP("007"), nil}},
+		{
+			name:     "string trim",
+			expr:     qframe.Expr("trim", col("COL1")),
+			input:    map[string]interface{}{"COL1": []*string{strP("  a b\t"), nil}},
+			expected: []*string{strP("a b"), nil}},
+		{
+			name:     "string contains and startswith",
+			expr:     qframe.ParseExpr("contains(COL1, 'b') | startswith(COL1, COL2)"),
+			input:    map[string]interface{}{"COL1": []*string{strP("abc"), strP("cde"), strP("xyz"), nil}, "COL2": []string{"x", "c", "y", "a"}},
+			expected: []*bool{boolP(true), boolP(true), boolP(false), nil}},
+		{
+			name:     "string replace",
+			expr:     qframe.Expr("replace", col("COL1"), "a", "AA"),
+			input:    map[string]interface{}{"COL1": []*string{strP("banana"), nil}},
+			expected: []*string{strP("bAAnAAnAA"), nil}},
+		{
+			name:     "string substr",
+			expr:     qframe.Expr("substr", col("COL1"), 1, 2),
+			input:    map[string]interface{}{"COL1": []*string{strP("åäö"), strP("abcd"), strP("a"), nil}},
+			expected: []*string{strP("äö"), strP("bc"), strP(""), nil}},
+		{
+			name:     "string substr to end",
+			expr:     qframe.Expr("substr", col("COL1"), 2, -1),
+			input:    map[string]interface{}{"COL1": []string{"abcd"}},
+			expected: []string{"cd"}},
+		{
+			name:     "string split part",
+			expr:     qframe.Expr("split_part", col("COL1"), ",", 1),
+			input:    map[string]interface{}{"COL1": []*string{strP("a,b,c"), strP("a"), nil}},
+			expected: []*string{strP("b"), nil, nil}},
+		{
+			name:     "string regex extract",
+			expr:     qframe.Expr("regex_extract", col("COL1"), "id=([0-9]+)"),
+			input:    map[string]interface{}{"COL1": []*string{strP("x id=123 y"), strP("id=x"), nil}},
+			expected: []*string{strP("123"), nil, nil}},
+		{
+			name:     "string pad",
+			expr:     qframe.ParseExpr("pad(COL1, 4, '0') + pad(COL1, -4, '.')"),
+			input:    map[string]interface{}{"COL1": []*string{strP("12"), strP("12345"), nil}},
+			expected: []*string{strP("001212.."), strP("1234512345"), nil}},
+		{
+			name:     "enum format",
+			expr:     qframe.Expr("format", col("COL1"), "<%s>"),
+			input:    map[string]interface{}{"COL1": []*string{strP("a"), nil}},
+			expected: []*string{strP("<a>"), nil},
+			enums:    map[string][]string{"COL1": nil}},
+		{
+			name:         "custom parameterized function",
+			expr:         qframe.Expr("scale", col("COL1"), 3),
+			input:        map[string]interface{}{"COL1": []int{1, 2}},
+			expected:     []int{3, 6},
+			customFnName: "scale",
+			customFn:     func(n int) func(int) int { return func(x int) int { return n * x } }},
 		{
 			name:     "chained multi argument evaluation - four arguments including constant",
 			expr:     qframe.Expr("/", col("COL1"), col("COL2"), col("COL3"), 3.0),
@@ -2532,6 +3814,154 @@ func TestQFrame_EvalSuccess(t *testing.T) {
 	}
 }
 
+func TestQFrame_ParseExpr(t *testing.T) {
+	table := []struct {
+		expr     string
+		input    map[string]interface{}
+		expected interface{}
+	}{
+		{expr: "a * 2 + abs(b)",
+			input:    map[string]interface{}{"a": []int{1, 2}, "b": []int{-3, 4}},
+			expected: []int{5, 8}},
+		{expr: "a * (2 + b)",
+			input:    map[string]interface{}{"a": []int{1, 2}, "b": []int{-3, 4}},
+			expected: []int{-1, 12}},
+		{expr: "a - b - 1",
+			input:    map[string]interface{}{"a": []int{10, 20}, "b": []int{1, 2}},
+			expected: []int{8, 17}},
+		{expr: "-a + 1",
+			input:    map[string]interface{}{"a": []int{10, 20}},
+			expected: []int{-9, -19}},
+		{expr: `"col a" / -2.0`,
+			input:    map[string]interface{}{"col a": []float64{1, 3}},
+			expected: []float64{-0.5, -1.5}},
+		{expr: "x | y & !z",
+			input:    map[string]interface{}{"x": []bool{false, true}, "y": []bool{true, false}, "z": []bool{false, true}},
+			expected: []bool{true, true}},
+		{expr: "upper(s) + 'it''s'",
+			input:    map[string]interface{}{"s": []string{"a", "b"}},
+			expected: []string{"Ait's", "Bit's"}},
+		{expr: "str(a) + str(int(1e1))",
+			input:    map[string]interface{}{"a": []int{1, 2}},
+			expected: []string{"110", "210"}},
+		{expr: "nand(x, true)",
+			input:    map[string]interface{}{"x": []bool{false, true}},
+			expected: []bool{true, false}},
+		{expr: "null",
+			input:    map[string]interface{}{"x": []bool{false, true}},
+			expected: []*string{nil, nil}},
+		{expr: "é + 1",
+			input:    map[string]interface{}{"é": []int{1, 2}},
+			expected: []int{2, 3}},
+		{expr: "größe_2 * 2",
+			input:    map[string]interface{}{"größe_2": []int{1, 2}},
+			expected: []int{2, 4}},
+		{expr: `"été à" + 1`,
+			input:    map[string]interface{}{"été à": []int{1, 2}},
+			expected: []int{2, 3}},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr := qframe.ParseExpr(tc.expr)
+			assertNotErr(t, expr.Err())
+			out := qframe.New(tc.input).Eval("result", expr)
+			assertNotErr(t, out.Err)
+			assertEquals(t, qframe.New(map[string]interface{}{"result": tc.expected}), out.Select("result"))
+		})
+	}
+}
+
+func TestQFrame_ParseExprErrors(t *testing.T) {
+	table := []struct {
+		expr string
+		err  string
+	}{
+		{expr: "a +", err: "unexpected end of expression at position 4"},
+		{expr: "a + * b", err: "unexpected '*' at position 5"},
+		{expr: "(a + b", err: "unexpected end of expression at position 7"},
+		{expr: "a b", err: "unexpected 'b' at position 3"},
+		{expr: "abs()", err: "function 'abs' requires at least one argument at position 1"},
+		{expr: "f(a b)", err: "unexpected 'b' at position 5"},
+		{expr: "'abc", err: "unterminated quote ' at position 1"},
+		{expr: "a # b", err: "unexpected character '#' at position 3"},
+		{expr: "é € b", err: "unexpected character '€' at position 4"},
+		{expr: "1.2.3", err: "invalid number '1.2.3' at position 1"},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.expr, func(t *testing.T) {
+			assertErr(t, qframe.ParseExpr(tc.expr).Err(), tc.err)
+		})
+	}
+
+	// Unknown functions are reported when the expression is evaluated
+	out := qframe.New(map[string]interface{}{"a": []int{1}}).Eval("b", qframe.ParseExpr("foo(a)"))
+	assertErr(t, out.Err, "Could not find")
+}
+
+func TestQFrame_EvalConditionalErrors(t *testing.T) {
+	in := qframe.New(map[string]interface{}{"COL1": []int{1, 2}, "COL2": []string{"a", "b"}})
+	table := []struct {
+		expr qframe.Expression
+		err  string
+	}{
+		{expr: qframe.Expr("if", col("COL1"), col("COL2")), err: "invalid number of arguments to if: 2"},
+		{expr: qframe.Expr("if", col("COL1"), col("COL1"), col("COL1")), err: "condition must be bool"},
+		{expr: qframe.Expr("coalesce", col("COL1"), col("COL2")), err: "cannot combine columns of type int and string"},
+	}
+
+	for _, tc := range table {
+		out := in.Eval("COL3", tc.expr)
+		assertErr(t, out.Err, tc.err)
+	}
+}
+
+func TestQFrame_EvalParamFuncErrors(t *testing.T) {
+	in := qframe.New(map[string]interface{}{"FLOAT": []float64{1, 2}, "INT": []int{1, 2}, "STRING": []string{"a", "b"}})
+	table := []struct {
+		expr qframe.Expression
+		err  string
+	}{
+		{expr: qframe.Expr("clip", col("FLOAT"), 1), err: "expected 2 parameters, was 1"},
+		{expr: qframe.Expr("round", col("FLOAT"), 1.5), err: "parameter 1 must be int, was float64"},
+		{expr: qframe.Expr("replace", col("STRING"), "a", nil), err: "parameter 2 must not be null"},
+		{expr: qframe.Expr("regex_extract", col("STRING"), "("), err: "missing closing )"},
+		{expr: qframe.Expr("pad", col("STRING"), 3, "ab"), err: "fill must be a single character"},
+		{expr: qframe.Expr("clip", col("STRING"), 0, 1), err: "Could not find parameterized String function with name 'clip'"},
+		{expr: qframe.Expr("round", col("STRING"), 1), err: "Could not find parameterized String function with name 'round'"},
+		{expr: qframe.Expr("clip", col("INT"), "a", 1), err: "parameter 1 must be float64, was string"},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.err, func(t *testing.T) {
+			out := in.Eval("RESULT", tc.expr)
+			assertErr(t, out.Err, tc.err)
+		})
+	}
+}
+
+func TestContext_String(t *testing.T) {
+	s := eval.NewDefaultCtx().String()
+	for _, name := range []string{"sqrt", "pow", "clip", "trim", "startswith", "split_part", "regex_extract", "Parameterized"} {
+		if !strings.Contains(s, "  "+name+"\n") && !strings.Contains(s, " "+name+"\n") {
+			t.Errorf("%s not found in context: %s", name, s)
+		}
+	}
+}
+
+func TestQFrame_ApplyPromotion(t *testing.T) {
+	in := qframe.New(map[string]interface{}{"INT": []int{1, 2}, "FLOAT": []float64{0.5, 1.5}, "STRING": []string{"a", "b"}})
+	out := in.Apply(qframe.Instruction{Fn: function.MulF, DstCol: "RESULT", SrcCol1: "INT", SrcCol2: "FLOAT"})
+	assertEquals(t, qframe.New(map[string]interface{}{"RESULT": []float64{0.5, 3}}), out.Select("RESULT"))
+
+	out = in.Apply(qframe.Instruction{Fn: function.PlusI, DstCol: "RESULT", SrcCol1: "INT", SrcCol2: "STRING"})
+	assertErr(t, out.Err, "cannot combine columns of type int and string")
+
+	out = in.Eval("RESULT", qframe.Expr("+", col("STRING"), col("INT")))
+	assertErr(t, out.Err, "cannot combine columns of type string and int")
+}
+
 func TestQFrame_Typing(t *testing.T) {
 	qf := qframe.New(map[string]interface{}{
 		"ints":    []int{1, 2},
@@ -2593,6 +4023,23 @@ func TestQFrame_AppendSuccess(t *testing.T) {
 	assertEquals(t, expected, f1.Append(f2, f3))
 }
 
+func TestQFrame_AppendTypes(t *testing.T) {
+	a, b, c := "a", "b", "c"
+	f1 := qframe.New(map[string]interface{}{
+		"FLOAT": []float64{1.5}, "BOOL": []bool{true}, "STRING": []*string{&a}, "ENUM": []*string{&a}},
+		newqf.Enums(map[string][]string{"ENUM": nil}))
+	f2 := qframe.New(map[string]interface{}{
+		"FLOAT": []float64{2.5, math.NaN()}, "BOOL": []bool{false, true}, "STRING": []*string{nil, &b}, "ENUM": []*string{&c, nil}},
+		newqf.Enums(map[string][]string{"ENUM": nil}))
+	expected := qframe.New(map[string]interface{}{
+		"FLOAT":  []float64{1.5, 2.5, math.NaN()},
+		"BOOL":   []bool{true, false, true},
+		"STRING": []*string{&a, nil, &b},
+		"ENUM":   []*string{&a, &c, nil}},
+		newqf.Enums(map[string][]string{"ENUM": nil}))
+	assertEquals(t, expected, f1.Append(f2))
+}
+
 func assertContainsQFrame(t *testing.T, frames []qframe.QFrame, frame qframe.QFrame) {
 	t.Helper()
 	for _, f := range frames {
@@ -2616,3 +4063,772 @@ func TestQFrame_GroupByQFrames(t *testing.T) {
 	assertContainsQFrame(t, ff, qframe.New(map[string]interface{}{"COL1": []int{2}, "COL2": []int{20}}))
 	assertContainsQFrame(t, ff, qframe.New(map[string]interface{}{"COL1": []int{3, 3}, "COL2": []int{30, 31}}))
 }
+
+func TestQFrame_Join(t *testing.T) {
+	a, b, c, d, x, y, z := "a", "b", "c", "d", "x", "y", "z"
+	one, two, three, four := 1, 2, 3, 4
+	left := qframe.New(map[string]interface{}{
+		"KEY": []*string{&a, &b, &c, nil},
+		"VAL": []int{1, 2, 3, 4}},
+		newqf.ColumnOrder("KEY", "VAL"))
+	right := qframe.New(map[string]interface{}{
+		"KEY":   []*string{&b, &b, &d, &a, nil},
+		"VAL":   []float64{20, 21, 40, 10, 50},
+		"OTHER": []*string{&x, &y, &z, &x, &y}},
+		newqf.ColumnOrder("KEY", "OTHER", "VAL"))
+
+	table := []struct {
+		name     string
+		configs  []join.ConfigFunc
+		expected map[string]interface{}
+		order    []string
+	}{
+		{
+			name:    "inner",
+			configs: []join.ConfigFunc{join.On("KEY")},
+			expected: map[string]interface{}{
+				"KEY":       []*string{&a, &b, &b},
+				"VAL_left":  []int{1, 2, 2},
+				"OTHER":     []*string{&x, &x, &y},
+				"VAL_right": []float64{10, 20, 21}},
+			order: []string{"KEY", "VAL_left", "OTHER", "VAL_right"}},
+		{
+			name:    "left",
+			configs: []join.ConfigFunc{join.On("KEY"), join.How("left"), join.Suffixes("", "_r")},
+			expected: map[string]interface{}{
+				"KEY":   []*string{&a, &b, &b, &c, nil},
+				"VAL":   []int{1, 2, 2, 3, 4},
+				"OTHER": []*string{&x, &x, &y, nil, nil},
+				"VAL_r": []float64{10, 20, 21, math.NaN(), math.NaN()}},
+			order: []string{"KEY", "VAL", "OTHER", "VAL_r"}},
+		{
+			name:    "right",
+			configs: []join.ConfigFunc{join.On("KEY"), join.How("right")},
+			expected: map[string]interface{}{
+				"KEY":       []*string{&b, &b, &d, &a, nil},
+				"VAL_left":  []*int{&two, &two, nil, &one, nil},
+				"OTHER":     []*string{&x, &y, &z, &x, &y},
+				"VAL_right": []float64{20, 21, 40, 10, 50}},
+			order: []string{"KEY", "VAL_left", "OTHER", "VAL_right"}},
+		{
+			name:    "full",
+			configs: []join.ConfigFunc{join.On("KEY"), join.How("full")},
+			expected: map[string]interface{}{
+				"KEY":       []*string{&a, &b, &b, &c, nil, &d, nil},
+				"VAL_left":  []*int{&one, &two, &two, &three, &four, nil, nil},
+				"OTHER":     []*string{&x, &x, &y, nil, nil, &z, &y},
+				"VAL_right": []float64{10, 20, 21, math.NaN(), math.NaN(), 40, 50}},
+			order: []string{"KEY", "VAL_left", "OTHER", "VAL_right"}},
+		{
+			name:    "full with null keys matching",
+			configs: []join.ConfigFunc{join.On("KEY"), join.How("full"), join.Null(true)},
+			expected: map[string]interface{}{
+				"KEY":       []*string{&a, &b, &b, &c, nil, &d},
+				"VAL_left":  []*int{&one, &two, &two, &three, &four, nil},
+				"OTHER":     []*string{&x, &x, &y, nil, &y, &z},
+				"VAL_right": []float64{10, 20, 21, math.NaN(), 50, 40}},
+			order: []string{"KEY", "VAL_left", "OTHER", "VAL_right"}},
+		{
+			name:     "semi",
+			configs:  []join.ConfigFunc{join.On("KEY"), join.How("semi")},
+			expected: map[string]interface{}{"KEY": []*string{&a, &b}, "VAL": []int{1, 2}},
+			order:    []string{"KEY", "VAL"}},
+		{
+			name:     "anti",
+			configs:  []join.ConfigFunc{join.On("KEY"), join.How("anti")},
+			expected: map[string]interface{}{"KEY": []*string{&c, nil}, "VAL": []int{3, 4}},
+			order:    []string{"KEY", "VAL"}},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			out := left.Join(right, tc.configs...)
+			assertNotErr(t, out.Err)
+			assertEquals(t, qframe.New(tc.expected, newqf.ColumnOrder(tc.order...)), out)
+			if !reflect.DeepEqual(out.ColumnNames(), tc.order) {
+				t.Errorf("Unexpected column order: %v", out.ColumnNames())
+			}
+		})
+	}
+}
+
+func TestQFrame_JoinMultipleKeys(t *testing.T) {
+	a, b := "a", "b"
+	left := qframe.New(map[string]interface{}{
+		"K1": []int{1, 1, 2, 2},
+		"K2": []*string{&a, &b, &a, &b},
+		"X":  []bool{true, false, true, false}},
+		newqf.Enums(map[string][]string{"K2": {"b", "a"}}))
+	right := qframe.New(map[string]interface{}{
+		"K1": []int{2, 1, 3},
+		"K2": []*string{&a, &b, &a},
+		"Y":  []float64{1.5, 2.5, 3.5}},
+		newqf.Enums(map[string][]string{"K2": {"b", "a"}}))
+
+	// Order of the left QFrame is respected
+	out := left.Sort(qframe.Order{Column: "K1", Reverse: true}).Join(right)
+	expected := qframe.New(map[string]interface{}{
+		"K1": []int{2, 1},
+		"K2": []*string{&a, &b},
+		"X":  []bool{true, false},
+		"Y":  []float64{1.5, 2.5}},
+		newqf.Enums(map[string][]string{"K2": {"b", "a"}}),
+		newqf.ColumnOrder("K1", "K2", "X", "Y"))
+	assertEquals(t, expected, out)
+}
+
+func TestQFrame_JoinErrors(t *testing.T) {
+	left := qframe.New(map[string]interface{}{"A": []int{1}, "B": []int{2}})
+	table := []struct {
+		name    string
+		right   qframe.QFrame
+		configs []join.ConfigFunc
+		err     string
+	}{
+		{name: "no common columns", right: qframe.New(map[string]interface{}{"C": []int{1}}), err: "no common columns"},
+		{name: "unknown column", right: left, configs: []join.ConfigFunc{join.On("C")}, err: "unknown column"},
+		{name: "type mismatch", right: qframe.New(map[string]interface{}{"A": []float64{1}}), err: "different types"},
+		{name: "invalid how", right: left, configs: []join.ConfigFunc{join.How("outer")}, err: "How must be"},
+		{name: "same suffixes", right: left, configs: []join.ConfigFunc{join.Suffixes("_x", "_x")}, err: "suffixes must differ"},
+		{name: "duplicate name", right: qframe.New(map[string]interface{}{"A": []int{1}, "B_left": []int{1}, "B": []int{3}}),
+			configs: []join.ConfigFunc{join.On("A")}, err: "duplicate column name"},
+		{name: "error in other", right: qframe.QFrame{Err: errors.New("foo")}, err: "foo"},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			out := left.Join(tc.right, tc.configs...)
+			assertErr(t, out.Err, tc.err)
+		})
+	}
+}
+
+func TestQFrame_NullableIntBool(t *testing.T) {
+	one, two, three, tr, fa := 1, 2, 3, true, false
+	input := qframe.New(map[string]interface{}{
+		"INT":  []*int{&three, nil, &one, &two, nil},
+		"BOOL": []*bool{&tr, &fa, nil, &tr, nil},
+		"KEY":  []int{1, 1, 2, 2, 3}},
+		newqf.ColumnOrder("INT", "BOOL", "KEY"))
+	assertNotErr(t, input.Err)
+
+	t.Run("view", func(t *testing.T) {
+		view := input.MustIntView("INT")
+		assertTrue(t, !view.IsNull(0) && view.ItemAt(0) == 3)
+		assertTrue(t, view.IsNull(1))
+		assertTrue(t, input.MustBoolView("BOOL").IsNull(2))
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		table := []struct {
+			clause   qframe.FilterClause
+			expected []int
+		}{
+			{clause: qframe.Filter{Column: "INT", Comparator: "isnull"}, expected: []int{1, 3}},
+			{clause: qframe.Filter{Column: "INT", Comparator: "isnotnull"}, expected: []int{1, 2, 2}},
+			{clause: qframe.Filter{Column: "INT", Comparator: ">", Arg: 1}, expected: []int{1, 2}},
+			{clause: qframe.Filter{Column: "INT", Comparator: "!=", Arg: 1}, expected: []int{1, 1, 2, 3}},
+			{clause: qframe.Filter{Column: "BOOL", Comparator: "isnull"}, expected: []int{2, 3}},
+			{clause: qframe.Filter{Column: "BOOL", Comparator: "=", Arg: false}, expected: []int{1}},
+			{clause: qframe.Or(
+				qframe.Filter{Column: "KEY", Comparator: "=", Arg: 2},
+				qframe.Filter{Column: "INT", Comparator: "isnull"}), expected: []int{1, 2, 2, 3}},
+		}
+
+		for i, tc := range table {
+			t.Run(fmt.Sprintf("Filter %d", i), func(t *testing.T) {
+				out := input.Filter(tc.clause).Select("KEY")
+				assertEquals(t, qframe.New(map[string]interface{}{"KEY": tc.expected}), out)
+			})
+		}
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		out := input.Sort(qframe.Order{Column: "INT"}).Select("INT")
+		assertEquals(t, qframe.New(map[string]interface{}{"INT": []*int{nil, nil, &one, &two, &three}}), out)
+
+		out = input.Sort(qframe.Order{Column: "INT", NullLast: true}).Select("INT")
+		assertEquals(t, qframe.New(map[string]interface{}{"INT": []*int{&one, &two, &three, nil, nil}}), out)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		out := input.GroupBy(groupby.Columns("KEY")).Aggregate(
+			qframe.Aggregation{Fn: "sum", Column: "INT"},
+			qframe.Aggregation{Fn: "majority", Column: "BOOL"}).Sort(qframe.Order{Column: "KEY"})
+		expected := qframe.New(map[string]interface{}{
+			"KEY":  []int{1, 2, 3},
+			"INT":  []*int{&three, &three, nil},
+			"BOOL": []*bool{&fa, &tr, nil}},
+			newqf.ColumnOrder("KEY", "INT", "BOOL"))
+		assertEquals(t, expected, out)
+	})
+
+	t.Run("group by null", func(t *testing.T) {
+		assertTrue(t, input.Distinct(groupby.Columns("INT")).Len() == 5)
+		assertTrue(t, input.Distinct(groupby.Columns("INT"), groupby.Null(true)).Len() == 4)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		out := input.Apply(
+			qframe.Instruction{Fn: func(x int) int { return 2 * x }, DstCol: "INT", SrcCol1: "INT"},
+			qframe.Instruction{Fn: func(x, y int) int { return x + y }, DstCol: "SUM", SrcCol1: "INT", SrcCol2: "KEY"},
+			qframe.Instruction{Fn: func(x int) float64 { return float64(x) }, DstCol: "FLOAT", SrcCol1: "INT"})
+		four, six, seven := 4, 6, 7
+		expected := qframe.New(map[string]interface{}{
+			"INT":   []*int{&six, nil, &two, &four, nil},
+			"SUM":   []*int{&seven, nil, &four, &six, nil},
+			"FLOAT": []float64{6, math.NaN(), 2, 4, math.NaN()}},
+			newqf.ColumnOrder("INT", "SUM", "FLOAT"))
+		assertEquals(t, expected, out.Select("INT", "SUM", "FLOAT"))
+	})
+
+	t.Run("to json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assertNotErr(t, input.ToJSON(buf))
+		expected := `[{"INT":3,"BOOL":true,"KEY":1},{"INT":null,"BOOL":false,"KEY":1},` +
+			`{"INT":1,"BOOL":null,"KEY":2},{"INT":2,"BOOL":true,"KEY":2},{"INT":null,"BOOL":null,"KEY":3}]`
+		if buf.String() != expected {
+			t.Errorf("Unexpected JSON: %s", buf.String())
+		}
+	})
+
+	t.Run("to csv", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assertNotErr(t, input.ToCSV(buf))
+		expected := "INT,BOOL,KEY\n3,true,1\n,false,1\n1,,2\n2,true,2\n,,3\n"
+		if buf.String() != expected {
+			t.Errorf("Unexpected CSV: %s", buf.String())
+		}
+
+		out := qframe.ReadCSV(buf, csv.EmptyNull(true))
+		assertEquals(t, input, out)
+	})
+
+	t.Run("arrow", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assertNotErr(t, input.ToArrow(buf))
+		assertEquals(t, input, qframe.ReadArrow(buf))
+	})
+}
+
+func TestQFrame_Time(t *testing.T) {
+	t1 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	t2 := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	t3 := time.Date(2021, 1, 1, 0, 0, 0, 500, time.UTC)
+	input := qframe.New(map[string]interface{}{
+		"TIME": []*time.Time{&t2, nil, &t1, &t3},
+		"KEY":  []int{1, 1, 2, 2}},
+		newqf.ColumnOrder("TIME", "KEY"))
+	assertNotErr(t, input.Err)
+
+	t.Run("view", func(t *testing.T) {
+		view := input.MustTimeView("TIME")
+		assertTrue(t, view.ItemAt(0).Equal(t2))
+		assertTrue(t, view.IsNull(1))
+		assertTrue(t, view.ItemAt(1).IsZero())
+		assertTrue(t, view.Len() == 4)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		table := []struct {
+			clause   qframe.FilterClause
+			expected []int
+		}{
+			{clause: qframe.Filter{Column: "TIME", Comparator: ">", Arg: t1}, expected: []int{1, 2}},
+			{clause: qframe.Filter{Column: "TIME", Comparator: "<=", Arg: t2}, expected: []int{1, 2}},
+			{clause: qframe.Filter{Column: "TIME", Comparator: "=", Arg: t3}, expected: []int{2}},
+			{clause: qframe.Filter{Column: "TIME", Comparator: "!=", Arg: t3}, expected: []int{1, 1, 2}},
+			{clause: qframe.Filter{Column: "TIME", Comparator: "in", Arg: []time.Time{t1, t3}}, expected: []int{2, 2}},
+			{clause: qframe.Filter{Column: "TIME", Comparator: "isnull"}, expected: []int{1}},
+			{clause: qframe.Filter{Column: "TIME", Comparator: func(x time.Time) bool { return x.Year() == 2020 }}, expected: []int{1, 2}},
+		}
+
+		for i, tc := range table {
+			t.Run(fmt.Sprintf("Filter %d", i), func(t *testing.T) {
+				out := input.Filter(tc.clause).Select("KEY")
+				assertEquals(t, qframe.New(map[string]interface{}{"KEY": tc.expected}), out)
+			})
+		}
+	})
+
+	t.Run("filter column", func(t *testing.T) {
+		in := qframe.New(map[string]interface{}{
+			"A": []time.Time{t1, t2, t3},
+			"B": []time.Time{t2, t2, t2}})
+		out := in.Filter(qframe.Filter{Column: "A", Comparator: ">=", Arg: types.ColumnName("B")})
+		assertEquals(t, qframe.New(map[string]interface{}{"A": []time.Time{t2, t3}, "B": []time.Time{t2, t2}}), out)
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		out := input.Sort(qframe.Order{Column: "TIME", NullLast: true}).Select("TIME")
+		assertEquals(t, qframe.New(map[string]interface{}{"TIME": []*time.Time{&t1, &t2, &t3, nil}}), out)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		out := input.GroupBy(groupby.Columns("KEY")).Aggregate(
+			qframe.Aggregation{Fn: "max", Column: "TIME"}).Sort(qframe.Order{Column: "KEY"})
+		expected := qframe.New(map[string]interface{}{
+			"KEY":  []int{1, 2},
+			"TIME": []time.Time{t2, t3}},
+			newqf.ColumnOrder("KEY", "TIME"))
+		assertEquals(t, expected, out)
+	})
+
+	t.Run("group by", func(t *testing.T) {
+		in := qframe.New(map[string]interface{}{"TIME": []time.Time{t1, t2, t1, t1}})
+		out := in.GroupBy(groupby.Columns("TIME")).Aggregate(qframe.Aggregation{Fn: "count", Column: "TIME", As: "COUNT"})
+		assertEquals(t, qframe.New(map[string]interface{}{
+			"TIME": []time.Time{t1, t2}, "COUNT": []int{3, 1}}, newqf.ColumnOrder("TIME", "COUNT")),
+			out.Sort(qframe.Order{Column: "TIME"}))
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		out := input.Apply(
+			qframe.Instruction{Fn: func(x time.Time) int { return x.Year() }, DstCol: "YEAR", SrcCol1: "TIME"},
+			qframe.Instruction{Fn: func(x time.Time) time.Time { return x.Add(time.Hour) }, DstCol: "LATER", SrcCol1: "TIME"})
+		y2020, y2021 := 2020, 2021
+		l1, l2, l3 := t1.Add(time.Hour), t2.Add(time.Hour), t3.Add(time.Hour)
+		expected := qframe.New(map[string]interface{}{
+			"YEAR":  []*int{&y2020, nil, &y2020, &y2021},
+			"LATER": []*time.Time{&l2, nil, &l1, &l3}},
+			newqf.ColumnOrder("YEAR", "LATER"))
+		assertEquals(t, expected, out.Select("YEAR", "LATER"))
+	})
+
+	t.Run("to json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assertNotErr(t, input.Select("TIME").ToJSON(buf))
+		expected := `[{"TIME":"2020-06-01T12:00:00Z"},{"TIME":null},{"TIME":"2020-01-01T12:00:00Z"},{"TIME":"2021-01-01T00:00:00.0000005Z"}]`
+		if buf.String() != expected {
+			t.Errorf("Unexpected JSON: %s", buf.String())
+		}
+	})
+
+	t.Run("to and from csv", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assertNotErr(t, input.ToCSV(buf))
+		expected := "TIME,KEY\n2020-06-01T12:00:00Z,1\n,1\n2020-01-01T12:00:00Z,2\n2021-01-01T00:00:00.0000005Z,2\n"
+		if buf.String() != expected {
+			t.Errorf("Unexpected CSV: %s", buf.String())
+		}
+
+		out := qframe.ReadCSV(buf, csv.Types(map[string]string{"TIME": types.Time}))
+		assertEquals(t, input, out)
+	})
+
+	t.Run("join", func(t *testing.T) {
+		other := qframe.New(map[string]interface{}{"TIME": []time.Time{t1, t3}, "VAL": []int{10, 30}})
+		out := input.Join(other, join.How("left")).Sort(qframe.Order{Column: "TIME"})
+		ten, thirty := 10, 30
+		expected := qframe.New(map[string]interface{}{
+			"TIME": []*time.Time{nil, &t1, &t2, &t3},
+			"KEY":  []int{1, 2, 1, 2},
+			"VAL":  []*int{nil, &ten, nil, &thirty}},
+			newqf.ColumnOrder("TIME", "KEY", "VAL"))
+		assertEquals(t, expected, out)
+	})
+}
+
+func TestQFrame_ReadCSVTime(t *testing.T) {
+	loc := time.FixedZone("", 2*60*60)
+	t1 := time.Date(2020, 1, 2, 3, 4, 5, 0, loc)
+	t2 := time.Date(2020, 1, 3, 3, 4, 5, 0, loc)
+	table := []struct {
+		name     string
+		input    string
+		configs  []csv.ConfigFunc
+		expected interface{}
+		err      string
+	}{
+		{
+			name:     "auto detected with time format",
+			input:    "T\n2020-01-02 03:04:05 +0200\n2020-01-03 03:04:05 +0200\n",
+			configs:  []csv.ConfigFunc{csv.TimeFormat("2006-01-02 15:04:05 -0700")},
+			expected: []time.Time{t1, t2},
+		},
+		{
+			name:     "not detected without time format",
+			input:    "T\n2020-01-02T03:04:05+02:00\n",
+			expected: []string{"2020-01-02T03:04:05+02:00"},
+		},
+		{
+			name:     "explicit type with default format and null",
+			input:    "T\n2020-01-02T03:04:05+02:00\n\n",
+			configs:  []csv.ConfigFunc{csv.Types(map[string]string{"T": types.Time})},
+			expected: []*time.Time{&t1, nil},
+		},
+		{
+			name:    "explicit type with invalid time",
+			input:   "T\nfoo\n",
+			configs: []csv.ConfigFunc{csv.Types(map[string]string{"T": types.Time})},
+			err:     "Create time column",
+		},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			out := qframe.ReadCSV(strings.NewReader(tc.input), tc.configs...)
+			if tc.err != "" {
+				assertErr(t, out.Err, tc.err)
+				return
+			}
+
+			assertEquals(t, qframe.New(map[string]interface{}{"T": tc.expected}), out)
+		})
+	}
+
+	t.Run("location is kept", func(t *testing.T) {
+		out := qframe.ReadCSV(strings.NewReader("T\n2020-01-02T03:04:05+02:00\n"),
+			csv.Types(map[string]string{"T": types.Time}))
+		_, offset := out.MustTimeView("T").ItemAt(0).Zone()
+		assertTrue(t, offset == 2*60*60)
+
+		buf := new(bytes.Buffer)
+		assertNotErr(t, out.ToCSV(buf))
+		assertTrue(t, buf.String() == "T\n2020-01-02T03:04:05+02:00\n")
+	})
+}
+
+func TestQFrame_Pivot(t *testing.T) {
+	input := qframe.New(map[string]interface{}{
+		"DATE":   []string{"d1", "d1", "d2", "d2", "d2", "d1"},
+		"METRIC": []string{"cpu", "mem", "cpu", "cpu", "disk", "cpu"},
+		"VALUE":  []int{1, 2, 3, 4, 5, 6}},
+		newqf.ColumnOrder("DATE", "METRIC", "VALUE"))
+
+	t.Run("sum", func(t *testing.T) {
+		two, five := 2, 5
+		expected := qframe.New(map[string]interface{}{
+			"DATE": []string{"d1", "d2"},
+			"cpu":  []int{7, 7},
+			"disk": []*int{nil, &five},
+			"mem":  []*int{&two, nil}},
+			newqf.ColumnOrder("DATE", "cpu", "disk", "mem"))
+		assertEquals(t, expected, input.Pivot([]string{"DATE"}, "METRIC", "VALUE", "sum"))
+	})
+
+	t.Run("count", func(t *testing.T) {
+		expected := qframe.New(map[string]interface{}{
+			"DATE": []string{"d1", "d2"},
+			"cpu":  []int{2, 2},
+			"disk": []int{0, 1},
+			"mem":  []int{1, 0}},
+			newqf.ColumnOrder("DATE", "cpu", "disk", "mem"))
+		assertEquals(t, expected, input.Pivot([]string{"DATE"}, "METRIC", "VALUE", "count"))
+	})
+
+	t.Run("custom function and float values", func(t *testing.T) {
+		in := qframe.New(map[string]interface{}{
+			"K": []int{1, 1, 2},
+			"C": []int{10, 20, 10},
+			"V": []float64{1.5, 2.5, 3.5}})
+		out := in.Pivot([]string{"K"}, "C", "V", func(xs []float64) float64 { return xs[0] * 2 })
+		expected := qframe.New(map[string]interface{}{
+			"K":  []int{1, 2},
+			"10": []float64{3, 7},
+			"20": []float64{5, math.NaN()}},
+			newqf.ColumnOrder("K", "10", "20"))
+		assertEquals(t, expected, out)
+	})
+
+	t.Run("null pivot values are left out", func(t *testing.T) {
+		a := "a"
+		in := qframe.New(map[string]interface{}{
+			"K": []int{1, 1},
+			"C": []*string{&a, nil},
+			"V": []int{1, 2}})
+		expected := qframe.New(map[string]interface{}{"K": []int{1}, "a": []int{1}}, newqf.ColumnOrder("K", "a"))
+		assertEquals(t, expected, in.Pivot([]string{"K"}, "C", "V", "sum"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assertErr(t, input.Pivot(nil, "METRIC", "VALUE", "sum").Err, "index column")
+		assertErr(t, input.Pivot([]string{"DATE"}, "FOO", "VALUE", "sum").Err, "unknown column")
+		assertErr(t, input.Pivot([]string{"DATE"}, "METRIC", "VALUE", "foo").Err, "aggregation")
+		in := qframe.New(map[string]interface{}{"cpu": []string{"x"}, "C": []string{"cpu"}, "V": []int{1}})
+		assertErr(t, in.Pivot([]string{"cpu"}, "C", "V", "sum").Err, "duplicate column")
+	})
+
+	t.Run("melt is the inverse", func(t *testing.T) {
+		wide := input.Pivot([]string{"DATE"}, "METRIC", "VALUE", "sum")
+		long := wide.Melt([]string{"DATE"}, nil, "METRIC", "VALUE").Filter(
+			qframe.Filter{Column: "VALUE", Comparator: "isnotnull"})
+		back := long.Pivot([]string{"DATE"}, "METRIC", "VALUE", "sum")
+		assertEquals(t, wide, back)
+	})
+}
+
+func TestQFrame_Melt(t *testing.T) {
+	input := qframe.New(map[string]interface{}{
+		"ID": []string{"a", "b"},
+		"X":  []int{1, 2},
+		"Y":  []int{3, 4}},
+		newqf.ColumnOrder("ID", "X", "Y"))
+
+	out := input.Melt([]string{"ID"}, []string{"Y", "X"}, "VAR", "VAL")
+	expected := qframe.New(map[string]interface{}{
+		"ID":  []string{"a", "b", "a", "b"},
+		"VAR": []string{"Y", "Y", "X", "X"},
+		"VAL": []int{3, 4, 1, 2}},
+		newqf.ColumnOrder("ID", "VAR", "VAL"),
+		newqf.Enums(map[string][]string{"VAR": {"Y", "X"}}))
+	assertEquals(t, expected, out)
+
+	// Enum ordering follows the value columns
+	sorted := out.Sort(qframe.Order{Column: "VAR"}, qframe.Order{Column: "ID"})
+	assertEquals(t, expected, sorted)
+
+	t.Run("all non id columns by default", func(t *testing.T) {
+		out := input.Melt([]string{"ID"}, nil, "VAR", "VAL")
+		assertTrue(t, out.Len() == 4)
+		assertTrue(t, out.MustEnumView("VAR").ItemAt(0) != nil && *out.MustEnumView("VAR").ItemAt(0) == "X")
+	})
+
+	t.Run("enum variable column with many value columns", func(t *testing.T) {
+		data := map[string]interface{}{"ID": []int{1}}
+		for i := 0; i < 300; i++ {
+			data[fmt.Sprintf("C%d", i)] = []int{i}
+		}
+		out := qframe.New(data).Melt([]string{"ID"}, nil, "VAR", "VAL")
+		assertNotErr(t, out.Err)
+		assertTrue(t, out.Len() == 300)
+		assertTrue(t, out.ColumnTypeMap()["VAR"] == types.Enum)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		mixed := input.Apply(qframe.Instruction{Fn: 1.5, DstCol: "F"})
+		assertErr(t, mixed.Melt([]string{"ID"}, nil, "VAR", "VAL").Err, "same type")
+		assertErr(t, input.Melt([]string{"ID"}, []string{"Z"}, "VAR", "VAL").Err, "unknown column")
+		assertErr(t, input.Melt([]string{"ID"}, nil, "ID", "VAL").Err, "duplicate column")
+		assertErr(t, input.Melt([]string{"ID", "X", "Y"}, nil, "VAR", "VAL").Err, "no value columns")
+	})
+}
+
+func readChunks(t *testing.T, r *qframe.CSVChunkReader) []qframe.QFrame {
+	t.Helper()
+	var result []qframe.QFrame
+	for {
+		qf, err := r.Read()
+		if err == io.EOF {
+			return result
+		}
+
+		assertNotErr(t, err)
+		result = append(result, qf)
+	}
+}
+
+func TestQFrame_CSVChunkReader(t *testing.T) {
+	t.Run("chunks", func(t *testing.T) {
+		input := "A,B\n1,a\n2,b\n3,c\n4,d\n5,e\n"
+		chunks := readChunks(t, qframe.NewCSVChunkReader(strings.NewReader(input), 2))
+		assertTrue(t, len(chunks) == 3)
+		assertEquals(t, qframe.New(map[string]interface{}{"A": []int{1, 2}, "B": []string{"a", "b"}}), chunks[0])
+		assertEquals(t, qframe.New(map[string]interface{}{"A": []int{3, 4}, "B": []string{"c", "d"}}), chunks[1])
+		assertEquals(t, qframe.New(map[string]interface{}{"A": []int{5}, "B": []string{"e"}}), chunks[2])
+	})
+
+	t.Run("enum values grow beyond the first chunk", func(t *testing.T) {
+		buf := bytes.NewBufferString("A\n")
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(buf, "v%d\n", i%400)
+		}
+
+		chunks := readChunks(t, qframe.NewCSVChunkReader(buf, 300, csv.Types(map[string]string{"A": "enum"})))
+		assertTrue(t, len(chunks) == 4)
+		for _, c := range chunks {
+			assertTrue(t, c.ColumnTypeMap()["A"] == types.Enum)
+		}
+		assertTrue(t, *chunks[1].MustEnumView("A").ItemAt(99) == "v399")
+		assertTrue(t, *chunks[3].MustEnumView("A").ItemAt(99) == "v199")
+	})
+
+	t.Run("even number of chunks", func(t *testing.T) {
+		chunks := readChunks(t, qframe.NewCSVChunkReader(strings.NewReader("A\n1\n2\n3\n4\n"), 2))
+		assertTrue(t, len(chunks) == 2)
+	})
+
+	t.Run("only header", func(t *testing.T) {
+		chunks := readChunks(t, qframe.NewCSVChunkReader(strings.NewReader("A,B\n"), 10))
+		assertTrue(t, len(chunks) == 1)
+		assertTrue(t, chunks[0].Len() == 0)
+		assertTrue(t, reflect.DeepEqual(chunks[0].ColumnNames(), []string{"A", "B"}))
+	})
+
+	t.Run("types from first chunk", func(t *testing.T) {
+		r := qframe.NewCSVChunkReader(strings.NewReader("A\n1\n2\n1.5\n"), 2)
+		qf, err := r.Read()
+		assertNotErr(t, err)
+		assertTrue(t, qf.ColumnTypeMap()["A"] == types.Int)
+
+		_, err = r.Read()
+		assertErr(t, err, "Create int column")
+
+		// The error is sticky
+		_, err = r.Read()
+		assertErr(t, err, "Create int column")
+	})
+
+	t.Run("explicit types", func(t *testing.T) {
+		r := qframe.NewCSVChunkReader(strings.NewReader("A\n1\n2\n1.5\n"), 2, csv.Types(map[string]string{"A": types.Float}))
+		chunks := readChunks(t, r)
+		assertEquals(t, qframe.New(map[string]interface{}{"A": []float64{1.5}}), chunks[1])
+	})
+
+	t.Run("stable enums", func(t *testing.T) {
+		r := qframe.NewCSVChunkReader(strings.NewReader("E\ny\nx\nz\nx\n"), 2, csv.Types(map[string]string{"E": types.Enum}))
+		chunks := readChunks(t, r)
+		assertTrue(t, len(chunks) == 2)
+
+		// The values of the first chunk, y < x, keep their order in the second
+		// chunk and z, first seen in the second chunk, is added last.
+		sorted := chunks[1].Sort(qframe.Order{Column: "E"})
+		assertTrue(t, *sorted.MustEnumView("E").ItemAt(0) == "x")
+		assertTrue(t, *sorted.MustEnumView("E").ItemAt(1) == "z")
+	})
+
+	t.Run("strict enums", func(t *testing.T) {
+		r := qframe.NewCSVChunkReader(strings.NewReader("E\nx\nx\ny\n"), 2,
+			csv.Types(map[string]string{"E": types.Enum}),
+			csv.EnumValues(map[string][]string{"E": {"x"}}))
+		_, err := r.Read()
+		assertNotErr(t, err)
+
+		_, err = r.Read()
+		assertErr(t, err, "unknown enum value")
+	})
+
+	t.Run("line number in errors", func(t *testing.T) {
+		r := qframe.NewCSVChunkReader(strings.NewReader("A,B\n1,2\n3,4\n5\n"), 2)
+		_, err := r.Read()
+		assertNotErr(t, err)
+
+		_, err = r.Read()
+		assertErr(t, err, "Wrong number of columns on line 4")
+	})
+
+	t.Run("invalid chunk size", func(t *testing.T) {
+		_, err := qframe.NewCSVChunkReader(strings.NewReader("A\n1\n"), 0).Read()
+		assertErr(t, err, "chunk size must be positive")
+	})
+}
+
+func TestLazyFrame_Collect(t *testing.T) {
+	in := qframe.New(map[string]interface{}{
+		"A": []int{1, 2, 3, 4, 5, 6},
+		"B": []string{"a", "b", "a", "b", "a", "b"},
+		"C": []float64{1.5, 2.5, 3.5, 4.5, 5.5, 6.5},
+	})
+
+	table := []struct {
+		name string
+		lazy func(qf qframe.LazyFrame) qframe.LazyFrame
+		fn   func(qf qframe.QFrame) qframe.QFrame
+	}{
+		{
+			name: "no operations",
+			lazy: func(qf qframe.LazyFrame) qframe.LazyFrame { return qf },
+			fn:   func(qf qframe.QFrame) qframe.QFrame { return qf },
+		},
+		{
+			name: "select filter sort",
+			lazy: func(qf qframe.LazyFrame) qframe.LazyFrame {
+				return qf.Select("C", "A").Filter(qframe.Filter{Column: "A", Comparator: ">", Arg: 2}).Sort(qframe.Order{Column: "C", Reverse: true})
+			},
+			fn: func(qf qframe.QFrame) qframe.QFrame {
+				return qf.Select("C", "A").Filter(qframe.Filter{Column: "A", Comparator: ">", Arg: 2}).Sort(qframe.Order{Column: "C", Reverse: true})
+			},
+		},
+		{
+			name: "apply filter on applied column",
+			lazy: func(qf qframe.LazyFrame) qframe.LazyFrame {
+				return qf.Apply(qframe.Instruction{Fn: function.PlusI, DstCol: "D", SrcCol1: "A", SrcCol2: "A"}).
+					Filter(qframe.Filter{Column: "D", Comparator: "<", Arg: 8}).
+					Filter(qframe.Filter{Column: "B", Comparator: "=", Arg: "a"})
+			},
+			fn: func(qf qframe.QFrame) qframe.QFrame {
+				return qf.Apply(qframe.Instruction{Fn: function.PlusI, DstCol: "D", SrcCol1: "A", SrcCol2: "A"}).
+					Filter(qframe.Filter{Column: "D", Comparator: "<", Arg: 8}).
+					Filter(qframe.Filter{Column: "B", Comparator: "=", Arg: "a"})
+			},
+		},
+		{
+			name: "group by",
+			lazy: func(qf qframe.LazyFrame) qframe.LazyFrame {
+				return qf.Filter(qframe.Not(qframe.Filter{Column: "A", Comparator: "=", Arg: 1})).
+					GroupBy(groupby.Columns("B")).Aggregate(qframe.Aggregation{Fn: "sum", Column: "C"}).
+					Sort(qframe.Order{Column: "B"})
+			},
+			fn: func(qf qframe.QFrame) qframe.QFrame {
+				return qf.Filter(qframe.Not(qframe.Filter{Column: "A", Comparator: "=", Arg: 1})).
+					GroupBy(groupby.Columns("B")).Aggregate(qframe.Aggregation{Fn: "sum", Column: "C"}).
+					Sort(qframe.Order{Column: "B"})
+			},
+		},
+		{
+			name: "constant column only",
+			lazy: func(qf qframe.LazyFrame) qframe.LazyFrame {
+				return qf.Apply(qframe.Instruction{Fn: 1, DstCol: "D"}).Select("D")
+			},
+			fn: func(qf qframe.QFrame) qframe.QFrame {
+				return qf.Apply(qframe.Instruction{Fn: 1, DstCol: "D"}).Select("D")
+			},
+		},
+	}
+
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			out := tc.lazy(in.Lazy()).Collect()
+			assertNotErr(t, out.Err)
+			assertEquals(t, tc.fn(in), out)
+		})
+	}
+}
+
+func TestLazyFrame_Explain(t *testing.T) {
+	in := qframe.New(map[string]interface{}{"A": []int{1, 2}, "B": []int{3, 4}, "C": []int{5, 6}, "D": []int{7, 8}})
+	lazy := in.Lazy().
+		Apply(qframe.Instruction{Fn: function.PlusI, DstCol: "E", SrcCol1: "A", SrcCol2: "B"},
+			qframe.Instruction{Fn: function.MulI, DstCol: "F", SrcCol1: "A", SrcCol2: "D"}).
+		Sort(qframe.Order{Column: "A", Reverse: true}).
+		Filter(qframe.Filter{Column: "A", Comparator: ">", Arg: 1}).
+		Filter(qframe.Filter{Column: "E", Comparator: "<", Arg: 10}).
+		Filter(qframe.Filter{Column: "B", Comparator: "<", Arg: 10}).
+		Select("E", "A")
+
+	expected := `Source: QFrame, columns: A, B
+Filter: ["and", ["<", "B", 10], [">", "A", 1]]
+Apply: E = func(int, int) int(A, B)
+Filter: ["<", "E", 10]
+Sort: A desc
+Select: E, A`
+	if lazy.Explain() != expected {
+		t.Errorf("Unexpected plan:\n%s\nExpected:\n%s", lazy.Explain(), expected)
+	}
+
+	out := lazy.Collect()
+	assertNotErr(t, out.Err)
+	assertEquals(t, qframe.New(map[string]interface{}{"E": []int{6}, "A": []int{2}}, newqf.ColumnOrder("E", "A")), out)
+}
+
+func TestLazyFrame_ScanCSV(t *testing.T) {
+	input := `A,B,C
+1,x,notanint
+2,y,3
+3,x,4`
+
+	out := qframe.ScanCSV(strings.NewReader(input), csv.Types(map[string]string{"C": "int"})).
+		Filter(qframe.Filter{Column: "B", Comparator: "=", Arg: "x"}).
+		Select("A").
+		Collect()
+	assertNotErr(t, out.Err)
+	assertEquals(t, qframe.New(map[string]interface{}{"A": []int{1, 3}}), out)
+
+	out = qframe.ScanCSV(strings.NewReader(input), csv.Types(map[string]string{"C": "int"})).Collect()
+	if out.Err == nil {
+		t.Errorf("Expected parse error when reading all columns")
+	}
+
+	out = qframe.ScanCSV(strings.NewReader(input)).Select("A", "D").Collect()
+	assertErr(t, out.Err, `unknown column: "D"`)
+}
@@ -0,0 +1,253 @@
+package qframe
+
+import (
+	"github.com/yistabraq/qframe/config/groupby"
+	"github.com/yistabraq/qframe/filter"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	qfstrings "github.com/yistabraq/qframe/internal/strings"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// Pivot reshapes the QFrame from long to wide format.
+//
+// The result contains one row for each distinct combination of values in the index columns
+// and, in addition to the index columns, one column for each distinct value in the columns
+// column. The cells are populated by aggregating the values in the values column using aggFn,
+// which may be any aggregation accepted by Grouper.Aggregate, including "count".
+//
+// Rows are sorted by the index columns and the new columns are ordered by the value they were
+// created from. Cells without any values are null (except for "count" where they are 0).
+// Rows with a null value in the columns column are left out since they cannot be named.
+//
+// Time complexity O(m * n) where m = number of new columns, n = number of rows in the result.
+func (qf QFrame) Pivot(indexCols []string, columns, values string, aggFn types.SliceFuncOrBuiltInId) QFrame {
+	if qf.Err != nil {
+		return qf
+	}
+
+	if len(indexCols) == 0 {
+		return qf.withErr(qerrors.New("Pivot", "at least one index column must be given"))
+	}
+
+	if err := qf.checkColumns("Pivot", append(append([]string{}, indexCols...), columns, values)); err != nil {
+		return qf.withErr(err)
+	}
+
+	src := qf.Filter(Filter{Column: columns, Comparator: filter.IsNotNull})
+	if src.Err != nil {
+		return qf.withErr(qerrors.Propagate("Pivot", src.Err))
+	}
+
+	rowFirsts, rowOf := src.sortedGroups(indexCols, true)
+	colFirsts, colOf := src.sortedGroups([]string{columns}, false)
+
+	cells := make([][]index.Int, len(colFirsts))
+	for c := range cells {
+		cells[c] = make([]index.Int, len(rowFirsts))
+	}
+
+	for _, i := range src.index {
+		r, c := rowOf[i], colOf[i]
+		cells[c][r] = append(cells[c][r], i)
+	}
+
+	newColumns := make([]namedColumn, 0, len(indexCols)+len(colFirsts))
+	newColumnsByName := make(map[string]namedColumn, len(indexCols)+len(colFirsts))
+	addColumn := func(name string, col column.Column) error {
+		if _, ok := newColumnsByName[name]; ok {
+			return qerrors.New("Pivot", "duplicate column name in result: %s", name)
+		}
+
+		nc := namedColumn{Column: col, name: name, pos: len(newColumns)}
+		newColumns = append(newColumns, nc)
+		newColumnsByName[name] = nc
+		return nil
+	}
+
+	for _, name := range indexCols {
+		if err := addColumn(name, src.columnsByName[name].Subset(rowFirsts)); err != nil {
+			return qf.withErr(err)
+		}
+	}
+
+	pivotCol, valueCol := src.columnsByName[columns], src.columnsByName[values]
+	for c, first := range colFirsts {
+		name := pivotCol.StringAt(first, "")
+		if err := qfstrings.CheckName(name); err != nil {
+			return qf.withErr(qerrors.Propagate("Pivot", err))
+		}
+
+		col, err := pivotCell(valueCol, cells[c], aggFn)
+		if err != nil {
+			return qf.withErr(qerrors.Propagate("Pivot", err))
+		}
+
+		if err := addColumn(name, col); err != nil {
+			return qf.withErr(err)
+		}
+	}
+
+	return QFrame{columns: newColumns, columnsByName: newColumnsByName, index: index.NewAscending(uint32(len(rowFirsts)))}
+}
+
+// sortedGroups groups the rows of the QFrame by columns. It returns the first row of each
+// group, sorted by columns, and a mapping from row to the position of its group in that order.
+func (qf QFrame) sortedGroups(columns []string, groupByNull bool) (index.Int, []int) {
+	groups := qf.GroupBy(groupby.Columns(columns...), groupby.Null(groupByNull)).indices
+	firsts := make(index.Int, len(groups))
+	for i, g := range groups {
+		firsts[i] = g[0]
+	}
+
+	orders := make([]Order, len(columns))
+	for i, c := range columns {
+		orders[i] = Order{Column: c}
+	}
+	firsts = qf.withIndex(firsts).Sort(orders...).index
+
+	groupOf := make(map[uint32]int, len(groups))
+	for i, g := range groups {
+		groupOf[g[0]] = i
+	}
+
+	positions := make([]int, qf.columnLen())
+	for pos, first := range firsts {
+		for _, i := range groups[groupOf[first]] {
+			positions[i] = pos
+		}
+	}
+
+	return firsts, positions
+}
+
+// pivotCell aggregates the values in each of the cells, empty cells are null.
+func pivotCell(col column.Column, cells []index.Int, aggFn types.SliceFuncOrBuiltInId) (column.Column, error) {
+	if aggFn == "count" {
+		counts := make([]int, len(cells))
+		for i, cell := range cells {
+			counts[i] = len(cell)
+		}
+
+		return icolumn.New(counts), nil
+	}
+
+	nonEmpty := make([]index.Int, 0, len(cells))
+	ix := make(index.Int, len(cells))
+	for i, cell := range cells {
+		if len(cell) == 0 {
+			ix[i] = noMatch
+			continue
+		}
+
+		ix[i] = uint32(len(nonEmpty))
+		nonEmpty = append(nonEmpty, cell)
+	}
+
+	aggCol, err := aggregate(col, nonEmpty, aggFn)
+	if err != nil {
+		return nil, err
+	}
+
+	return subsetWithNull(aggCol, ix, uint32(len(nonEmpty)))
+}
+
+// Melt reshapes the QFrame from wide to long format, it is the inverse of Pivot.
+//
+// Each row in the QFrame is turned into one row per value column. The id columns are repeated
+// for each of these rows, the name of the value column is stored in a column named varName and
+// the value itself in a column named valueName. If no value columns are given all columns that
+// are not id columns are used. All value columns must have the same type.
+//
+// The variable column is an enum column, with the values ordered as the value columns, unless
+// there are too many value columns to fit in an enum in which case it is a string column.
+//
+// Rows are ordered by value column first and then by the order of the rows in the QFrame.
+//
+// Time complexity O(m * n) where m = number of value columns, n = number of rows.
+func (qf QFrame) Melt(idCols, valueCols []string, varName, valueName string) QFrame {
+	if qf.Err != nil {
+		return qf
+	}
+
+	if err := qf.checkColumns("Melt", append(append([]string{}, idCols...), valueCols...)); err != nil {
+		return qf.withErr(err)
+	}
+
+	if len(valueCols) == 0 {
+		idSet := qfstrings.NewStringSet(idCols)
+		for _, col := range qf.columns {
+			if !idSet.Contains(col.name) {
+				valueCols = append(valueCols, col.name)
+			}
+		}
+	}
+
+	if len(valueCols) == 0 {
+		return qf.withErr(qerrors.New("Melt", "no value columns"))
+	}
+
+	for _, name := range []string{varName, valueName} {
+		if err := qfstrings.CheckName(name); err != nil {
+			return qf.withErr(qerrors.Propagate("Melt", err))
+		}
+	}
+
+	valueType := qf.columnsByName[valueCols[0]].DataType()
+	valueColumns := make([]column.Column, len(valueCols))
+	for i, name := range valueCols {
+		col := qf.columnsByName[name]
+		if col.DataType() != valueType {
+			return qf.withErr(qerrors.New("Melt", "value columns must have the same type, %s is %s, %s is %s",
+				valueCols[0], valueType, name, col.DataType()))
+		}
+		valueColumns[i] = col.Subset(qf.index)
+	}
+
+	valueCol, err := valueColumns[0].Append(valueColumns[1:]...)
+	if err != nil {
+		return qf.withErr(qerrors.Propagate("Melt", err))
+	}
+
+	rowCount := len(valueCols) * qf.Len()
+	idIx := make(index.Int, 0, rowCount)
+	varData := make([]*string, 0, rowCount)
+	for i := range valueCols {
+		name := &valueCols[i]
+		for _, j := range qf.index {
+			idIx = append(idIx, j)
+			varData = append(varData, name)
+		}
+	}
+
+	// Fall back to a string column if the value column names do not fit in an enum
+	var varCol column.Column
+	varCol, err = ecolumn.New(varData, valueCols)
+	if err != nil {
+		varCol = scolumn.New(varData)
+	}
+
+	newColumns := make([]namedColumn, 0, len(idCols)+2)
+	for _, name := range idCols {
+		newColumns = append(newColumns, namedColumn{Column: qf.columnsByName[name].Subset(idIx), name: name})
+	}
+	newColumns = append(newColumns,
+		namedColumn{Column: varCol, name: varName},
+		namedColumn{Column: valueCol, name: valueName})
+
+	newColumnsByName := make(map[string]namedColumn, len(newColumns))
+	for i := range newColumns {
+		newColumns[i].pos = i
+		col := newColumns[i]
+		if _, ok := newColumnsByName[col.name]; ok {
+			return qf.withErr(qerrors.New("Melt", "duplicate column name in result: %s", col.name))
+		}
+		newColumnsByName[col.name] = col
+	}
+
+	return QFrame{columns: newColumns, columnsByName: newColumnsByName, index: index.NewAscending(uint32(rowCount))}
+}
@@ -0,0 +1,197 @@
+package qframe
+
+import (
+	"time"
+
+	"github.com/yistabraq/qframe/config/rolling"
+	"github.com/yistabraq/qframe/internal/bcolumn"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/ecolumn"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/internal/scolumn"
+	"github.com/yistabraq/qframe/internal/tcolumn"
+	"github.com/yistabraq/qframe/qerrors"
+	"github.com/yistabraq/qframe/types"
+)
+
+// Rolling applies fn to a rolling window over srcCol, in the current index order, and stores
+// the result in dstCol. fn may be any aggregation accepted by Grouper.Aggregate.
+//
+// By default the window contains one row, use rolling.WindowSize to change that and rolling.Position
+// to control where in the window the result is placed. For even window sizes a centered result
+// is placed on the row just after the middle of the window. Rows for which the window does not
+// fit within the QFrame are null unless a pad value has been set using rolling.PadValue.
+//
+// Alternatively the window can be defined dynamically based on the values of another column
+// using rolling.IntervalFunction. The result is then placed on the first row of the window.
+//
+// Time complexity O(m * n) where m = size of the window, n = number of rows.
+func (qf QFrame) Rolling(fn types.SliceFuncOrBuiltInId, dstCol, srcCol string, configFns ...rolling.ConfigFunc) QFrame {
+	return qf.rolling(fn, dstCol, srcCol, []index.Int{qf.index}, configFns)
+}
+
+func (qf QFrame) rolling(fn types.SliceFuncOrBuiltInId, dstCol, srcCol string, series []index.Int, configFns []rolling.ConfigFunc) QFrame {
+	if qf.Err != nil {
+		return qf
+	}
+
+	conf, err := rolling.NewConfig(configFns)
+	if err != nil {
+		return qf.withErr(err)
+	}
+
+	var inInterval func(start, row uint32) bool
+	if conf.IntervalFunc != nil {
+		intervalCol, ok := qf.columnsByName[conf.IntervalColName]
+		if !ok {
+			return qf.withErr(qerrors.New("Rolling", unknownCol(conf.IntervalColName)))
+		}
+
+		if inInterval, err = intervalFunc(intervalCol.Column, conf.IntervalFunc); err != nil {
+			return qf.withErr(qerrors.Propagate("Rolling", err))
+		}
+	}
+
+	return qf.applySeries("Rolling", dstCol, srcCol, series, func(col column.Column, series []index.Int) (column.Column, error) {
+		windows, ix := rollingWindows(uint32(col.Len()), series, conf, inInterval)
+		result, err := aggregate(col, windows, fn)
+		if err != nil {
+			return nil, err
+		}
+
+		resultLen := uint32(result.Len())
+		if conf.PadValue != nil {
+			padCol, err := padColumn(conf.PadValue, result)
+			if err != nil {
+				return nil, err
+			}
+
+			if result, err = result.Append(padCol); err != nil {
+				return nil, err
+			}
+
+			for _, s := range series {
+				for _, row := range s {
+					if ix[row] == noMatch {
+						ix[row] = resultLen
+					}
+				}
+			}
+		}
+
+		return subsetWithNull(result, ix, uint32(result.Len()))
+	})
+}
+
+// rollingWindows returns the windows, in the form of row indices, of all series together with
+// an index mapping each row to the position of its window. Rows without a complete window map
+// to noMatch.
+func rollingWindows(colLen uint32, series []index.Int, conf rolling.Config, inInterval func(start, row uint32) bool) ([]index.Int, index.Int) {
+	ix := make(index.Int, colLen)
+	for i := range ix {
+		ix[i] = noMatch
+	}
+
+	windows := make([]index.Int, 0, colLen)
+	for _, s := range series {
+		for k, row := range s {
+			var start, end int
+			if inInterval != nil {
+				start, end = k, k+1
+				for end < len(s) && inInterval(row, s[end]) {
+					end++
+				}
+			} else {
+				switch conf.Position {
+				case "start":
+					start = k
+				case "end":
+					start = k - conf.WindowSize + 1
+				default:
+					start = k - conf.WindowSize/2
+				}
+				end = start + conf.WindowSize
+			}
+
+			if start < 0 || end > len(s) {
+				continue
+			}
+
+			ix[row] = uint32(len(windows))
+			windows = append(windows, s[start:end])
+		}
+	}
+
+	return windows, ix
+}
+
+// intervalFunc returns a function that reports if row is part of the interval starting at start
+// according to fn which must match the type of col.
+func intervalFunc(col column.Column, fn rolling.IntervalFunc) (func(start, row uint32) bool, error) {
+	all := index.NewAscending(uint32(col.Len()))
+	switch c := col.(type) {
+	case icolumn.Column:
+		if f, ok := fn.(func(int, int) bool); ok {
+			v := c.View(all)
+			return func(start, row uint32) bool { return f(v.ItemAt(int(start)), v.ItemAt(int(row))) }, nil
+		}
+	case fcolumn.Column:
+		if f, ok := fn.(func(float64, float64) bool); ok {
+			v := c.View(all)
+			return func(start, row uint32) bool { return f(v.ItemAt(int(start)), v.ItemAt(int(row))) }, nil
+		}
+	case bcolumn.Column:
+		if f, ok := fn.(func(bool, bool) bool); ok {
+			v := c.View(all)
+			return func(start, row uint32) bool { return f(v.ItemAt(int(start)), v.ItemAt(int(row))) }, nil
+		}
+	case scolumn.Column:
+		if f, ok := fn.(func(*string, *string) bool); ok {
+			v := c.View(all)
+			return func(start, row uint32) bool { return f(v.ItemAt(int(start)), v.ItemAt(int(row))) }, nil
+		}
+	case ecolumn.Column:
+		if f, ok := fn.(func(*string, *string) bool); ok {
+			v := c.View(all)
+			return func(start, row uint32) bool { return f(v.ItemAt(int(start)), v.ItemAt(int(row))) }, nil
+		}
+	case tcolumn.Column:
+		if f, ok := fn.(func(time.Time, time.Time) bool); ok {
+			v := c.View(all)
+			return func(start, row uint32) bool { return f(v.ItemAt(int(start)), v.ItemAt(int(row))) }, nil
+		}
+	}
+
+	return nil, qerrors.New("intervalFunc", "invalid interval function type %T for %s column", fn, col.DataType())
+}
+
+// padColumn creates a column with the single value v that can be appended to like.
+func padColumn(v rolling.DataValue, like column.Column) (column.Column, error) {
+	if _, ok := like.(ecolumn.Column); ok {
+		switch t := v.(type) {
+		case string:
+			return ecolumn.New([]*string{&t}, nil)
+		case *string:
+			return ecolumn.New([]*string{t}, nil)
+		}
+	}
+
+	switch t := v.(type) {
+	case int:
+		return icolumn.New([]int{t}), nil
+	case float64:
+		return fcolumn.New([]float64{t}), nil
+	case bool:
+		return bcolumn.New([]bool{t}), nil
+	case string:
+		return scolumn.New([]*string{&t}), nil
+	case *string:
+		return scolumn.New([]*string{t}), nil
+	case time.Time:
+		return tcolumn.New([]time.Time{t}), nil
+	}
+
+	return nil, qerrors.New("padColumn", "invalid pad value type %T", v)
+}
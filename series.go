@@ -0,0 +1,278 @@
+package qframe
+
+import (
+	"math"
+
+	"github.com/yistabraq/qframe/internal/bitmap"
+	"github.com/yistabraq/qframe/internal/column"
+	"github.com/yistabraq/qframe/internal/fcolumn"
+	"github.com/yistabraq/qframe/internal/icolumn"
+	"github.com/yistabraq/qframe/internal/index"
+	"github.com/yistabraq/qframe/qerrors"
+)
+
+// A series is a sequence of rows, in index order, over which the operations in this file are
+// applied. A QFrame is a single series while a Grouper consists of one series per group.
+//
+// All operations produce a column with one value per row in the underlying column data so
+// that the result can be set directly on the QFrame without changing the index.
+
+// seriesFn computes a new column from col by processing each of the series independently.
+type seriesFn func(col column.Column, series []index.Int) (column.Column, error)
+
+func (qf QFrame) applySeries(op, dstCol, srcCol string, series []index.Int, fn seriesFn) QFrame {
+	if qf.Err != nil {
+		return qf
+	}
+
+	col, ok := qf.columnsByName[srcCol]
+	if !ok {
+		return qf.withErr(qerrors.New(op, unknownCol(srcCol)))
+	}
+
+	result, err := fn(col.Column, series)
+	if err != nil {
+		return qf.withErr(qerrors.Propagate(op, err))
+	}
+
+	return qf.setColumn(dstCol, result)
+}
+
+// Shift sets dstCol to the values of srcCol shifted n rows in the current index order.
+// A positive n lags the values (each row gets the value n rows before it) while a negative
+// n leads the values (each row gets the value n rows after it). Rows without a corresponding
+// value are set to null. Any column type may be shifted.
+//
+// Time complexity O(n) where n = number of rows.
+func (qf QFrame) Shift(dstCol, srcCol string, n int) QFrame {
+	return qf.applySeries("Shift", dstCol, srcCol, []index.Int{qf.index}, shiftFn(n))
+}
+
+// Diff sets dstCol to the difference between each value in srcCol and the value n rows
+// before it in the current index order. Negative values of n compute the difference to
+// the value n rows after. The result is null if any of the values is null or missing.
+// Only int and float columns are supported, the result has the same type as srcCol.
+//
+// Time complexity O(n) where n = number of rows.
+func (qf QFrame) Diff(dstCol, srcCol string, n int) QFrame {
+	return qf.applySeries("Diff", dstCol, srcCol, []index.Int{qf.index}, diffFn(n))
+}
+
+// PctChange sets dstCol to the relative change, (x - prev) / prev, between each value
+// in srcCol and the value n rows before it in the current index order. The result is
+// null if any of the values is null or missing. Only int and float columns are supported,
+// the result is a float column.
+//
+// Time complexity O(n) where n = number of rows.
+func (qf QFrame) PctChange(dstCol, srcCol string, n int) QFrame {
+	return qf.applySeries("PctChange", dstCol, srcCol, []index.Int{qf.index}, pctChangeFn(n))
+}
+
+// CumSum sets dstCol to the cumulative sum of srcCol in the current index order.
+// Null values are skipped and result in null. Only int and float columns are supported,
+// the result has the same type as srcCol.
+//
+// Time complexity O(n) where n = number of rows.
+func (qf QFrame) CumSum(dstCol, srcCol string) QFrame {
+	return qf.applySeries("CumSum", dstCol, srcCol, []index.Int{qf.index}, cumSum)
+}
+
+// CumMax sets dstCol to the cumulative maximum of srcCol in the current index order.
+// Null values are skipped and result in null. Any column type is supported, the result
+// has the same type as srcCol.
+//
+// Time complexity O(n) where n = number of rows.
+func (qf QFrame) CumMax(dstCol, srcCol string) QFrame {
+	return qf.applySeries("CumMax", dstCol, srcCol, []index.Int{qf.index}, cumBestFn(column.GreaterThan))
+}
+
+// CumMin sets dstCol to the cumulative minimum of srcCol in the current index order.
+// Null values are skipped and result in null. Any column type is supported, the result
+// has the same type as srcCol.
+//
+// Time complexity O(n) where n = number of rows.
+func (qf QFrame) CumMin(dstCol, srcCol string) QFrame {
+	return qf.applySeries("CumMin", dstCol, srcCol, []index.Int{qf.index}, cumBestFn(column.LessThan))
+}
+
+// CumCount sets dstCol to an int column numbering the rows in the current index order,
+// starting at 0.
+//
+// Time complexity O(n) where n = number of rows.
+func (qf QFrame) CumCount(dstCol string) QFrame {
+	if qf.Err != nil {
+		return qf
+	}
+
+	return qf.setColumn(dstCol, cumCount(qf.columnLen(), []index.Int{qf.index}))
+}
+
+func shiftFn(n int) seriesFn {
+	return func(col column.Column, series []index.Int) (column.Column, error) {
+		return subsetWithNull(col, shiftIndex(uint32(col.Len()), series, n), uint32(col.Len()))
+	}
+}
+
+// shiftIndex returns an index of length colLen mapping each row to the row n steps before
+// it in its series, or noMatch if there is no such row.
+func shiftIndex(colLen uint32, series []index.Int, n int) index.Int {
+	ix := make(index.Int, colLen)
+	for i := range ix {
+		ix[i] = noMatch
+	}
+
+	for _, s := range series {
+		for k, row := range s {
+			if src := k - n; src >= 0 && src < len(s) {
+				ix[row] = s[src]
+			}
+		}
+	}
+
+	return ix
+}
+
+func diffFn(n int) seriesFn {
+	return func(col column.Column, series []index.Int) (column.Column, error) {
+		colLen := uint32(col.Len())
+		prev := shiftIndex(colLen, series, n)
+		if c, ok := col.(icolumn.Column); ok {
+			view := c.View(index.NewAscending(colLen))
+			data := make([]int, colLen)
+			var valid bitmap.Bitmap
+			for i, p := range prev {
+				if p == noMatch || view.IsNull(i) || view.IsNull(int(p)) {
+					if valid == nil {
+						valid = bitmap.New(int(colLen))
+					}
+					valid.SetNull(uint32(i))
+					continue
+				}
+
+				data[i] = view.ItemAt(i) - view.ItemAt(int(p))
+			}
+
+			return icolumn.NewNullable(data, valid), nil
+		}
+
+		return floatSeries(col, prev, func(x, p float64) float64 { return x - p })
+	}
+}
+
+func pctChangeFn(n int) seriesFn {
+	return func(col column.Column, series []index.Int) (column.Column, error) {
+		prev := shiftIndex(uint32(col.Len()), series, n)
+		return floatSeries(col, prev, func(x, p float64) float64 { return (x - p) / p })
+	}
+}
+
+// floatSeries computes fn for each row and the row in prev of an int or float column. The
+// result is a float column.
+func floatSeries(col column.Column, prev index.Int, fn func(x, p float64) float64) (column.Column, error) {
+	valueAt, err := floatValueAt(col)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]float64, len(prev))
+	for i, p := range prev {
+		data[i] = math.NaN()
+		if p == noMatch {
+			continue
+		}
+
+		x, xOk := valueAt(uint32(i))
+		pv, pOk := valueAt(p)
+		if xOk && pOk {
+			data[i] = fn(x, pv)
+		}
+	}
+
+	return fcolumn.New(data), nil
+}
+
+func cumSum(col column.Column, series []index.Int) (column.Column, error) {
+	colLen := col.Len()
+	switch c := col.(type) {
+	case icolumn.Column:
+		view := c.View(index.NewAscending(uint32(colLen)))
+		data := make([]int, colLen)
+		var valid bitmap.Bitmap
+		for _, s := range series {
+			sum := 0
+			for _, row := range s {
+				if view.IsNull(int(row)) {
+					if valid == nil {
+						valid = bitmap.New(colLen)
+					}
+					valid.SetNull(row)
+					continue
+				}
+
+				sum += view.ItemAt(int(row))
+				data[row] = sum
+			}
+		}
+
+		return icolumn.NewNullable(data, valid), nil
+	case fcolumn.Column:
+		view := c.View(index.NewAscending(uint32(colLen)))
+		data := make([]float64, colLen)
+		for _, s := range series {
+			sum := 0.0
+			for _, row := range s {
+				v := view.ItemAt(int(row))
+				if math.IsNaN(v) {
+					data[row] = v
+					continue
+				}
+
+				sum += v
+				data[row] = sum
+			}
+		}
+
+		return fcolumn.New(data), nil
+	}
+
+	return nil, qerrors.New("cumSum", "only defined for int and float columns, not %s", col.DataType())
+}
+
+// cumBestFn returns a function that keeps track of the best value seen so far, a value is
+// better than the current best if comparing it to the current best results in better.
+func cumBestFn(better column.CompareResult) seriesFn {
+	return func(col column.Column, series []index.Int) (column.Column, error) {
+		comp := col.Comparable(false, false, false)
+		ix := make(index.Int, col.Len())
+		for i := range ix {
+			ix[i] = noMatch
+		}
+
+		for _, s := range series {
+			best := uint32(noMatch)
+			for _, row := range s {
+				if isNull(comp, row) {
+					continue
+				}
+
+				if best == noMatch || comp.Compare(row, best) == better {
+					best = row
+				}
+				ix[row] = best
+			}
+		}
+
+		return subsetWithNull(col, ix, uint32(col.Len()))
+	}
+}
+
+func cumCount(colLen uint32, series []index.Int) column.Column {
+	data := make([]int, colLen)
+	for _, s := range series {
+		for k, row := range s {
+			data[row] = k
+		}
+	}
+
+	return icolumn.New(data)
+}
@@ -13,10 +13,14 @@ DataSlice can be a slice of any of the supported data types.
 
 The following types are currently supported:
 	[]bool
+	[]*bool
 	[]float64
 	[]int
+	[]*int
 	[]string
 	[]*string
+	[]time.Time
+	[]*time.Time
 */
 type DataSlice = interface{}
 
@@ -28,6 +32,7 @@ For example:
 	func(x []int) int
 	func(x []*string) *string
 	func(x []bool) bool
+	func(x []time.Time) time.Time
 
 Or it can be a string identifying a built in function.
 
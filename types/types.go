@@ -8,7 +8,8 @@ const (
 	// This is mainly used to indicate that the type of a column should be auto detected.
 	None DataType = ""
 
-	// Int translates into the Go int type. Missing values cannot be represented explicitly.
+	// Int translates into the Go int type. Missing values are tracked separately from the data
+	// and can be detected using the isnull filter or the IsNull function of IntView.
 	Int = "int"
 
 	// String translates into the Go *string type. nil represents a missing value.
@@ -19,13 +20,20 @@ const (
 	// Float translates into the Go float64 type. NaN represents a missing value.
 	Float = "float"
 
-	// Bool translates into the Go bool type. Missing values cannot be represented explicitly.
+	// Bool translates into the Go bool type. Missing values are tracked separately from the data
+	// and can be detected using the isnull filter or the IsNull function of BoolView.
 	Bool = "bool"
 
 	// Enum translates into the Go *string type. nil represents a missing value.
-	// An enum column can, at most, have 254 distinct values.
+	// Values are stored as 8, 16 or 32 bit codes depending on the number of distinct values in the column.
 	Enum = "enum"
 
+	// Time translates into the Go time.Time type. Internally the time is stored as nanoseconds
+	// since the Unix epoch together with the location of the column. Missing values are tracked
+	// separately from the data and can be detected using the isnull filter or the IsNull function
+	// of TimeView.
+	Time = "time"
+
 	// Undefined represents an unspecified data type.
 	// This is used for zero length columns where the datatype could not be identified.
 	Undefined DataType = "Undefined"
@@ -40,6 +48,7 @@ const (
 	FunctionTypeFloat
 	FunctionTypeBool
 	FunctionTypeString
+	FunctionTypeTime
 )
 
 func (t FunctionType) String() string {
@@ -52,6 +61,8 @@ func (t FunctionType) String() string {
 		return "String function"
 	case FunctionTypeFloat:
 		return "Float function"
+	case FunctionTypeTime:
+		return "Time function"
 	case FunctionTypeUndefined:
 		return "Undefined type function"
 	default: